@@ -0,0 +1,106 @@
+// Package alerts raises budget alerts when an expense pushes a category past
+// one of its thresholds, and sends them through the configured dispatchers.
+//
+// Alerts are persisted by the store before anything is sent, and each
+// threshold fires once per category and month, so a failed notification is
+// logged but never retried nor repeated.
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-sheet/config"
+	"go-sheet/store"
+	"log"
+	"time"
+)
+
+// MaxThreshold bounds thresholds, in percent of the planned amount
+const MaxThreshold = 1000
+
+// dispatchTimeout bounds how long the dispatchers of one evaluation may take
+const dispatchTimeout = 30 * time.Second
+
+// Dispatcher sends an alert somewhere people will see it
+type Dispatcher interface {
+	Dispatch(ctx context.Context, alert store.Alert) error
+}
+
+// Dispatchers sends every alert through each of its dispatchers
+type Dispatchers []Dispatcher
+
+func (d Dispatchers) Dispatch(ctx context.Context, alert store.Alert) error {
+	var errs []error
+	for _, dispatcher := range d {
+		errs = append(errs, dispatcher.Dispatch(ctx, alert))
+	}
+	return errors.Join(errs...)
+}
+
+// Open returns the dispatchers enabled in cfg. members supplies the email
+// recipients when cfg.SMTP.To is empty.
+func Open(cfg config.Alerts, members MemberLister) Dispatchers {
+	var dispatchers Dispatchers
+	if cfg.Webhook.URL != "" {
+		dispatchers = append(dispatchers, NewWebhook(cfg.Webhook))
+	}
+	if cfg.SMTP.Addr != "" {
+		dispatchers = append(dispatchers, NewSMTP(cfg.SMTP, members))
+	}
+	return dispatchers
+}
+
+// Service evaluates alerts and dispatches the ones raised
+type Service struct {
+	store      store.AlertStore
+	dispatcher Dispatcher
+}
+
+// NewService returns a Service; dispatcher may be nil to only record alerts
+func NewService(s store.AlertStore, dispatcher Dispatcher) *Service {
+	return &Service{store: s, dispatcher: dispatcher}
+}
+
+// Check evaluates the categories touched by the given expenses and returns
+// the alerts raised. The expenses are already saved by then, so errors are
+// logged rather than returned, and dispatching happens in the background.
+func (s *Service) Check(ctx context.Context, workspaceID string, expenseIDs ...string) []store.Alert {
+	raised, err := s.store.EvaluateAlerts(ctx, workspaceID, expenseIDs)
+	if err != nil {
+		log.Printf("alerts: evaluating workspace %s: %v", workspaceID, err)
+		return []store.Alert{}
+	}
+
+	if len(raised) > 0 && s.dispatcher != nil {
+		go s.dispatch(raised)
+	}
+	return raised
+}
+
+func (s *Service) dispatch(alerts []store.Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), dispatchTimeout)
+	defer cancel()
+
+	for _, alert := range alerts {
+		if err := s.dispatcher.Dispatch(ctx, alert); err != nil {
+			log.Printf("alerts: dispatching %s: %v", alert.ID, err)
+		}
+	}
+}
+
+// ValidateThresholds checks the percentages of a category
+func ValidateThresholds(percents []int) error {
+	for _, percent := range percents {
+		if percent < 1 || percent > MaxThreshold {
+			return fmt.Errorf("thresholds must be between 1 and %d, got %d", MaxThreshold, percent)
+		}
+	}
+	return nil
+}
+
+// Summary is the one-line description used as notification subject
+func Summary(alert store.Alert) string {
+	return fmt.Sprintf("%s %s: spent %s of %s planned (%d%% threshold)", alert.CategoryName, alert.ReferenceMonth,
+		alert.SpentAmount, alert.PlannedAmount, alert.Threshold)
+}
@@ -0,0 +1,152 @@
+package alerts
+
+import (
+	"context"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"go-sheet/store/memory"
+	"slices"
+	"testing"
+	"time"
+)
+
+const workspaceID = "11111111-1111-1111-1111-111111111111"
+
+// recorder is a Dispatcher that hands the alerts over a channel
+type recorder chan store.Alert
+
+func (r recorder) Dispatch(ctx context.Context, alert store.Alert) error {
+	r <- alert
+	return nil
+}
+
+type fixture struct {
+	store      *memory.Store
+	categoryID string
+	paidID     string
+	month      month.YearMonth
+}
+
+func newFixture(t *testing.T, planned money.Amount, thresholds ...int) fixture {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+	s := memory.New()
+	s.SetClock(func() time.Time { return now })
+	categoryID, err := s.CreateCategory(ctx, workspaceID, store.CategoryInput{Name: "Groceries", PlannedAmount: planned})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetAlertThresholds(ctx, workspaceID, categoryID, thresholds); err != nil {
+		t.Fatal(err)
+	}
+	paidType, err := s.CreatePaidType(ctx, workspaceID, store.PaidType{Type: "Card"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fixture{store: s, categoryID: categoryID, paidID: paidType.ID, month: month.Of(now)}
+}
+
+func (f fixture) spend(t *testing.T, amount money.Amount) string {
+	t.Helper()
+	id, err := f.store.CreateExpense(context.Background(), workspaceID, store.ExpenseInput{
+		CategoryID:     f.categoryID,
+		ReferenceMonth: f.month,
+		PaidID:         f.paidID,
+		SpentAmount:    amount,
+		PaymentDate:    time.Date(2026, time.October, 5, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func thresholdsOf(alerts []store.Alert) []int {
+	percents := []int{}
+	for _, alert := range alerts {
+		percents = append(percents, alert.Threshold)
+	}
+	return percents
+}
+
+func TestCheckRaisesEachThresholdOnce(t *testing.T) {
+	f := newFixture(t, money.FromMinor(100_00), 50, 100)
+	service := NewService(f.store, nil)
+
+	steps := []struct {
+		spent money.Amount
+		want  []int
+	}{
+		{money.FromMinor(40_00), []int{}},
+		{money.FromMinor(20_00), []int{50}},
+		{money.FromMinor(10_00), []int{}},
+		{money.FromMinor(30_00), []int{100}},
+		{money.FromMinor(50_00), []int{}},
+	}
+	for i, step := range steps {
+		raised := service.Check(context.Background(), workspaceID, f.spend(t, step.spent))
+		if got := thresholdsOf(raised); !slices.Equal(got, step.want) {
+			t.Fatalf("step %d: raised %v, want %v", i, got, step.want)
+		}
+	}
+}
+
+func TestCheckRaisesEveryThresholdCrossedAtOnce(t *testing.T) {
+	f := newFixture(t, money.FromMinor(100_00), 100, 50, 80)
+
+	raised := NewService(f.store, nil).Check(context.Background(), workspaceID, f.spend(t, money.FromMinor(150_00)))
+	if got := thresholdsOf(raised); !slices.Equal(got, []int{50, 80, 100}) {
+		t.Fatalf("raised %v, want [50 80 100]", got)
+	}
+	alert := raised[0]
+	if alert.CategoryName != "Groceries" || alert.SpentAmount != money.FromMinor(150_00) || alert.PlannedAmount != money.FromMinor(100_00) {
+		t.Errorf("alert = %+v", alert)
+	}
+}
+
+func TestCheckSkipsCategoryWithoutPlannedAmount(t *testing.T) {
+	f := newFixture(t, 0, 50)
+
+	raised := NewService(f.store, nil).Check(context.Background(), workspaceID, f.spend(t, money.FromMinor(10_00)))
+	if len(raised) != 0 {
+		t.Fatalf("raised %v, want none", raised)
+	}
+}
+
+func TestCheckDispatchesRaisedAlerts(t *testing.T) {
+	f := newFixture(t, money.FromMinor(100_00), 90)
+	dispatched := make(recorder, 1)
+
+	raised := NewService(f.store, dispatched).Check(context.Background(), workspaceID, f.spend(t, money.FromMinor(95_00)))
+	if len(raised) != 1 {
+		t.Fatalf("raised %v, want one alert", raised)
+	}
+	select {
+	case alert := <-dispatched:
+		if alert.ID != raised[0].ID {
+			t.Errorf("dispatched %s, want %s", alert.ID, raised[0].ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("alert was not dispatched")
+	}
+}
+
+func TestValidateThresholds(t *testing.T) {
+	tests := []struct {
+		percents []int
+		ok       bool
+	}{
+		{nil, true},
+		{[]int{1, 50, MaxThreshold}, true},
+		{[]int{0}, false},
+		{[]int{50, MaxThreshold + 1}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateThresholds(tt.percents); (err == nil) != tt.ok {
+			t.Errorf("ValidateThresholds(%v) = %v", tt.percents, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package alerts
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"go-sheet/config"
+	"go-sheet/store"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// MemberLister finds who to email about a workspace
+type MemberLister interface {
+	ListMembers(ctx context.Context, workspaceID string) ([]store.Member, error)
+}
+
+// SMTP emails alerts, to the configured recipients or else to every member
+// of the workspace that has an email
+type SMTP struct {
+	addr    string
+	auth    smtp.Auth
+	from    string // header form, e.g. "go-sheet <alerts@example.com>"
+	sender  string // envelope address
+	to      []string
+	members MemberLister
+}
+
+// NewSMTP returns an SMTP dispatcher for the given settings. Credentials are
+// optional, for relays such as a local Mailpit that accept anyone.
+func NewSMTP(cfg config.SMTP, members MemberLister) *SMTP {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(cfg.Addr)
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	sender := cfg.From
+	if address, err := mail.ParseAddress(cfg.From); err == nil {
+		sender = address.Address
+	}
+	return &SMTP{addr: cfg.Addr, auth: auth, from: cfg.From, sender: sender, to: cfg.To, members: members}
+}
+
+func (s *SMTP) Dispatch(ctx context.Context, alert store.Alert) error {
+	to, err := s.recipients(ctx, alert.WorkspaceID)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	if err := s.send(ctx, to, s.message(alert, to)); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return nil
+}
+
+// send does what smtp.SendMail does, but over a connection bound to ctx:
+// the dial and the whole conversation stop at its deadline or cancellation
+func (s *SMTP) send(ctx context.Context, to []string, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(s.addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err := client.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.sender); err != nil {
+		return err
+	}
+	for _, address := range to {
+		if err := client.Rcpt(address); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (s *SMTP) recipients(ctx context.Context, workspaceID string) ([]string, error) {
+	if len(s.to) > 0 || s.members == nil {
+		return s.to, nil
+	}
+
+	members, err := s.members.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	var to []string
+	for _, member := range members {
+		if member.Email != "" {
+			to = append(to, member.Email)
+		}
+	}
+	return to, nil
+}
+
+func (s *SMTP) message(alert store.Alert, to []string) []byte {
+	var b strings.Builder
+	header := func(name, value string) {
+		b.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", s.from)
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", "Budget alert: "+Summary(alert)))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	b.WriteString("\r\n")
+
+	fmt.Fprintf(&b, "%s has reached %d%% of its planned amount for %s.\r\n\r\n", alert.CategoryName, alert.Threshold, alert.ReferenceMonth)
+	fmt.Fprintf(&b, "Planned: %s\r\nSpent:   %s\r\n", alert.PlannedAmount, alert.SpentAmount)
+	return []byte(b.String())
+}
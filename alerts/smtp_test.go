@@ -0,0 +1,122 @@
+package alerts
+
+import (
+	"bufio"
+	"context"
+	"go-sheet/config"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP answers one SMTP session without extensions and returns what
+// the client sent: the envelope commands and the message
+func fakeSMTP(l net.Listener) <-chan []string {
+	session := make(chan []string, 1)
+	go func() {
+		var lines []string
+		defer func() { session <- lines }()
+
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 fake ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			lines = append(lines, line)
+			switch command := strings.ToUpper(strings.Fields(line + " ")[0]); command {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				body, err := tp.ReadDotLines()
+				if err != nil {
+					return
+				}
+				lines = append(lines, body...)
+				tp.PrintfLine("250 queued")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				return
+			default:
+				tp.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+	return session
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestSMTPSendsAlert(t *testing.T) {
+	l := listen(t)
+	session := fakeSMTP(l)
+
+	cfg := config.SMTP{Addr: l.Addr().String(), From: "go-sheet <alerts@example.com>", To: []string{"ana@example.com", "bia@example.com"}}
+	if err := NewSMTP(cfg, nil).Dispatch(context.Background(), testAlert); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := <-session
+	sent := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"MAIL FROM:<alerts@example.com>",
+		"RCPT TO:<ana@example.com>",
+		"RCPT TO:<bia@example.com>",
+		"To: ana@example.com, bia@example.com",
+		"Groceries has reached 80% of its planned amount",
+		"QUIT",
+	} {
+		if !strings.Contains(sent, want) {
+			t.Errorf("session lacks %q:\n%s", want, sent)
+		}
+	}
+}
+
+func TestSMTPStopsAtContextDeadline(t *testing.T) {
+	// The server accepts the connection but never greets
+	l := listen(t)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			bufio.NewReader(conn).ReadString('\n')
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := NewSMTP(config.SMTP{Addr: l.Addr().String(), From: "alerts@example.com", To: []string{"ana@example.com"}}, nil).Dispatch(ctx, testAlert)
+	if err == nil {
+		t.Fatal("Dispatch succeeded against a silent server")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Dispatch took %s, want it bounded by the context", elapsed)
+	}
+}
+
+func TestSMTPWithoutRecipientsSendsNothing(t *testing.T) {
+	// No server listens on the address, so any attempt would fail
+	err := NewSMTP(config.SMTP{Addr: "127.0.0.1:1", From: "alerts@example.com"}, nil).Dispatch(context.Background(), testAlert)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,69 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-sheet/config"
+	"go-sheet/store"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the body, as "sha256=<hex>",
+// when the webhook has a secret
+const SignatureHeader = "X-Go-Sheet-Signature"
+
+// WebhookEvent is the JSON body posted to webhooks
+type WebhookEvent struct {
+	Event   string      `json:"event"`
+	Summary string      `json:"summary"`
+	Alert   store.Alert `json:"alert"`
+}
+
+// Webhook posts alerts as JSON to a URL
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhook returns a Webhook for the given settings
+func NewWebhook(cfg config.Webhook) *Webhook {
+	return &Webhook{url: cfg.URL, secret: []byte(cfg.Secret), client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *Webhook) Dispatch(ctx context.Context, alert store.Alert) error {
+	body, err := json.Marshal(WebhookEvent{Event: "budget.alert", Summary: Summary(alert), Alert: alert})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s answered %s", w.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package alerts
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-sheet/config"
+	"go-sheet/money"
+	"go-sheet/store"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testAlert = store.Alert{
+	ID:            "alert-1",
+	WorkspaceID:   workspaceID,
+	CategoryName:  "Groceries",
+	Threshold:     80,
+	SpentAmount:   money.FromMinor(85_00),
+	PlannedAmount: money.FromMinor(100_00),
+}
+
+func TestWebhookSignsBody(t *testing.T) {
+	secret := "s3cret"
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(config.Webhook{URL: server.URL, Secret: secret}).Dispatch(context.Background(), testAlert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if got, want := header.Get(SignatureHeader), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Event != "budget.alert" || event.Alert.ID != testAlert.ID || event.Summary != Summary(testAlert) {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestWebhookWithoutSecretIsUnsigned(t *testing.T) {
+	signed := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, signed = r.Header[SignatureHeader]
+	}))
+	defer server.Close()
+
+	if err := NewWebhook(config.Webhook{URL: server.URL}).Dispatch(context.Background(), testAlert); err != nil {
+		t.Fatal(err)
+	}
+	if signed {
+		t.Errorf("%s sent without a secret", SignatureHeader)
+	}
+}
+
+func TestWebhookFailsOnNon2xx(t *testing.T) {
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusBadRequest, http.StatusInternalServerError} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		err := NewWebhook(config.Webhook{URL: server.URL}).Dispatch(context.Background(), testAlert)
+		if err == nil || !strings.Contains(err.Error(), http.StatusText(status)) {
+			t.Errorf("status %d: err = %v", status, err)
+		}
+		server.Close()
+	}
+}
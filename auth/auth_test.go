@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/me", Middleware(testVerifier()), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, UserID(ctx))
+	})
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	good := mustSign(t, testSecret, validClaims())
+	expired := validClaims()
+	expired.ExpiresAt = testNow.Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantBody      string
+	}{
+		{"valid", "Bearer " + good, http.StatusOK, validClaims().Subject},
+		{"lowercase scheme", "bearer " + good, http.StatusOK, validClaims().Subject},
+		{"missing header", "", http.StatusUnauthorized, "Missing bearer token"},
+		{"empty token", "Bearer ", http.StatusUnauthorized, "Missing bearer token"},
+		{"basic scheme", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, "Missing bearer token"},
+		{"no scheme", good, http.StatusUnauthorized, "Missing bearer token"},
+		{"invalid token", "Bearer x.y.z", http.StatusUnauthorized, "Invalid or expired token"},
+		{"expired token", "Bearer " + mustSign(t, testSecret, expired), http.StatusUnauthorized, "Invalid or expired token"},
+	}
+	r := newTestRouter()
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+		if body := w.Body.String(); !strings.Contains(body, tt.wantBody) {
+			t.Errorf("%s: body = %s, want it to contain %q", tt.name, body, tt.wantBody)
+		}
+	}
+}
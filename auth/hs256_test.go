@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testNow    = time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+)
+
+func testVerifier() *HS256Verifier {
+	v := NewHS256Verifier(testSecret, "authenticated")
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func validClaims() Claims {
+	return Claims{
+		Subject:   "11111111-1111-1111-1111-111111111111",
+		Email:     "ana@example.com",
+		Role:      "authenticated",
+		Audience:  audience{"authenticated"},
+		ExpiresAt: testNow.Add(time.Hour).Unix(),
+		IssuedAt:  testNow.Unix(),
+	}
+}
+
+func mustSign(t *testing.T, secret []byte, claims Claims) string {
+	t.Helper()
+	token, err := SignHS256(secret, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifyGoodToken(t *testing.T) {
+	identity, err := testVerifier().Verify(context.Background(), mustSign(t, testSecret, validClaims()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Identity{UserID: "11111111-1111-1111-1111-111111111111", Email: "ana@example.com", Role: "authenticated"}
+	if identity != want {
+		t.Errorf("identity = %+v, want %+v", identity, want)
+	}
+}
+
+func TestVerifyAudienceForms(t *testing.T) {
+	tests := []struct {
+		name string
+		aud  audience
+		ok   bool
+	}{
+		{"single", audience{"authenticated"}, true},
+		{"list", audience{"other", "authenticated"}, true},
+		{"wrong", audience{"anon"}, false},
+		{"missing", nil, false},
+	}
+	for _, tt := range tests {
+		claims := validClaims()
+		claims.Audience = tt.aud
+		_, err := testVerifier().Verify(context.Background(), mustSign(t, testSecret, claims))
+		if (err == nil) != tt.ok {
+			t.Errorf("%s audience: err = %v", tt.name, err)
+		}
+	}
+
+	// Without a configured audience any aud is accepted
+	v := testVerifier()
+	v.audience = ""
+	claims := validClaims()
+	claims.Audience = audience{"anon"}
+	if _, err := v.Verify(context.Background(), mustSign(t, testSecret, claims)); err != nil {
+		t.Errorf("no audience configured: %v", err)
+	}
+}
+
+func TestVerifyTimes(t *testing.T) {
+	tests := []struct {
+		name    string
+		exp     time.Time
+		nbf     time.Time
+		wantErr string
+	}{
+		{name: "expired within leeway", exp: testNow.Add(-leeway / 2)},
+		{name: "expired past leeway", exp: testNow.Add(-leeway - time.Second), wantErr: "expired"},
+		{name: "no exp", wantErr: "expired"},
+		{name: "nbf within leeway", exp: testNow.Add(time.Hour), nbf: testNow.Add(leeway / 2)},
+		{name: "nbf past leeway", exp: testNow.Add(time.Hour), nbf: testNow.Add(leeway + time.Second), wantErr: "not valid yet"},
+		{name: "nbf in the past", exp: testNow.Add(time.Hour), nbf: testNow.Add(-time.Hour)},
+	}
+	for _, tt := range tests {
+		claims := validClaims()
+		claims.ExpiresAt, claims.NotBefore = 0, 0
+		if !tt.exp.IsZero() {
+			claims.ExpiresAt = tt.exp.Unix()
+		}
+		if !tt.nbf.IsZero() {
+			claims.NotBefore = tt.nbf.Unix()
+		}
+
+		_, err := testVerifier().Verify(context.Background(), mustSign(t, testSecret, claims))
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr != "" && (!errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	good := mustSign(t, testSecret, validClaims())
+	parts := strings.Split(good, ".")
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	// resign signs a token with a custom header, so only the header is wrong
+	resign := func(header string) string {
+		unsigned := encode(header) + "." + parts[1]
+		return unsigned + "." + sign(testSecret, unsigned)
+	}
+
+	noSubject := validClaims()
+	noSubject.Subject = ""
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"bad signature", parts[0] + "." + parts[1] + "." + sign([]byte("another secret"), parts[0]+"."+parts[1]), "bad signature"},
+		{"other secret", mustSign(t, []byte("another secret"), validClaims()), "bad signature"},
+		{"tampered claims", parts[0] + "." + encode(`{"sub":"someone-else","aud":"authenticated","exp":9999999999}`) + "." + parts[2], "bad signature"},
+		{"alg none", encode(`{"alg":"none","typ":"JWT"}`) + "." + parts[1] + ".", "unsupported header"},
+		{"alg HS512", resign(`{"alg":"HS512","typ":"JWT"}`), "unsupported header"},
+		{"alg RS256", resign(`{"alg":"RS256","typ":"JWT"}`), "unsupported header"},
+		{"missing sub", mustSign(t, testSecret, noSubject), "no subject"},
+		{"two segments", parts[0] + "." + parts[1], "malformed"},
+		{"garbage", "not-a-token", "malformed"},
+	}
+	for _, tt := range tests {
+		_, err := testVerifier().Verify(context.Background(), tt.token)
+		if !errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
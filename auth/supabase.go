@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	supabase "github.com/nedpals/supabase-go"
+)
+
+// SupabaseVerifier validates tokens by asking GoTrue for their user. It
+// needs no JWT secret but costs one HTTP round trip per request.
+type SupabaseVerifier struct {
+	client *supabase.Client
+}
+
+// NewSupabaseVerifier returns a verifier for the project at url, using its
+// anon (public) key
+func NewSupabaseVerifier(url, key string) *SupabaseVerifier {
+	return &SupabaseVerifier{client: supabase.CreateClient(url, key)}
+}
+
+func (v *SupabaseVerifier) Verify(ctx context.Context, token string) (Identity, error) {
+	user, err := v.client.Auth.User(ctx, token)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if user.ID == "" {
+		return Identity{}, fmt.Errorf("%w: no user", ErrInvalidToken)
+	}
+
+	return Identity{UserID: user.ID, Email: user.Email, Role: user.Role}, nil
+}
@@ -0,0 +1,63 @@
+// Package blob stores opaque files, such as receipts, by key.
+//
+// Keys are slash-separated paths made of letters, digits, ".", "-" and "_"
+// (e.g. "<workspace>/<expense>/<uuid>.pdf"). Local keeps them on disk and
+// S3 in any S3-compatible object store.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-sheet/config"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned when no blob has the key
+var ErrNotFound = errors.New("blob not found")
+
+// Info describes a stored blob
+type Info struct {
+	ContentType string
+	Size        int64
+}
+
+// Store keeps blobs by key
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any blob there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens a blob; the caller closes the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	// Delete removes a blob; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Open returns the Store configured for receipts
+func Open(cfg config.Receipts) (Store, error) {
+	switch cfg.Backend {
+	case "local":
+		return NewLocal(cfg.Dir), nil
+	case "s3":
+		return NewS3(cfg.S3)
+	}
+	return nil, fmt.Errorf("unknown blob backend %q", cfg.Backend)
+}
+
+// checkKey rejects keys that could escape the store's root or need escaping
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+		for _, r := range segment {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+				return fmt.Errorf("invalid blob key %q", key)
+			}
+		}
+	}
+	return nil
+}
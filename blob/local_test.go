@@ -0,0 +1,95 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func read(t *testing.T, s Store, key string) (string, Info) {
+	t.Helper()
+	r, info, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	defer r.Close()
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body), info
+}
+
+func TestLocalPutGetDelete(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLocal(filepath.Join(dir, "receipts"))
+	ctx := context.Background()
+	key := "ws-1/expense-1/receipt.png"
+
+	if err := l.Put(ctx, key, strings.NewReader("png bytes"), 9, "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if body, info := read(t, l, key); body != "png bytes" || info.ContentType != "image/png" || info.Size != 9 {
+		t.Errorf("Get = %q, %+v", body, info)
+	}
+
+	// Replacing a blob keeps only the new content
+	if err := l.Put(ctx, key, strings.NewReader("new"), 3, "image/png"); err != nil {
+		t.Fatalf("Put over an existing blob: %v", err)
+	}
+	if body, _ := read(t, l, key); body != "new" {
+		t.Errorf("after replacing, Get = %q, want %q", body, "new")
+	}
+
+	if err := l.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := l.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := l.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+}
+
+func TestLocalPutChecksSize(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLocal(dir)
+	ctx := context.Background()
+
+	for _, content := range []string{"short", "much too long"} {
+		if err := l.Put(ctx, "a/b.pdf", strings.NewReader(content), 8, "application/pdf"); err == nil {
+			t.Errorf("Put of %d bytes announced as 8 succeeded", len(content))
+		}
+	}
+	// Neither the blob nor a temporary file is left behind
+	entries, _ := os.ReadDir(filepath.Join(dir, "a"))
+	if len(entries) != 0 {
+		t.Errorf("left %d files behind", len(entries))
+	}
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLocal(filepath.Join(dir, "root"))
+	ctx := context.Background()
+
+	for _, key := range []string{"../escaped.pdf", "a/../../escaped.pdf", "/etc/passwd", "a\\..\\b.pdf", ""} {
+		if err := l.Put(ctx, key, strings.NewReader("x"), 1, "application/pdf"); err == nil {
+			t.Errorf("Put(%q) accepted a key outside the root", key)
+		}
+		if _, _, err := l.Get(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q): err = %v, want an invalid key error", key, err)
+		}
+		if err := l.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) accepted a key outside the root", key)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped.pdf")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a blob was written outside the root: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"go-sheet/config"
+	"go-sheet/month"
+	"go-sheet/sheet"
+	"go-sheet/store/postgres"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func runExport(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	workspaceID := flags.String("workspace", "", "workspace to export (required)")
+	format := flags.String("format", "", "csv or xlsx (default: from the -o extension, else csv)")
+	table := flags.String("table", "expenses", "table of a CSV export: expenses or summary")
+	monthFlag := flags.String("month", "", "month to export, YYYY-MM (default: current month)")
+	from := flags.String("from", "", "first month of a range, YYYY-MM")
+	to := flags.String("to", "", "last month of a range, YYYY-MM")
+	output := flags.String("o", "", "file to write (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *workspaceID == "" {
+		return errors.New("-workspace is required")
+	}
+	if *monthFlag != "" && (*from != "" || *to != "") {
+		return errors.New("use either -month or -from/-to, not both")
+	}
+	if (*from == "") != (*to == "") {
+		return errors.New("-from and -to must be given together")
+	}
+
+	req := sheet.Request{WorkspaceID: *workspaceID, From: month.Current(), To: month.Current()}
+	var err error
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*output), ".")
+		if *format != string(sheet.XLSX) {
+			*format = string(sheet.CSV)
+		}
+	}
+	if req.Format, err = sheet.ParseFormat(*format); err != nil {
+		return err
+	}
+	if req.Table, err = sheet.ParseTable(*table); err != nil {
+		return err
+	}
+	if *monthFlag != "" {
+		if req.From, err = parseMonthFlag("-month", *monthFlag); err != nil {
+			return err
+		}
+		req.To = req.From
+	}
+	if *from != "" {
+		if req.From, err = parseMonthFlag("-from", *from); err != nil {
+			return err
+		}
+		if req.To, err = parseMonthFlag("-to", *to); err != nil {
+			return err
+		}
+		if req.To.Before(req.From) {
+			return errors.New("-to must not be before -from")
+		}
+	}
+
+	conn, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	buffered := bufio.NewWriter(out)
+	if err := sheet.Write(context.Background(), buffered, postgres.New(conn, cfg.Currency), req); err != nil {
+		return err
+	}
+
+	return buffered.Flush()
+}
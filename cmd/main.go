@@ -1,13 +1,81 @@
 package main
 
 import (
-	routes "go-sheet/router"
-
-	"github.com/gin-gonic/gin"
+	"context"
+	"database/sql"
+	"fmt"
+	"go-sheet/config"
+	"go-sheet/db"
+	"log"
+	"os"
+	"time"
 )
 
+const usage = `usage: go-sheet <command> [arguments]
+
+commands:
+  serve                      start the HTTP API (default)
+  migrate up                 apply every pending schema migration
+  migrate down [-steps N]    revert the last N applied migrations (default 1)
+  migrate status             list migrations and whether they are applied
+  rollover [-month YYYY-MM]  create the planned expenses of a month (default: next month)
+  rollover -from YYYY-MM -to YYYY-MM
+                             backfill the planned expenses of a range of months
+  recurring [-through YYYY-MM-DD]
+                             create the pending expenses of due recurring expenses (default: end of this month)
+  export -workspace ID [-format csv|xlsx] [-table expenses|summary]
+         [-month YYYY-MM | -from YYYY-MM -to YYYY-MM] [-o FILE]
+                             export expenses and per-category totals (default: current month, stdout)
+  rates -workspace ID [-format csv|json] FILE
+                             import the exchange rates of a CSV or JSON file (- for stdin)`
+
 func main() {
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	switch command {
+	case "serve":
+		err = serve(cfg)
+	case "migrate":
+		err = migrate(cfg, args)
+	case "rollover":
+		err = runRollover(cfg, args)
+	case "recurring":
+		err = runRecurring(cfg, args)
+	case "export":
+		err = runExport(cfg, args)
+	case "rates":
+		err = runRates(cfg, args)
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return
+	default:
+		err = fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// openDatabase creates the shared pool and fails fast when Postgres is unreachable
+func openDatabase(cfg config.Config) (*sql.DB, error) {
+	log.Printf("connecting to %s", cfg.Database)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	routes.Initialize(gin.Default())
+	conn, err := db.Open(ctx, cfg.Database.DSN(), cfg.Database.Pool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
 
+	return conn, nil
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go-sheet/config"
+	"go-sheet/rates"
+	"go-sheet/store/postgres"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func runRates(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("rates", flag.ContinueOnError)
+	workspaceID := flags.String("workspace", "", "workspace the rates belong to (required)")
+	format := flags.String("format", "", "csv or json (default: from the file extension, else csv)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *workspaceID == "" {
+		return errors.New("-workspace is required")
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one rate file, or - for stdin")
+	}
+	name := flags.Arg(0)
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(name), ".")
+		if *format != string(rates.JSON) {
+			*format = string(rates.CSV)
+		}
+	}
+	parsed, err := rates.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	list, err := rates.Parse(parsed, in)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	conn, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	imported, err := postgres.New(conn, cfg.Currency).ImportRates(context.Background(), *workspaceID, list)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d exchange rate(s)\n", imported)
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go-sheet/config"
+	"go-sheet/recurring"
+	"go-sheet/store/postgres"
+	"time"
+)
+
+func runRecurring(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("recurring", flag.ContinueOnError)
+	throughFlag := flags.String("through", "", "last date to materialise, YYYY-MM-DD (default: end of the current month)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	through := recurring.Horizon(time.Now())
+	if *throughFlag != "" {
+		date, err := time.Parse(time.DateOnly, *throughFlag)
+		if err != nil {
+			return fmt.Errorf("-through must be YYYY-MM-DD, got %q", *throughFlag)
+		}
+		through = date
+	}
+
+	conn, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Sem despachantes aqui: os alertas ficam para a próxima despesa salva pela API
+	result, err := recurring.NewRunner(postgres.New(conn, cfg.Currency), nil).Run(context.Background(), through)
+	fmt.Printf("through %s: created %d pending expense(s) from %d recurring expense(s)\n",
+		result.Through.Format(time.DateOnly), result.Created, result.Recurring)
+	return err
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go-sheet/config"
+	"go-sheet/month"
+	"go-sheet/rollover"
+	"go-sheet/store/postgres"
+)
+
+func runRollover(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("rollover", flag.ContinueOnError)
+	monthFlag := flags.String("month", "", "month to roll over, YYYY-MM (default: next month)")
+	from := flags.String("from", "", "first month of a backfill, YYYY-MM")
+	to := flags.String("to", "", "last month of a backfill, YYYY-MM (default: current month)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *monthFlag != "" && (*from != "" || *to != "") {
+		return errors.New("use either -month or -from/-to, not both")
+	}
+	if *from == "" && *to != "" {
+		return errors.New("-to needs -from")
+	}
+
+	conn, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	runner := rollover.NewRunner(postgres.New(conn, cfg.Currency))
+	ctx := context.Background()
+
+	var results []rollover.Result
+	if *from != "" {
+		start, err := parseMonthFlag("-from", *from)
+		if err != nil {
+			return err
+		}
+		end := month.Current()
+		if *to != "" {
+			if end, err = parseMonthFlag("-to", *to); err != nil {
+				return err
+			}
+		}
+		results, err = runner.Backfill(ctx, start, end)
+		printRollover(results)
+		return err
+	}
+
+	target := month.Current().Next()
+	if *monthFlag != "" {
+		if target, err = parseMonthFlag("-month", *monthFlag); err != nil {
+			return err
+		}
+	}
+
+	result, err := runner.Run(ctx, target)
+	if err != nil {
+		return err
+	}
+	printRollover([]rollover.Result{result})
+
+	return nil
+}
+
+func parseMonthFlag(name, value string) (month.YearMonth, error) {
+	m, err := month.Parse(value)
+	if err != nil {
+		return month.YearMonth{}, fmt.Errorf("%s must be YYYY-MM, got %q", name, value)
+	}
+	return m, nil
+}
+
+func printRollover(results []rollover.Result) {
+	for _, result := range results {
+		fmt.Printf("%s: created %d planned expense(s)\n", result.Month, result.Created)
+	}
+}
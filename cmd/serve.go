@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-sheet/alerts"
+	"go-sheet/auth"
+	"go-sheet/blob"
+	"go-sheet/config"
+	"go-sheet/db/migrations"
+	"go-sheet/receipts"
+	"go-sheet/recurring"
+	"go-sheet/rollover"
+	routes "go-sheet/router"
+	"go-sheet/store/postgres"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(cfg config.Config) error {
+	verifier, err := auth.NewVerifier(cfg.Auth)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	conn, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if pending, err := migrations.Pending(context.Background(), conn); err != nil {
+		log.Printf("could not check schema migrations: %v", err)
+	} else if pending > 0 {
+		log.Printf("warning: %d schema migration(s) pending, run `go-sheet migrate up`", pending)
+	}
+
+	st := postgres.New(conn, cfg.Currency)
+
+	if cfg.Rollover.Enabled {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go rollover.NewRunner(st).Start(ctx, cfg.Rollover.Interval)
+	}
+
+	blobs, err := blob.Open(cfg.Receipts)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	deps := routes.Dependencies{
+		Store:    st,
+		Verifier: verifier,
+		Config:   cfg,
+		Receipts: receipts.NewService(blobs, st, []byte(cfg.Receipts.SigningKey), cfg.Receipts.LinkTTL, int64(cfg.Receipts.MaxBytes), cfg.Receipts.PublicURL),
+	}
+	if dispatchers := alerts.Open(cfg.Alerts, st); len(dispatchers) > 0 {
+		deps.Dispatcher = dispatchers
+	}
+	if cfg.Recurring.Enabled {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go recurring.NewRunner(st, alerts.NewService(st, deps.Dispatcher)).Start(ctx, cfg.Recurring.Interval)
+	}
+	if err := routes.Initialize(gin.Default(), deps); err != nil {
+		return fmt.Errorf("server stopped: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS monthly_expenses;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS paid_type;
+DROP TABLE IF EXISTS status;
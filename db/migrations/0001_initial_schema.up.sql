@@ -0,0 +1,47 @@
+-- Baseline schema. IF NOT EXISTS lets databases that were created by hand
+-- before migrations existed adopt this version without losing data.
+CREATE TABLE IF NOT EXISTS status (
+    status_id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    status_name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS paid_type (
+    paid_id    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    paid_type  TEXT NOT NULL,
+    paid_color TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS categories (
+    category_id     UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category_name   TEXT NOT NULL,
+    amount_planned  NUMERIC(14, 2) NOT NULL CHECK (amount_planned >= 0),
+    category_color  TEXT NOT NULL DEFAULT '',
+    description     TEXT,
+    reference_month DATE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS monthly_expenses (
+    expense_id        UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category_id       UUID NOT NULL REFERENCES categories (category_id),
+    reference_month   DATE NOT NULL,
+    spent_amount      NUMERIC(14, 2) CHECK (spent_amount >= 0),
+    amount_planned    NUMERIC(14, 2) NOT NULL CHECK (amount_planned >= 0),
+    difference_amount NUMERIC(14, 2),
+    payment_date      DATE,
+    file              TEXT,
+    description       TEXT,
+    paid_id           UUID REFERENCES paid_type (paid_id) ON DELETE SET NULL,
+    status_id         UUID REFERENCES status (status_id) ON DELETE SET NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS monthly_expenses_reference_month_idx ON monthly_expenses (reference_month);
+CREATE INDEX IF NOT EXISTS monthly_expenses_category_id_idx ON monthly_expenses (category_id);
+CREATE INDEX IF NOT EXISTS monthly_expenses_status_id_idx ON monthly_expenses (status_id);
+CREATE INDEX IF NOT EXISTS monthly_expenses_paid_id_idx ON monthly_expenses (paid_id);
+
+-- CreateCategory and the pending-payments dashboard look these up by name
+INSERT INTO status (status_name) VALUES ('pending'), ('paid')
+ON CONFLICT (status_name) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS monthly_expenses_planned_idx;
+ALTER TABLE monthly_expenses DROP COLUMN IF EXISTS is_planned;
@@ -0,0 +1,17 @@
+-- Marks the rows that hold a category's planned amount for a month, as opposed
+-- to actual spending registered through POST /expenses. There is at most one
+-- planned row per (category, month), which makes the monthly rollover idempotent.
+ALTER TABLE monthly_expenses ADD COLUMN is_planned BOOLEAN NOT NULL DEFAULT false;
+
+-- Rows inserted by CreateCategory never had a spent amount nor a payment method
+UPDATE monthly_expenses SET is_planned = true
+WHERE expense_id IN (
+    SELECT DISTINCT ON (category_id, reference_month) expense_id
+    FROM monthly_expenses
+    WHERE spent_amount IS NULL AND paid_id IS NULL
+    ORDER BY category_id, reference_month, created_at
+);
+
+CREATE UNIQUE INDEX monthly_expenses_planned_idx
+    ON monthly_expenses (category_id, reference_month)
+    WHERE is_planned;
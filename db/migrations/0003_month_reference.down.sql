@@ -0,0 +1,2 @@
+-- The original days cannot be recovered; only the constraint is dropped
+ALTER TABLE monthly_expenses DROP CONSTRAINT IF EXISTS monthly_expenses_reference_month_first_day;
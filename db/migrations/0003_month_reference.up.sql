@@ -0,0 +1,25 @@
+-- reference_month used to be written with the Go layout "2006-01-01", which
+-- repeats the month in the day position (October became 2026-10-10). Move
+-- every row to the first day of its month and keep it that way.
+
+-- Two planned rows of the same category may collapse onto the same month;
+-- keep the oldest one planned so the unique index still holds
+UPDATE monthly_expenses SET is_planned = false
+WHERE is_planned AND expense_id NOT IN (
+    SELECT DISTINCT ON (category_id, date_trunc('month', reference_month)) expense_id
+    FROM monthly_expenses
+    WHERE is_planned
+    ORDER BY category_id, date_trunc('month', reference_month), created_at
+);
+
+UPDATE monthly_expenses
+SET reference_month = date_trunc('month', reference_month)::date
+WHERE EXTRACT(DAY FROM reference_month) <> 1;
+
+UPDATE categories
+SET reference_month = date_trunc('month', reference_month)::date
+WHERE EXTRACT(DAY FROM reference_month) <> 1;
+
+ALTER TABLE monthly_expenses
+    ADD CONSTRAINT monthly_expenses_reference_month_first_day
+    CHECK (EXTRACT(DAY FROM reference_month) = 1);
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS monthly_expenses_owner_month_idx;
+DROP INDEX IF EXISTS paid_type_owner_id_idx;
+DROP INDEX IF EXISTS categories_owner_id_idx;
+DROP INDEX IF EXISTS status_owner_name_idx;
+DROP INDEX IF EXISTS status_system_name_idx;
+
+-- User statuses are dropped so status names are unique again
+UPDATE monthly_expenses SET status_id = NULL
+WHERE status_id IN (SELECT status_id FROM status WHERE owner_id IS NOT NULL);
+DELETE FROM status WHERE owner_id IS NOT NULL;
+ALTER TABLE status ADD CONSTRAINT status_status_name_key UNIQUE (status_name);
+
+ALTER TABLE status DROP COLUMN owner_id;
+ALTER TABLE paid_type DROP COLUMN owner_id;
+ALTER TABLE monthly_expenses DROP COLUMN owner_id;
+ALTER TABLE categories DROP COLUMN owner_id;
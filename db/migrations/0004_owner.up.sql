@@ -0,0 +1,19 @@
+-- Every row now belongs to the Supabase user that created it. Rows written
+-- before authentication existed keep a NULL owner and stay invisible until
+-- they are assigned, e.g.:
+--   UPDATE categories SET owner_id = '<user uuid>' WHERE owner_id IS NULL;
+--   (and the same for monthly_expenses and paid_type)
+ALTER TABLE categories ADD COLUMN owner_id UUID;
+ALTER TABLE monthly_expenses ADD COLUMN owner_id UUID;
+ALTER TABLE paid_type ADD COLUMN owner_id UUID;
+
+-- Statuses without an owner ('pending', 'paid') are shared by everyone and
+-- cannot be deleted; users may add their own on top of them
+ALTER TABLE status ADD COLUMN owner_id UUID;
+ALTER TABLE status DROP CONSTRAINT IF EXISTS status_status_name_key;
+CREATE UNIQUE INDEX status_system_name_idx ON status (status_name) WHERE owner_id IS NULL;
+CREATE UNIQUE INDEX status_owner_name_idx ON status (owner_id, status_name) WHERE owner_id IS NOT NULL;
+
+CREATE INDEX categories_owner_id_idx ON categories (owner_id);
+CREATE INDEX paid_type_owner_id_idx ON paid_type (owner_id);
+CREATE INDEX monthly_expenses_owner_month_idx ON monthly_expenses (owner_id, reference_month);
@@ -0,0 +1,20 @@
+-- Data of shared (non-personal) workspaces ends up owned by an id that is
+-- not a user; assign it again after rolling back if needed
+ALTER TABLE status DROP CONSTRAINT IF EXISTS status_workspace_id_fkey;
+ALTER TABLE paid_type DROP CONSTRAINT IF EXISTS paid_type_workspace_id_fkey;
+ALTER TABLE monthly_expenses DROP CONSTRAINT IF EXISTS monthly_expenses_workspace_id_fkey;
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_workspace_id_fkey;
+
+ALTER INDEX status_workspace_name_idx RENAME TO status_owner_name_idx;
+ALTER INDEX monthly_expenses_workspace_month_idx RENAME TO monthly_expenses_owner_month_idx;
+ALTER INDEX paid_type_workspace_id_idx RENAME TO paid_type_owner_id_idx;
+ALTER INDEX categories_workspace_id_idx RENAME TO categories_owner_id_idx;
+
+ALTER TABLE status RENAME COLUMN workspace_id TO owner_id;
+ALTER TABLE paid_type RENAME COLUMN workspace_id TO owner_id;
+ALTER TABLE monthly_expenses RENAME COLUMN workspace_id TO owner_id;
+ALTER TABLE categories RENAME COLUMN workspace_id TO owner_id;
+
+DROP TABLE IF EXISTS workspace_invites;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
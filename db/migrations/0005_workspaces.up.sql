@@ -0,0 +1,66 @@
+-- Budget data moves from a single owner to a workspace shared by members.
+-- Every existing owner gets a personal workspace whose id is their user id,
+-- so owner_id values carry over unchanged as workspace_id.
+CREATE TABLE IF NOT EXISTS workspaces (
+    workspace_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id UUID NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS workspace_members_user_id_idx ON workspace_members (user_id);
+
+-- Pending invites; accepting one deletes it and creates the membership
+CREATE TABLE IF NOT EXISTS workspace_invites (
+    invite_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    invited_by UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS workspace_invites_workspace_email_idx ON workspace_invites (workspace_id, lower(email));
+CREATE INDEX IF NOT EXISTS workspace_invites_email_idx ON workspace_invites (lower(email));
+
+INSERT INTO workspaces (workspace_id, name)
+SELECT owner_id, 'Personal'
+FROM (
+    SELECT owner_id FROM categories
+    UNION SELECT owner_id FROM monthly_expenses
+    UNION SELECT owner_id FROM paid_type
+    UNION SELECT owner_id FROM status
+) owners
+WHERE owner_id IS NOT NULL
+ON CONFLICT (workspace_id) DO NOTHING;
+
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT workspace_id, workspace_id, 'owner' FROM workspaces
+ON CONFLICT (workspace_id, user_id) DO NOTHING;
+
+ALTER TABLE categories RENAME COLUMN owner_id TO workspace_id;
+ALTER TABLE monthly_expenses RENAME COLUMN owner_id TO workspace_id;
+ALTER TABLE paid_type RENAME COLUMN owner_id TO workspace_id;
+ALTER TABLE status RENAME COLUMN owner_id TO workspace_id;
+
+ALTER INDEX categories_owner_id_idx RENAME TO categories_workspace_id_idx;
+ALTER INDEX paid_type_owner_id_idx RENAME TO paid_type_workspace_id_idx;
+ALTER INDEX monthly_expenses_owner_month_idx RENAME TO monthly_expenses_workspace_month_idx;
+ALTER INDEX status_owner_name_idx RENAME TO status_workspace_name_idx;
+
+ALTER TABLE categories ADD CONSTRAINT categories_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces (workspace_id);
+ALTER TABLE monthly_expenses ADD CONSTRAINT monthly_expenses_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces (workspace_id);
+ALTER TABLE paid_type ADD CONSTRAINT paid_type_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces (workspace_id);
+ALTER TABLE status ADD CONSTRAINT status_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces (workspace_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS monthly_expenses_workspace_payment_idx;
+DROP INDEX IF EXISTS monthly_expenses_import_ref_idx;
+ALTER TABLE monthly_expenses DROP COLUMN IF EXISTS import_ref;
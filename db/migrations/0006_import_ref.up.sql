@@ -0,0 +1,8 @@
+-- Identifies an imported expense in its source statement (OFX FITID, CAMT
+-- AcctSvcrRef, or a CSV column) so the same entry is never imported twice
+ALTER TABLE monthly_expenses ADD COLUMN IF NOT EXISTS import_ref TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS monthly_expenses_import_ref_idx
+    ON monthly_expenses (workspace_id, import_ref) WHERE import_ref IS NOT NULL;
+CREATE INDEX IF NOT EXISTS monthly_expenses_workspace_payment_idx
+    ON monthly_expenses (workspace_id, payment_date);
@@ -0,0 +1,25 @@
+-- Categorisation rules of a workspace. Every condition is optional; a rule
+-- matches when all of its conditions do. Rules are evaluated by ascending
+-- priority and each set_* column is taken from the first matching rule that
+-- has it. Deleting a paid type drops the rules that match on it, since
+-- clearing the condition would make them match everything.
+CREATE TABLE IF NOT EXISTS categorization_rules (
+    rule_id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id        UUID NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+    name                TEXT NOT NULL,
+    priority            INTEGER NOT NULL DEFAULT 0,
+    enabled             BOOLEAN NOT NULL DEFAULT true,
+    description_pattern TEXT,
+    min_amount          NUMERIC(14, 2),
+    max_amount          NUMERIC(14, 2),
+    match_paid_id       UUID REFERENCES paid_type (paid_id) ON DELETE CASCADE,
+    day_from            SMALLINT CHECK (day_from BETWEEN 1 AND 31),
+    day_to              SMALLINT CHECK (day_to BETWEEN 1 AND 31),
+    set_category_id     UUID REFERENCES categories (category_id) ON DELETE SET NULL,
+    set_paid_id         UUID REFERENCES paid_type (paid_id) ON DELETE SET NULL,
+    set_status_id       UUID REFERENCES status (status_id) ON DELETE SET NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS categorization_rules_workspace_priority_idx
+    ON categorization_rules (workspace_id, priority, created_at);
@@ -0,0 +1 @@
+ALTER TABLE monthly_expenses DROP COLUMN IF EXISTS receipt_key;
@@ -0,0 +1,4 @@
+-- Blob storage key of the receipt uploaded for an expense. The API renders
+-- it as a signed download URL in the file field; the free-form file column
+-- is kept for expenses without an uploaded receipt.
+ALTER TABLE monthly_expenses ADD COLUMN IF NOT EXISTS receipt_key TEXT;
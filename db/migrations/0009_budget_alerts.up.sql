@@ -0,0 +1,24 @@
+-- Spending thresholds of a category, as a percentage of its planned amount.
+-- Each threshold raises at most one alert per category and month.
+CREATE TABLE IF NOT EXISTS budget_thresholds (
+    category_id UUID NOT NULL REFERENCES categories (category_id) ON DELETE CASCADE,
+    percent     INTEGER NOT NULL CHECK (percent BETWEEN 1 AND 1000),
+    PRIMARY KEY (category_id, percent)
+);
+
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    alert_id        UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id    UUID NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+    category_id     UUID NOT NULL REFERENCES categories (category_id) ON DELETE CASCADE,
+    reference_month DATE NOT NULL,
+    threshold       INTEGER NOT NULL,
+    spent_amount    NUMERIC(14, 2) NOT NULL,
+    planned_amount  NUMERIC(14, 2) NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    acknowledged_at TIMESTAMPTZ,
+    acknowledged_by UUID,
+    UNIQUE (category_id, reference_month, threshold)
+);
+
+CREATE INDEX IF NOT EXISTS budget_alerts_workspace_month_idx
+    ON budget_alerts (workspace_id, reference_month DESC, created_at DESC);
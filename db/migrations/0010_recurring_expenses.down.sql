@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS monthly_expenses_recurring_occurrence_idx;
+
+ALTER TABLE monthly_expenses
+    DROP COLUMN IF EXISTS occurrence_date,
+    DROP COLUMN IF EXISTS recurring_id;
+
+DROP TABLE IF EXISTS recurring_exceptions;
+DROP TABLE IF EXISTS recurring_expenses;
@@ -0,0 +1,39 @@
+-- Expenses that repeat on a schedule. Occurrences are copied into
+-- monthly_expenses as pending items by the recurring job; materialized_through
+-- is the last date it has handled, so rows deleted by hand are not recreated.
+CREATE TABLE IF NOT EXISTS recurring_expenses (
+    recurring_id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id         UUID NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+    category_id          UUID NOT NULL REFERENCES categories (category_id) ON DELETE CASCADE,
+    paid_id              UUID REFERENCES paid_type (paid_id) ON DELETE SET NULL,
+    amount               NUMERIC(14, 2) NOT NULL CHECK (amount >= 0),
+    description          TEXT,
+    frequency            TEXT NOT NULL CHECK (frequency IN ('weekly', 'monthly', 'yearly')),
+    every                INTEGER NOT NULL DEFAULT 1 CHECK (every >= 1),
+    day_of_month         SMALLINT CHECK (day_of_month BETWEEN 1 AND 31),
+    start_date           DATE NOT NULL,
+    end_date             DATE,
+    occurrences          INTEGER CHECK (occurrences >= 1),
+    materialized_through DATE,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS recurring_expenses_workspace_id_idx ON recurring_expenses (workspace_id);
+
+-- A single occurrence that is skipped or materialised with other values
+CREATE TABLE IF NOT EXISTS recurring_exceptions (
+    recurring_id    UUID NOT NULL REFERENCES recurring_expenses (recurring_id) ON DELETE CASCADE,
+    occurrence_date DATE NOT NULL,
+    skip            BOOLEAN NOT NULL DEFAULT false,
+    amount          NUMERIC(14, 2) CHECK (amount >= 0),
+    description     TEXT,
+    paid_id         UUID REFERENCES paid_type (paid_id) ON DELETE SET NULL,
+    PRIMARY KEY (recurring_id, occurrence_date)
+);
+
+ALTER TABLE monthly_expenses
+    ADD COLUMN IF NOT EXISTS recurring_id UUID REFERENCES recurring_expenses (recurring_id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS occurrence_date DATE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS monthly_expenses_recurring_occurrence_idx
+    ON monthly_expenses (recurring_id, occurrence_date) WHERE recurring_id IS NOT NULL;
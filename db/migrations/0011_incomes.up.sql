@@ -0,0 +1,18 @@
+-- Money coming in. reference_month is the month the income pays for, which
+-- the dashboard compares with that month's expenses; received_date stays
+-- NULL until the money arrives.
+CREATE TABLE IF NOT EXISTS incomes (
+    income_id       UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id    UUID NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+    source          TEXT NOT NULL CHECK (source <> ''),
+    amount          NUMERIC(14, 2) NOT NULL CHECK (amount >= 0),
+    reference_month DATE NOT NULL,
+    expected_date   DATE NOT NULL,
+    received_date   DATE,
+    recurring       BOOLEAN NOT NULL DEFAULT false,
+    description     TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS incomes_workspace_month_idx
+    ON incomes (workspace_id, reference_month);
@@ -0,0 +1,7 @@
+DROP FUNCTION IF EXISTS to_base_currency(NUMERIC, UUID, TEXT, DATE, TEXT);
+DROP FUNCTION IF EXISTS convert_amount(NUMERIC, UUID, TEXT, TEXT, DATE);
+DROP TABLE IF EXISTS exchange_rates;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS currency;
+ALTER TABLE incomes DROP COLUMN IF EXISTS currency;
+ALTER TABLE monthly_expenses DROP COLUMN IF EXISTS currency;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS base_currency;
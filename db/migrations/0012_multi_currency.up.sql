@@ -0,0 +1,55 @@
+-- Amounts without a currency are in the base currency of their workspace,
+-- and a workspace without one uses the CURRENCY the server is configured
+-- with. Planned amounts are always in the base currency.
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS base_currency CHAR(3) CHECK (base_currency ~ '^[A-Z]{3}$');
+ALTER TABLE monthly_expenses ADD COLUMN IF NOT EXISTS currency CHAR(3) CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE incomes ADD COLUMN IF NOT EXISTS currency CHAR(3) CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS currency CHAR(3) CHECK (currency ~ '^[A-Z]{3}$');
+
+-- One unit of from_currency is worth rate units of to_currency from
+-- rate_date until the next rate of the pair
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    workspace_id  UUID NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+    from_currency CHAR(3) NOT NULL CHECK (from_currency ~ '^[A-Z]{3}$'),
+    to_currency   CHAR(3) NOT NULL CHECK (to_currency ~ '^[A-Z]{3}$' AND to_currency <> from_currency),
+    rate_date     DATE NOT NULL,
+    rate          NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+    PRIMARY KEY (workspace_id, from_currency, to_currency, rate_date)
+);
+
+-- convert_amount converts amount with the latest rate of the pair on or
+-- before on_date, dividing by the rate of the opposite pair when only that
+-- one is known. It returns NULL when no rate covers the date.
+CREATE OR REPLACE FUNCTION convert_amount(amount NUMERIC, ws UUID, from_currency TEXT, to_currency TEXT, on_date DATE)
+RETURNS NUMERIC
+LANGUAGE sql STABLE AS $$
+    SELECT CASE
+        WHEN amount IS NULL OR from_currency = to_currency THEN amount
+        ELSE (
+            SELECT ROUND(CASE WHEN r.inverse THEN amount / r.rate ELSE amount * r.rate END, 2)
+            FROM (
+                SELECT rate, rate_date, false AS inverse FROM exchange_rates
+                WHERE workspace_id = ws AND exchange_rates.from_currency = convert_amount.from_currency
+                    AND exchange_rates.to_currency = convert_amount.to_currency AND rate_date <= on_date
+                UNION ALL
+                SELECT rate, rate_date, true FROM exchange_rates
+                WHERE workspace_id = ws AND exchange_rates.from_currency = convert_amount.to_currency
+                    AND exchange_rates.to_currency = convert_amount.from_currency AND rate_date <= on_date
+            ) r
+            ORDER BY r.rate_date DESC, r.inverse
+            LIMIT 1
+        )
+    END
+$$;
+
+-- to_base_currency converts an amount recorded in currency (NULL for the
+-- base currency) into the base currency of workspace ws
+CREATE OR REPLACE FUNCTION to_base_currency(amount NUMERIC, ws UUID, currency TEXT, on_date DATE, default_currency TEXT)
+RETURNS NUMERIC
+LANGUAGE sql STABLE AS $$
+    SELECT CASE
+        WHEN currency IS NULL THEN amount
+        ELSE convert_amount(amount, ws, currency,
+            COALESCE((SELECT base_currency FROM workspaces WHERE workspace_id = ws), default_currency), on_date)
+    END
+$$;
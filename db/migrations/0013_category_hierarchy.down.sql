@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS categories_parent_id_idx;
+ALTER TABLE categories DROP COLUMN IF EXISTS parent_id;
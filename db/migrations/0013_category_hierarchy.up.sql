@@ -0,0 +1,7 @@
+-- Categories form a tree: parent_id points at the parent category of the
+-- same workspace, NULL for top-level ones. A parent cannot be deleted while
+-- it has subcategories.
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES categories (category_id) ON DELETE RESTRICT
+    CHECK (parent_id <> category_id);
+
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
@@ -0,0 +1,2 @@
+-- Archived categories keep their history but get no new monthly rows
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
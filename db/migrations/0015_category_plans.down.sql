@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS planned_amount(UUID, DATE);
+DROP TABLE IF EXISTS category_plans;
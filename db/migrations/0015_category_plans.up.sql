@@ -0,0 +1,40 @@
+-- A category's planned amount applies from effective_month until the month
+-- of its next plan. categories.amount_planned keeps the last amount saved
+-- by UpdateCategory and is only used when a category has no plan at all.
+CREATE TABLE IF NOT EXISTS category_plans (
+    category_id     UUID NOT NULL REFERENCES categories (category_id) ON DELETE CASCADE,
+    effective_month DATE NOT NULL CHECK (effective_month = date_trunc('month', effective_month)),
+    amount_planned  NUMERIC(14, 2) NOT NULL CHECK (amount_planned >= 0),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (category_id, effective_month)
+);
+
+-- The planned rows already record what each month's budget was
+INSERT INTO category_plans (category_id, effective_month, amount_planned)
+SELECT DISTINCT ON (category_id, reference_month) category_id, reference_month, amount_planned
+FROM monthly_expenses
+WHERE is_planned
+ORDER BY category_id, reference_month, created_at DESC
+ON CONFLICT DO NOTHING;
+
+INSERT INTO category_plans (category_id, effective_month, amount_planned)
+SELECT category_id, date_trunc('month', created_at)::date, amount_planned
+FROM categories c
+WHERE NOT EXISTS (SELECT 1 FROM category_plans p WHERE p.category_id = c.category_id)
+ON CONFLICT DO NOTHING;
+
+-- planned_amount is the plan of a category effective in month m: the latest
+-- one from m or before, else the earliest one, else categories.amount_planned
+CREATE OR REPLACE FUNCTION planned_amount(category UUID, m DATE)
+RETURNS NUMERIC
+LANGUAGE sql STABLE AS $$
+    SELECT COALESCE(
+        (SELECT amount_planned FROM category_plans
+            WHERE category_id = category AND effective_month <= m
+            ORDER BY effective_month DESC LIMIT 1),
+        (SELECT amount_planned FROM category_plans
+            WHERE category_id = category
+            ORDER BY effective_month LIMIT 1),
+        (SELECT amount_planned FROM categories WHERE category_id = category)
+    )
+$$;
@@ -0,0 +1,258 @@
+// Package migrations embeds the versioned SQL schema of the application and
+// applies it, recording progress in the schema_migrations table.
+//
+// Every version is a pair of files named NNNN_description.up.sql and
+// NNNN_description.down.sql. Versions are applied in ascending order, each
+// one inside its own transaction.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockID serialises concurrent migration runs (e.g. two replicas starting at once)
+const lockID = 7_265_431
+
+// Migration is one schema version
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// State is a migration together with when it was applied, if ever
+type State struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Load returns every embedded migration sorted by version
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(fileName, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name.up.sql or NNNN_name.down.sql", fileName)
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", fileName, prefix)
+		}
+
+		body, err := files.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Status lists every known migration and whether it has been applied
+func Status(ctx context.Context, db *sql.DB) ([]State, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]State, len(migrations))
+	for i, m := range migrations {
+		states[i] = State{Migration: m}
+		if at, ok := applied[m.Version]; ok {
+			states[i].AppliedAt = &at
+		}
+	}
+
+	return states, nil
+}
+
+// Up applies every pending migration and returns the ones it ran
+func Up(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	states, err := Status(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, state := range states {
+		if state.AppliedAt != nil {
+			continue
+		}
+		var alreadyApplied bool
+		err := inTx(ctx, db, func(tx *sql.Tx) error {
+			// Another process may have applied it while we waited for the lock
+			if err := isApplied(ctx, tx, state.Version, &alreadyApplied); err != nil || alreadyApplied {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, state.Up); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, state.Version, state.Name)
+			return err
+		})
+		if err != nil {
+			return ran, fmt.Errorf("migration %d_%s up: %w", state.Version, state.Name, err)
+		}
+		if !alreadyApplied {
+			ran = append(ran, state.Migration)
+		}
+	}
+
+	return ran, nil
+}
+
+// Down reverts the most recently applied migrations, at most steps of them
+func Down(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	states, err := Status(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for i := len(states) - 1; i >= 0 && len(ran) < steps; i-- {
+		state := states[i]
+		if state.AppliedAt == nil {
+			continue
+		}
+		stillApplied := true
+		err := inTx(ctx, db, func(tx *sql.Tx) error {
+			if err := isApplied(ctx, tx, state.Version, &stillApplied); err != nil || !stillApplied {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, state.Down); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, state.Version)
+			return err
+		})
+		if err != nil {
+			return ran, fmt.Errorf("migration %d_%s down: %w", state.Version, state.Name, err)
+		}
+		if stillApplied {
+			ran = append(ran, state.Migration)
+		}
+	}
+
+	return ran, nil
+}
+
+// Pending reports how many embedded migrations have not been applied yet
+func Pending(ctx context.Context, db *sql.DB) (int, error) {
+	states, err := Status(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, state := range states {
+		if state.AppliedAt == nil {
+			pending++
+		}
+	}
+
+	return pending, nil
+}
+
+func ensureTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int]time.Time, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func isApplied(ctx context.Context, tx *sql.Tx, version int, applied *bool) error {
+	return tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(applied)
+}
+
+// inTx runs fn in a transaction holding the migration advisory lock, so a
+// concurrent run blocks until this version is committed or rolled back
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, lockID); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
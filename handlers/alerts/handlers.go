@@ -0,0 +1,126 @@
+package alerts
+
+import (
+	"errors"
+	service "go-sheet/alerts"
+	"go-sheet/auth"
+	"go-sheet/month"
+	"go-sheet/store"
+	"go-sheet/workspace"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ThresholdsRequest is the body of SetThresholds, in percent of the planned
+// amount; an empty list turns the category's alerts off
+type ThresholdsRequest struct {
+	Thresholds []int `json:"thresholds" binding:"required"`
+}
+
+// Handler serves the budget alert routes
+type Handler struct {
+	store store.AlertStore
+}
+
+// NewHandler returns a Handler backed by the given store
+func NewHandler(s store.AlertStore) *Handler {
+	return &Handler{store: s}
+}
+
+// ListAlerts returns the alerts of the workspace, newest first. Query
+// parameters: month=YYYY-MM, categoryId and unacknowledged=true.
+func (h *Handler) ListAlerts(ctx *gin.Context) {
+	var query store.AlertQuery
+	if value := ctx.Query("month"); value != "" {
+		m, err := month.Parse(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid month format. Use YYYY-MM"})
+			return
+		}
+		query.Month = m
+	}
+	query.CategoryID = ctx.Query("categoryId")
+	query.Unacknowledged = ctx.Query("unacknowledged") == "true"
+
+	list, err := h.store.ListAlerts(ctx.Request.Context(), workspace.ID(ctx), query)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Error querying alerts", "error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Alerts retrieved successfully",
+		"data":    list,
+	})
+}
+
+// AcknowledgeAlert marks an alert as seen by the caller
+func (h *Handler) AcknowledgeAlert(ctx *gin.Context) {
+	alert, err := h.store.AcknowledgeAlert(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"), auth.UserID(ctx))
+	if err != nil {
+		respondWithError(ctx, err, "Alert not found", "Error acknowledging alert")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Alert acknowledged successfully",
+		"data":    alert,
+	})
+}
+
+// GetThresholds returns the alert thresholds of a category
+func (h *Handler) GetThresholds(ctx *gin.Context) {
+	thresholds, err := h.store.AlertThresholds(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"))
+	if err != nil {
+		respondWithError(ctx, err, "Category not found", "Error querying thresholds")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Thresholds retrieved successfully",
+		"data":    gin.H{"categoryId": ctx.Param("id"), "thresholds": thresholds},
+	})
+}
+
+// SetThresholds replaces the alert thresholds of a category. They are
+// checked the next time an expense of the category is saved.
+func (h *Handler) SetThresholds(ctx *gin.Context) {
+	var req ThresholdsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Error binding JSON", "error": err.Error()})
+		return
+	}
+	if err := service.ValidateThresholds(req.Thresholds); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid thresholds", "error": err.Error()})
+		return
+	}
+
+	ws, categoryID := workspace.ID(ctx), ctx.Param("id")
+	if err := h.store.SetAlertThresholds(ctx.Request.Context(), ws, categoryID, req.Thresholds); err != nil {
+		respondWithError(ctx, err, "Category not found", "Error saving thresholds")
+		return
+	}
+	thresholds, err := h.store.AlertThresholds(ctx.Request.Context(), ws, categoryID)
+	if err != nil {
+		respondWithError(ctx, err, "Category not found", "Error querying thresholds")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Thresholds saved successfully",
+		"data":    gin.H{"categoryId": categoryID, "thresholds": thresholds},
+	})
+}
+
+func respondWithError(ctx *gin.Context, err error, notFound, message string) {
+	if errors.Is(err, store.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": notFound})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": message, "error": err.Error()})
+}
@@ -1,79 +1,63 @@
 package analytic
 
 import (
-	"database/sql"
-	"go-sheet/db"
+	"context"
+	"fmt"
+	"go-sheet/hierarchy"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"go-sheet/workspace"
+	"math"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// get planned, spent and diferenc amount by month
-func GetAnalyticTotal(ctx *gin.Context) {
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Error connecting to database",
-			"error":   err.Error(),
-		})
-		return
-	}
-	defer conn.Close()
+// maxSeriesMonths bounds the range of GetAnalyticSeries
+const maxSeriesMonths = 120
 
-	// Obter o mês da query string, se fornecido
-	monthParam := ctx.DefaultQuery("month", "")
-	var targetMonth time.Time
+// Store is what the analytic handlers need; the categories place the
+// breakdown in the category tree
+type Store interface {
+	store.AnalyticsStore
+	ListCategories(ctx context.Context, workspaceID string) ([]store.Category, error)
+}
 
-	if monthParam != "" {
-		// Se um mês foi fornecido, parse-o
-		targetMonth, err = time.Parse("2006-01", monthParam)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"status":  "error",
-				"message": "Invalid month format. Use YYYY-MM",
-				"error":   err.Error(),
-			})
-			return
-		}
-	} else {
-		// Se nenhum mês foi fornecido, use o mês atual
-		targetMonth = time.Now()
-	}
+// CategoryShare is a line of GetCategoryBreakdown. The total amounts add up
+// the category and all its subcategories; a parent shows up with zero
+// amounts of its own when only its subcategories had expenses.
+type CategoryShare struct {
+	store.CategoryShare
+	ParentID           *string      `json:"parentId"`
+	Depth              int          `json:"depth"`
+	TotalPlannedAmount money.Amount `json:"totalPlannedAmount"`
+	TotalSpentAmount   money.Amount `json:"totalSpentAmount"`
+}
 
-	// Formatar o mês alvo para o primeiro dia do mês
-	startOfMonth := targetMonth.Format("2006-01-01")
-	// Calcular o primeiro dia do próximo mês
-	endOfMonth := targetMonth.AddDate(0, 1, 0).Format("2006-01-01")
+// Handler serves the dashboard analytic routes
+type Handler struct {
+	store Store
+}
 
-	sqlQuery := `
-		SELECT 
-			SUM(amount_planned) AS total_planned, 
-			SUM(COALESCE(spent_amount, 0)) AS total_spent, 
-			SUM(amount_planned - COALESCE(spent_amount, 0)) AS total_difference 
-		FROM monthly_expenses 
-		WHERE reference_month >= $1 AND reference_month < $2
-	`
+// NewHandler returns a Handler backed by the given store. Amounts are
+// reported in the base currency of the workspace.
+func NewHandler(s Store) *Handler {
+	return &Handler{store: s}
+}
 
-	var totalPlanned, totalSpent, totalDifference sql.NullFloat64
+// get planned, spent and diferenc amount by month, and the income with what
+// is left of it. savingsRate is the net as a percentage of the income, null
+// without income. unconvertedExpenses counts the expenses left out of
+// totalSpent for want of an exchange rate.
+func (h *Handler) GetAnalyticTotal(ctx *gin.Context) {
+	targetMonth, ok := parseMonth(ctx)
+	if !ok {
+		return
+	}
 
-	err = conn.QueryRow(sqlQuery, startOfMonth, endOfMonth).Scan(&totalPlanned, &totalSpent, &totalDifference)
+	totals, err := h.store.Totals(ctx.Request.Context(), workspace.ID(ctx), targetMonth)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			// Não há dados para o mês especificado, retornar zeros
-			ctx.JSON(http.StatusOK, gin.H{
-				"status":  "success",
-				"message": "No data for the specified month",
-				"data": gin.H{
-					"month":           targetMonth.Format("2006-01"),
-					"totalPlanned":    0,
-					"totalSpent":      0,
-					"totalDifference": 0,
-				},
-			})
-			return
-		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Error executing query",
@@ -86,147 +70,198 @@ func GetAnalyticTotal(ctx *gin.Context) {
 		"status":  "success",
 		"message": "Analytic data retrieved successfully",
 		"data": gin.H{
-			"month":           targetMonth.Format("2006-01"),
-			"totalPlanned":    totalPlanned.Float64,
-			"totalSpent":      totalSpent.Float64,
-			"totalDifference": totalDifference.Float64,
+			"month":               targetMonth.String(),
+			"totalPlanned":        totals.Planned,
+			"totalSpent":          totals.Spent,
+			"totalDifference":     totals.Difference,
+			"totalIncome":         totals.Income,
+			"netBalance":          totals.Net,
+			"savingsRate":         savingsRate(totals),
+			"currency":            workspace.Currency(ctx),
+			"unconvertedExpenses": totals.Unconverted,
 		},
 	})
 }
 
-// GetPendingPayment retrieves all expenses with the status "pending"
 // GetPendingPayment retrieves all pending payments for a specific month
-func GetPendingPayment(ctx *gin.Context) {
-	conn, err := db.OpenConnection()
+func (h *Handler) GetPendingPayment(ctx *gin.Context) {
+	targetMonth, ok := parseMonth(ctx)
+	if !ok {
+		return
+	}
+
+	pendingPayments, err := h.store.PendingPayments(ctx.Request.Context(), workspace.ID(ctx), targetMonth)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
-			"message": "Error connecting to database",
+			"message": "Error querying database",
 			"error":   err.Error(),
 		})
 		return
 	}
-	defer conn.Close()
 
-	// Obter o mês da query string, se fornecido
-	monthParam := ctx.DefaultQuery("month", "")
-	var targetMonth time.Time
+	if len(pendingPayments) == 0 {
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "No pending payments found for the specified month",
+			"data":    []store.PendingPayment{},
+		})
+		return
+	}
 
-	if monthParam != "" {
-		// Se um mês foi fornecido, parse-o
-		targetMonth, err = time.Parse("2006-01", monthParam)
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Pending payments retrieved successfully",
+		"currency": workspace.Currency(ctx),
+		"data":     pendingPayments,
+	})
+}
+
+// GetAnalyticSeries returns planned, spent and difference per month, with the
+// month-over-month and year-over-year change of the spent amount. Query
+// parameters from=YYYY-MM and to=YYYY-MM default to the last 12 months.
+func (h *Handler) GetAnalyticSeries(ctx *gin.Context) {
+	to := month.Current()
+	if value := ctx.Query("to"); value != "" {
+		m, err := month.Parse(value)
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"status":  "error",
-				"message": "Invalid month format. Use YYYY-MM",
-				"error":   err.Error(),
-			})
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid to format. Use YYYY-MM", "error": err.Error()})
 			return
 		}
-	} else {
-		// Se nenhum mês foi fornecido, use o mês atual
-		targetMonth = time.Now()
-	}
-
-	// Formatar o mês alvo para o primeiro dia do mês
-	startOfMonth := targetMonth.Format("2006-01-01")
-	// Calcular o primeiro dia do próximo mês
-	endOfMonth := targetMonth.AddDate(0, 1, 0).Format("2006-01-01")
-
-	// Query para buscar todas as despesas com o status "pending" e para o mês especificado
-	sqlQuery := `
-        SELECT 
-            me.expense_id, 
-            me.category_id, 
-            c.category_name,
-            me.reference_month, 
-            me.spent_amount, 
-            me.amount_planned, 
-            me.payment_date, 
-            me.description,
-            s.status_name
-        FROM 
-            monthly_expenses me
-        JOIN
-            categories c ON me.category_id::text = c.category_id::text
-        JOIN
-            status s ON me.status_id::text = s.status_id::text
-        WHERE 
-            s.status_name = 'pending' AND me.reference_month >= $1 AND me.reference_month < $2
-    `
-
-	rows, err := conn.Query(sqlQuery, startOfMonth, endOfMonth)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		to = m
+	}
+	from := to.AddMonths(-11)
+	if value := ctx.Query("from"); value != "" {
+		m, err := month.Parse(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid from format. Use YYYY-MM", "error": err.Error()})
+			return
+		}
+		from = m
+	}
+	if to.Before(from) || from.AddMonths(maxSeriesMonths).Before(to.Next()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
-			"message": "Error querying database",
-			"error":   err.Error(),
+			"message": fmt.Sprintf("to must not be before from, and the range may span at most %d months", maxSeriesMonths),
 		})
 		return
 	}
-	defer rows.Close()
 
-	var pendingPayments []gin.H
-	for rows.Next() {
-		var expenseID, categoryID, categoryName, statusName string
-		var description sql.NullString // Alterado para sql.NullString
-		var referenceMonth time.Time
-		var spentAmount, amountPlanned sql.NullFloat64
-		var paymentDate sql.NullTime
+	points, err := h.store.MonthlySeries(ctx.Request.Context(), workspace.ID(ctx), from, to)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Error executing query", "error": err.Error()})
+		return
+	}
 
-		err := rows.Scan(&expenseID, &categoryID, &categoryName, &referenceMonth, &spentAmount, &amountPlanned, &paymentDate, &description, &statusName)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Error scanning row",
-				"error":   err.Error(),
-			})
-			return
-		}
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Analytic series retrieved successfully",
+		"currency": workspace.Currency(ctx),
+		"data":     points,
+	})
+}
+
+// GetCategoryBreakdown returns how the spending of a month splits across
+// categories, with each category's change from the previous month and from
+// the same month a year earlier. Categories come in tree order, parents
+// before their subcategories.
+func (h *Handler) GetCategoryBreakdown(ctx *gin.Context) {
+	targetMonth, ok := parseMonth(ctx)
+	if !ok {
+		return
+	}
 
-		payment := gin.H{
-			"expenseId":      expenseID,
-			"categoryId":     categoryID,
-			"categoryName":   categoryName,
-			"referenceMonth": referenceMonth.Format("2006-01-02"),
-			"spentAmount":    spentAmount.Float64,
-			"plannedAmount":  amountPlanned.Float64,
-			"paymentDate":    paymentDate.Time.Format("2006-01-02"),
-			"description":    description.String, // Use description.String
-			"statusName":     statusName,
+	ws := workspace.ID(ctx)
+	breakdown, err := h.store.CategoryBreakdown(ctx.Request.Context(), ws, targetMonth)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Error executing query", "error": err.Error()})
+		return
+	}
+	categories, err := h.store.ListCategories(ctx.Request.Context(), ws)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Error querying categories", "error": err.Error()})
+		return
+	}
+	shares := rollUpShares(categories, breakdown)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Category breakdown retrieved successfully",
+		"month":    targetMonth.String(),
+		"currency": workspace.Currency(ctx),
+		"data":     shares,
+	})
+}
+
+// rollUpShares places the breakdown in the category tree, adding the
+// ancestors that only have expenses through their subcategories
+func rollUpShares(categories []store.Category, breakdown []store.CategoryShare) []CategoryShare {
+	tree := hierarchy.New(categories)
+	byID := map[string]store.CategoryShare{}
+	own := map[string]hierarchy.Amounts{}
+	for _, share := range breakdown {
+		byID[share.CategoryID] = share
+		own[share.CategoryID] = hierarchy.Amounts{Planned: share.Planned, Spent: share.Spent}
+	}
+	rolled := tree.RollUp(own)
+
+	// Only the categories with expenses and their ancestors are listed
+	listed := map[string]bool{}
+	for id := range byID {
+		listed[id] = true
+		for _, ancestor := range tree.Ancestors(id) {
+			listed[ancestor] = true
 		}
+	}
 
-		// Adicione a descrição apenas se ela não for nula
-		if description.Valid {
-			payment["description"] = description.String
-		} else {
-			payment["description"] = "" // ou qualquer valor padrão que você queira usar
+	shares := []CategoryShare{}
+	for _, id := range tree.Order() {
+		if !listed[id] {
+			continue
 		}
+		category, _ := tree.Category(id)
+		share, ok := byID[id]
+		if !ok {
+			share = store.CategoryShare{CategoryID: id, CategoryName: category.CategoryName}
+		}
+		shares = append(shares, CategoryShare{
+			CategoryShare:      share,
+			ParentID:           category.ParentID,
+			Depth:              tree.Depth(id),
+			TotalPlannedAmount: rolled[id].Planned,
+			TotalSpentAmount:   rolled[id].Spent,
+		})
+	}
+	return shares
+}
 
-		pendingPayments = append(pendingPayments, payment)
+func savingsRate(totals store.MonthTotals) *float64 {
+	if totals.Income <= 0 {
+		return nil
 	}
+	rate := math.Round(float64(totals.Net)*100/float64(totals.Income)*100) / 100
+	return &rate
+}
 
-	if err := rows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Error iterating over rows",
-			"error":   err.Error(),
-		})
-		return
+// parseMonth reads the optional ?month=YYYY-MM parameter, defaulting to the
+// current month. It answers with 400 and returns false when it is malformed.
+func parseMonth(ctx *gin.Context) (month.YearMonth, bool) {
+	// Obter o mês da query string, se fornecido
+	monthParam := ctx.DefaultQuery("month", "")
+	if monthParam == "" {
+		// Se nenhum mês foi fornecido, use o mês atual
+		return month.Current(), true
 	}
 
-	if len(pendingPayments) == 0 {
-		ctx.JSON(http.StatusOK, gin.H{
-			"status":  "success",
-			"message": "No pending payments found for the specified month",
-			"data":    []gin.H{},
+	targetMonth, err := month.Parse(monthParam)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid month format. Use YYYY-MM",
+			"error":   err.Error(),
 		})
-		return
+		return month.YearMonth{}, false
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Pending payments retrieved successfully",
-		"data":    pendingPayments,
-	})
+	return targetMonth, true
 }
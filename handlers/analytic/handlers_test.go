@@ -0,0 +1,199 @@
+package analytic_test
+
+import (
+	"go-sheet/handlers/analytic"
+	"go-sheet/handlers/handlertest"
+	"go-sheet/month"
+	"go-sheet/store"
+	"net/http"
+	"testing"
+)
+
+const user = handlertest.UserA
+
+type totalResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Month               string   `json:"month"`
+		TotalPlanned        string   `json:"totalPlanned"`
+		TotalSpent          string   `json:"totalSpent"`
+		TotalDifference     string   `json:"totalDifference"`
+		TotalIncome         string   `json:"totalIncome"`
+		NetBalance          string   `json:"netBalance"`
+		SavingsRate         *float64 `json:"savingsRate"`
+		Currency            string   `json:"currency"`
+		UnconvertedExpenses int      `json:"unconvertedExpenses"`
+	} `json:"data"`
+}
+
+// setup spends 30.00 on a 1000.00 plan and 45.00 on a 200.00 one in the
+// current month, the one new categories are planned for
+func setup(t *testing.T) (*handlertest.Server, month.YearMonth) {
+	srv := handlertest.New(t)
+	current := month.Current()
+	rent := srv.CreateCategory(t, user, "Rent", "1000.00")
+	food := srv.CreateCategory(t, user, "Food", "200.00")
+	paidID := srv.CreatePaidType(t, user, "Card")
+	for _, expense := range []struct{ categoryID, amount string }{
+		{rent, "10.00"}, {rent, "10.00"}, {rent, "10.00"}, {food, "45.00"},
+	} {
+		srv.CreateExpense(t, user, `{"categoryId":"`+expense.categoryID+`","paidId":"`+paidID+`","referenceMonth":"`+current.String()+
+			`","spentAmount":"`+expense.amount+`","paymentDate":"`+current.String()+`-05"}`)
+	}
+	return srv, current
+}
+
+func TestAnalyticTotal(t *testing.T) {
+	srv, current := setup(t)
+
+	var resp totalResponse
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/dashboard/analytic/total?month="+current.String(), ""), &resp)
+	data := resp.Data
+	// The plans count once, however many expenses the categories have
+	if data.TotalPlanned != "1200.00" || data.TotalSpent != "75.00" || data.TotalDifference != "1125.00" {
+		t.Errorf("planned %s, spent %s, difference %s; want 1200.00, 75.00, 1125.00", data.TotalPlanned, data.TotalSpent, data.TotalDifference)
+	}
+	if data.Month != current.String() || data.Currency != "BRL" {
+		t.Errorf("month %s, currency %s", data.Month, data.Currency)
+	}
+	if data.TotalIncome != "0.00" || data.NetBalance != "-75.00" || data.SavingsRate != nil {
+		t.Errorf("income %s, net %s, savings rate %v", data.TotalIncome, data.NetBalance, data.SavingsRate)
+	}
+
+	srv.Expect(t, http.StatusCreated, user, http.MethodPost, "/api/v1/incomes",
+		`{"source":"Salary","amount":"300.00","expectedDate":"`+current.String()+`-01"}`)
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/dashboard/analytic/total?month="+current.String(), ""), &resp)
+	if data := resp.Data; data.TotalIncome != "300.00" || data.NetBalance != "225.00" || data.SavingsRate == nil || *data.SavingsRate != 75 {
+		t.Errorf("income %s, net %s, savings rate %v; want 300.00, 225.00, 75", data.TotalIncome, data.NetBalance, data.SavingsRate)
+	}
+}
+
+func TestAnalyticTotalCountsUnconvertedExpenses(t *testing.T) {
+	srv, current := setup(t)
+	var categories struct {
+		Data []store.Category `json:"data"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/categories", ""), &categories)
+	paidID := srv.CreatePaidType(t, user, "Cash")
+	// No USD rate was imported, so the expense cannot be converted to BRL
+	srv.CreateExpense(t, user, `{"categoryId":"`+categories.Data[0].CategoryID+`","paidId":"`+paidID+`","referenceMonth":"`+current.String()+
+		`","spentAmount":"99.00","currency":"USD","paymentDate":"`+current.String()+`-05"}`)
+
+	var resp totalResponse
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/dashboard/analytic/total?month="+current.String(), ""), &resp)
+	if resp.Data.TotalSpent != "75.00" || resp.Data.UnconvertedExpenses != 1 {
+		t.Errorf("spent %s with %d unconverted, want 75.00 with 1", resp.Data.TotalSpent, resp.Data.UnconvertedExpenses)
+	}
+}
+
+func TestAnalyticTotalOfEmptyMonth(t *testing.T) {
+	srv, current := setup(t)
+
+	var resp totalResponse
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/dashboard/analytic/total?month="+current.AddMonths(-24).String(), ""), &resp)
+	if resp.Data.TotalPlanned != "0.00" || resp.Data.TotalSpent != "0.00" {
+		t.Errorf("planned %s, spent %s; want zeros", resp.Data.TotalPlanned, resp.Data.TotalSpent)
+	}
+
+	if w := srv.Do(t, user, http.MethodGet, "/api/v1/dashboard/analytic/total?month=13-2026", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bad month: status %d, want 400", w.Code)
+	}
+}
+
+func TestAnalyticSeriesMatchesTotal(t *testing.T) {
+	srv, current := setup(t)
+
+	var resp struct {
+		Data []store.MonthPoint `json:"data"`
+	}
+	path := "/api/v1/dashboard/analytic/series?from=" + current.Prev().String() + "&to=" + current.String()
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, path, ""), &resp)
+	if len(resp.Data) != 2 {
+		t.Fatalf("got %d points, want 2", len(resp.Data))
+	}
+	if point := resp.Data[0]; point.Month != current.Prev() || point.Planned != 0 || point.Spent != 0 {
+		t.Errorf("previous month = %+v", point)
+	}
+	point := resp.Data[1]
+	if point.Planned.String() != "1200.00" || point.Spent.String() != "75.00" || point.MonthOverMonth.Change.String() != "75.00" {
+		t.Errorf("current month = %+v", point)
+	}
+
+	for _, query := range []string{"?from=2026-05&to=2026-01", "?from=2010-01&to=2026-01", "?to=later"} {
+		if w := srv.Do(t, user, http.MethodGet, "/api/v1/dashboard/analytic/series"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("series%s: status %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestCategoryBreakdown(t *testing.T) {
+	srv, current := setup(t)
+
+	var resp struct {
+		Data []analytic.CategoryShare `json:"data"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/dashboard/analytic/categories?month="+current.String(), ""), &resp)
+	if len(resp.Data) != 2 {
+		t.Fatalf("got %d shares, want 2", len(resp.Data))
+	}
+	want := map[string]struct{ planned, spent string }{
+		"Rent": {"1000.00", "30.00"},
+		"Food": {"200.00", "45.00"},
+	}
+	for _, share := range resp.Data {
+		w := want[share.CategoryName]
+		if share.Planned.String() != w.planned || share.Spent.String() != w.spent {
+			t.Errorf("%s: planned %s, spent %s; want %s, %s", share.CategoryName, share.Planned, share.Spent, w.planned, w.spent)
+		}
+	}
+}
+
+func TestPendingPayments(t *testing.T) {
+	srv, current := setup(t)
+
+	var statuses struct {
+		Data []store.Status `json:"data"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/status", ""), &statuses)
+	ids := map[string]string{}
+	for _, status := range statuses.Data {
+		ids[status.StatusName] = status.ID
+	}
+	var scheduled struct {
+		Data store.Status `json:"data"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodPost, "/api/v1/status", `{"statusName":"scheduled"}`), &scheduled)
+
+	category := srv.CreateCategory(t, user, "Gym", "0")
+	paidID := srv.CreatePaidType(t, user, "Cash")
+	expense := func(statusID string) string {
+		return srv.CreateExpense(t, user, `{"categoryId":"`+category+`","paidId":"`+paidID+`","statusId":"`+statusID+`","referenceMonth":"`+
+			current.String()+`","spentAmount":"80.00","paymentDate":"`+current.String()+`-10"}`)
+	}
+	pending := expense(ids["pending"])
+	expense(ids["paid"])
+	expense(scheduled.Data.ID)
+
+	var resp struct {
+		Data []store.PendingPayment `json:"data"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/dashboard/analytic/pending-payments?month="+current.String(), ""), &resp)
+	// Besides the pending expense, the planned row of each category starts pending
+	found := false
+	plans := map[string]bool{}
+	for _, payment := range resp.Data {
+		if payment.ExpenseID == pending {
+			found = true
+		} else if payment.SpentAmount == 0 {
+			plans[payment.CategoryName] = true
+		}
+	}
+	if len(resp.Data) != 4 || !found || !plans["Rent"] || !plans["Food"] || !plans["Gym"] {
+		t.Errorf("pending payments = %+v, want the three plans and the pending Gym expense", resp.Data)
+	}
+
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/dashboard/analytic/pending-payments?month="+current.Next().String(), ""), &resp)
+	if len(resp.Data) != 0 {
+		t.Errorf("next month: %d pending payments, want none", len(resp.Data))
+	}
+}
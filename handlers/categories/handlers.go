@@ -1,102 +1,172 @@
 package categories
 
 import (
-	"go-sheet/db"
+	"context"
+	"errors"
+	"go-sheet/hierarchy"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/receipts"
+	"go-sheet/store"
+	"go-sheet/workspace"
 	"net/http"
-	"time"
-
-	"database/sql"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 type Category struct {
-	ID            string  `json:"uuid" `
-	Name          string  `json:"name" binding:"required"`
-	PlannedAmount float64 `json:"plannedAmount" binding:"required"`
-	Color         string  `json:"color"`
-	Description   string  `json:"description"`
+	ID            string        `json:"uuid" `
+	Name          string        `json:"name" binding:"required"`
+	ParentID      *string       `json:"parentId"`                         // só na criação; depois use PUT /categories/:id/parent
+	PlannedAmount *money.Amount `json:"plannedAmount" binding:"required"` // ponteiro para aceitar 0
+	Color         string        `json:"color"`
+	Description   string        `json:"description"`
+}
+
+// MoveRequest is the body of MoveCategory; a null parentId makes the
+// category top-level
+type MoveRequest struct {
+	ParentID *string `json:"parentId"`
+}
+
+// CategoryNode is a category of GetCategories with its place in the tree.
+// The total amounts add up the category and all its subcategories; the
+// unconverted expenses of the category are left out of SpentAmount for want
+// of an exchange rate.
+type CategoryNode struct {
+	store.Category
+	Depth               int          `json:"depth"`
+	Path                []string     `json:"path"`
+	SpentAmount         money.Amount `json:"spentAmount"`
+	TotalPlannedAmount  money.Amount `json:"totalPlannedAmount"`
+	TotalSpentAmount    money.Amount `json:"totalSpentAmount"`
+	UnconvertedExpenses int          `json:"unconvertedExpenses"`
+}
+
+// PlanRequest is the body of SetPlan
+type PlanRequest struct {
+	PlannedAmount *money.Amount `json:"plannedAmount" binding:"required"`
+}
+
+// Store is what the category handlers need: the categories, their plans and
+// what was spent on them
+type Store interface {
+	store.CategoryStore
+	store.PlanStore
+	CategoryTotals(ctx context.Context, workspaceID string, from, to month.YearMonth) ([]store.CategoryTotal, error)
+}
+
+// Handler serves the category routes
+type Handler struct {
+	store    Store
+	receipts *receipts.Service
 }
 
-type CategoryResponse struct {
-	CategoryID     string         `json:"categoryId"`
-	CategoryName   string         `json:"categoryName"`
-	PlannedAmount  float64        `json:"plannedAmount"`
-	Color          string         `json:"color"`
-	ReferenceMonth sql.NullString `json:"referenceMonth"`
+// NewHandler returns a Handler backed by the given store. Amounts are
+// reported in the base currency of the workspace. receipts, which may be
+// nil, drops the receipts of the expenses removed by a forced delete.
+func NewHandler(s Store, rs *receipts.Service) *Handler {
+	return &Handler{store: s, receipts: rs}
 }
 
-func GetCategories(ctx *gin.Context) {
-	conn, err := db.OpenConnection()
+// GetCategories lists the categories as a tree, parents before their
+// subcategories, with the plan of ?month=YYYY-MM (the current month by
+// default) and what was spent in it rolled up to every ancestor. Archived categories are left out
+// unless ?includeArchived=true, but still count in their parents' totals: what
+// was spent on them always, their plan only up to the month they were
+// archived in.
+func (h *Handler) GetCategories(ctx *gin.Context) {
+	includeArchived := ctx.Query("includeArchived") == "true"
+
+	targetMonth := month.Current()
+	if value := ctx.Query("month"); value != "" {
+		m, err := month.Parse(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid month format. Use YYYY-MM", "error": err.Error()})
+			return
+		}
+		targetMonth = m
+	}
+
+	ws := workspace.ID(ctx)
+	categories, err := h.store.ListCategories(ctx.Request.Context(), ws)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
-			"message": "Error connecting to database",
+			"message": "Error querying database",
 			"error":   err.Error(),
 		})
 		return
 	}
-	defer conn.Close()
-
-	sqlQuery := `SELECT category_id, category_name, amount_planned, category_color, reference_month FROM categories`
-
-	rows, err := conn.Query(sqlQuery)
+	totals, err := h.store.CategoryTotals(ctx.Request.Context(), ws, targetMonth, targetMonth)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Error querying database",
 			"error":   err.Error(),
-			"rows":    err,
 		})
 		return
 	}
-	defer rows.Close()
-
-	var categories []CategoryResponse
-
-	for rows.Next() {
-		var category CategoryResponse
-		var referenceMonth sql.NullString
-		err := rows.Scan(
-			&category.CategoryID,
-			&category.CategoryName,
-			&category.PlannedAmount,
-			&category.Color,
-			&referenceMonth,
-		)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Error scanning database result",
-				"error":   err.Error(),
-			})
-			return
-		}
-
-		category.ReferenceMonth = referenceMonth
-		categories = append(categories, category)
-	}
-	if len(categories) == 0 {
-		categories = []CategoryResponse{}
-	}
-	if err := rows.Err(); err != nil {
+	planned, err := h.store.PlannedAmounts(ctx.Request.Context(), ws, targetMonth)
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
-			"message": "Error after scanning database results",
+			"message": "Error querying database",
 			"error":   err.Error(),
 		})
 		return
 	}
+	for i, category := range categories {
+		if amount, ok := planned[category.CategoryID]; ok {
+			categories[i].PlannedAmount = amount
+		}
+	}
+
+	tree := hierarchy.New(categories)
+	own := map[string]hierarchy.Amounts{}
+	for _, category := range categories {
+		amounts := hierarchy.Amounts{Planned: category.PlannedAmount}
+		if category.ArchivedAt != nil && month.Of(*category.ArchivedAt).Before(targetMonth) {
+			amounts.Planned = 0
+		}
+		own[category.CategoryID] = amounts
+	}
+	unconverted := map[string]int{}
+	for _, total := range totals {
+		amounts := own[total.CategoryID]
+		amounts.Spent = total.Spent
+		own[total.CategoryID] = amounts
+		unconverted[total.CategoryID] = total.Unconverted
+	}
+	rolled := tree.RollUp(own)
+
+	nodes := []CategoryNode{}
+	for _, id := range tree.Order() {
+		category, _ := tree.Category(id)
+		if category.ArchivedAt != nil && !includeArchived {
+			continue
+		}
+		nodes = append(nodes, CategoryNode{
+			Category:            category,
+			Depth:               tree.Depth(id),
+			Path:                tree.Path(id),
+			SpentAmount:         own[id].Spent,
+			TotalPlannedAmount:  rolled[id].Planned,
+			TotalSpentAmount:    rolled[id].Spent,
+			UnconvertedExpenses: unconverted[id],
+		})
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Successfully retrieved categories",
-		"data":    categories,
+		"status":   "success",
+		"message":  "Successfully retrieved categories",
+		"month":    targetMonth.String(),
+		"currency": workspace.Currency(ctx),
+		"data":     nodes,
 	})
 }
 
-func CreateCategory(ctx *gin.Context) {
+func (h *Handler) CreateCategory(ctx *gin.Context) {
 	var category Category
 
 	if err := ctx.ShouldBindJSON(&category); err != nil {
@@ -107,91 +177,80 @@ func CreateCategory(ctx *gin.Context) {
 		return
 	}
 
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to database"})
-		return
-	}
-	defer conn.Close()
-
-	if conn == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection is nil"})
+	if category.PlannedAmount.IsNegative() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request body", "error": "plannedAmount must not be negative"})
 		return
 	}
 
-	// Inserir categoria na tabela de categorias
-	sqlQuery := `INSERT INTO categories (category_id, category_name, amount_planned, category_color) 
-		VALUES ($1, $2, $3, $4)`
-	category.ID = uuid.NewString()
-	_, err = conn.Exec(sqlQuery, category.ID, category.Name, category.PlannedAmount, category.Color)
+	categoryID, err := h.store.CreateCategory(ctx.Request.Context(), workspace.ID(ctx), categoryInput(category))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert data into database"})
+		respondWithError(ctx, err, "Failed to create category")
 		return
 	}
 
-	// Modificar o formato da data para ser compatível com o tipo date do PostgreSQL
-	referenceMonth := time.Now().Format("2006-01-01") // Alterado para incluir o dia
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":    "Successfully created category and added to monthly expenses",
+		"categoryId": categoryID,
+	})
+}
 
-	// Atualizar a query para incluir reference_month, description e status_id
-	monthlyExpenseQuery := `INSERT INTO monthly_expenses (category_id, reference_month, spent_amount, amount_planned, difference_amount, payment_date, file, description, status_id) 
-		VALUES ($1, $2, NULL, $3, NULL, NULL, NULL, $4, $5)`
+// DeleteCategory archives the category: its expenses stay in the history but
+// it gets no new ones. With ?force=true it is deleted for good instead, along
+// with its subcategories and the expenses, alerts and recurring expenses of
+// all of them.
+func (h *Handler) DeleteCategory(ctx *gin.Context) {
+	categoryID := ctx.Param("id")
 
-	plannedAmount := category.PlannedAmount
-	description := category.Description
+	if ctx.Query("force") != "true" {
+		err := h.store.ArchiveCategory(ctx.Request.Context(), workspace.ID(ctx), categoryID)
+		if err != nil {
+			respondWithError(ctx, err, "Failed to archive category")
+			return
+		}
 
-	// Obter o status_id para "pending"
-	var statusID string
-	err = conn.QueryRow("SELECT status_id FROM status WHERE status_name = 'pending'").Scan(&statusID)
-	if err != nil {
-		// Rollback da inserção na tabela categories
-		sqlQuery = `DELETE FROM categories WHERE category_id = $1`
-		_, _ = conn.Exec(sqlQuery, category.ID)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pending status", "details": err.Error()})
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":     "success",
+			"message":    "Successfully archived category",
+			"categoryId": categoryID,
+		})
 		return
 	}
 
-	_, err = conn.Exec(monthlyExpenseQuery, category.ID, referenceMonth, plannedAmount, description, statusID)
+	receiptKeys, err := h.store.DeleteCategory(ctx.Request.Context(), workspace.ID(ctx), categoryID)
 	if err != nil {
-		// Rollback da inserção na tabela categories
-		sqlQuery = `DELETE FROM categories WHERE category_id = $1`
-		_, _ = conn.Exec(sqlQuery, category.ID)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert into monthly_expenses", "details": err.Error()})
+		respondWithError(ctx, err, "Failed to delete category")
 		return
 	}
+	if h.receipts != nil {
+		for _, key := range receiptKeys {
+			h.receipts.Forget(ctx.Request.Context(), key)
+		}
+	}
 
-	ctx.JSON(http.StatusCreated, gin.H{
-		"message":    "Successfully created category and added to monthly expenses",
-		"categoryId": category.ID,
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Successfully deleted category",
+		"categoryId": categoryID,
 	})
 }
 
-func DeleteCategory(ctx *gin.Context) {
+// UnarchiveCategory puts an archived category back in use
+func (h *Handler) UnarchiveCategory(ctx *gin.Context) {
 	categoryID := ctx.Param("id")
 
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Error connecting to database",
-			"error":   err.Error(),
-		})
-		return
-	}
-	defer conn.Close()
-
-	sqlQuery := `DELETE FROM categories WHERE category_id = $1`
-
-	_, err = conn.Exec(sqlQuery, categoryID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete data from database"})
+	if err := h.store.UnarchiveCategory(ctx.Request.Context(), workspace.ID(ctx), categoryID); err != nil {
+		respondWithError(ctx, err, "Failed to unarchive category")
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "Successfully deleted category",
+		"status":     "success",
+		"message":    "Successfully unarchived category",
+		"categoryId": categoryID,
 	})
 }
 
-func UpdateCategory(ctx *gin.Context) {
+func (h *Handler) UpdateCategory(ctx *gin.Context) {
 	categoryID := ctx.Param("id") // Obtém o ID da categoria a ser atualizada
 
 	var category Category
@@ -205,54 +264,142 @@ func UpdateCategory(ctx *gin.Context) {
 		return
 	}
 
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Error connecting to database",
-			"error":   err.Error(),
-		})
+	if category.PlannedAmount.IsNegative() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request body", "error": "plannedAmount must not be negative"})
 		return
 	}
-	defer conn.Close()
 
-	// Verificar se a categoria existe antes de tentar atualizar
-	var existingCategoryID string
-	err = conn.QueryRow("SELECT category_id FROM categories WHERE category_id = $1", categoryID).Scan(&existingCategoryID)
-	if err == sql.ErrNoRows {
+	err := h.store.UpdateCategory(ctx.Request.Context(), workspace.ID(ctx), categoryID, categoryInput(category))
+	if errors.Is(err, store.ErrNotFound) {
 		ctx.JSON(http.StatusNotFound, gin.H{"message": "Category not found"})
 		return
 	} else if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Error checking category existence", "details": err.Error()})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update category", "details": err.Error()})
 		return
 	}
 
-	// Atualizar a tabela `categories`
-	sqlUpdateCategory := `UPDATE categories 
-                          SET category_name = $1, amount_planned = $2, category_color = $3, description = $4 
-                          WHERE category_id = $5`
-	_, err = conn.Exec(sqlUpdateCategory, category.Name, category.PlannedAmount, category.Color, category.Description, categoryID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update category", "details": err.Error()})
+	// Retornar resposta de sucesso
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Successfully updated category and monthly expense",
+		"categoryId": categoryID,
+	})
+}
+
+// MoveCategory puts a category, with its subcategories, under another
+// parent or at the top level
+func (h *Handler) MoveCategory(ctx *gin.Context) {
+	categoryID := ctx.Param("id")
+
+	var req MoveRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request body", "error": err.Error()})
 		return
 	}
+	req.ParentID = parentID(req.ParentID)
 
-	// Atualizar a tabela `monthly_expenses` para o mês atual
-	currentMonth := time.Now().Format("2006-01-01") // Formato YYYY-MM-01 para o PostgreSQL
-
-	sqlUpdateExpense := `UPDATE monthly_expenses 
-                         SET amount_planned = $1, description = $2 
-                         WHERE category_id = $3 AND reference_month = $4`
-	_, err = conn.Exec(sqlUpdateExpense, category.PlannedAmount, category.Description, categoryID, currentMonth)
+	err := h.store.MoveCategory(ctx.Request.Context(), workspace.ID(ctx), categoryID, req.ParentID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update monthly expense", "details": err.Error()})
+		respondWithError(ctx, err, "Failed to move category")
 		return
 	}
 
-	// Retornar resposta de sucesso
 	ctx.JSON(http.StatusOK, gin.H{
 		"status":     "success",
-		"message":    "Successfully updated category and monthly expense",
+		"message":    "Successfully moved category",
 		"categoryId": categoryID,
+		"parentId":   req.ParentID,
 	})
 }
+
+func categoryInput(category Category) store.CategoryInput {
+	return store.CategoryInput{
+		Name:          category.Name,
+		ParentID:      parentID(category.ParentID),
+		PlannedAmount: *category.PlannedAmount,
+		Color:         category.Color,
+		Description:   category.Description,
+	}
+}
+
+// parentID treats an empty parentId like null
+func parentID(id *string) *string {
+	if id != nil && *id == "" {
+		return nil
+	}
+	return id
+}
+
+// ListPlans returns the planned amounts of a category by the month they take
+// effect, oldest first
+func (h *Handler) ListPlans(ctx *gin.Context) {
+	plans, err := h.store.ListPlans(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"))
+	if err != nil {
+		respondWithError(ctx, err, "Error querying plans")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Plans retrieved successfully",
+		"currency": workspace.Currency(ctx),
+		"data":     gin.H{"categoryId": ctx.Param("id"), "plans": plans},
+	})
+}
+
+// SetPlan sets the planned amount of a category from :month (YYYY-MM) until
+// its next plan. The expenses of those months are updated to match.
+func (h *Handler) SetPlan(ctx *gin.Context) {
+	m, err := month.Parse(ctx.Param("month"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid month format. Use YYYY-MM", "error": err.Error()})
+		return
+	}
+
+	var req PlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body", "error": err.Error()})
+		return
+	}
+	if req.PlannedAmount.IsNegative() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body", "error": "plannedAmount must not be negative"})
+		return
+	}
+
+	ws, categoryID := workspace.ID(ctx), ctx.Param("id")
+	if err := h.store.SetPlan(ctx.Request.Context(), ws, categoryID, m, *req.PlannedAmount); err != nil {
+		respondWithError(ctx, err, "Failed to save plan")
+		return
+	}
+	plans, err := h.store.ListPlans(ctx.Request.Context(), ws, categoryID)
+	if err != nil {
+		respondWithError(ctx, err, "Error querying plans")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Plan saved successfully",
+		"currency": workspace.Currency(ctx),
+		"data":     gin.H{"categoryId": categoryID, "plans": plans},
+	})
+}
+
+// respondWithError maps store errors to HTTP statuses; ErrUnknownCategory
+// is about the parent, since the category itself answers ErrNotFound
+func respondWithError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Category not found"})
+	case errors.Is(err, store.ErrUnknownCategory):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"status": "error", "message": "Parent category not found"})
+	case errors.Is(err, store.ErrHasSubcategories):
+		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "Move, archive or delete the subcategories first", "error": err.Error()})
+	case errors.Is(err, store.ErrCategoryCycle):
+		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "Invalid parent", "error": err.Error()})
+	case errors.Is(err, store.ErrCategoryArchived):
+		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "Parent category is archived", "error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": message, "error": err.Error()})
+	}
+}
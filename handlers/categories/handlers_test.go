@@ -0,0 +1,240 @@
+package categories_test
+
+import (
+	"go-sheet/handlers/categories"
+	"go-sheet/handlers/handlertest"
+	"go-sheet/month"
+	"go-sheet/store"
+	"net/http"
+	"testing"
+)
+
+const user = handlertest.UserA
+
+type listResponse struct {
+	Month string                    `json:"month"`
+	Data  []categories.CategoryNode `json:"data"`
+}
+
+func list(t *testing.T, srv *handlertest.Server, query string) map[string]categories.CategoryNode {
+	t.Helper()
+	var resp listResponse
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/categories"+query, ""), &resp)
+	nodes := map[string]categories.CategoryNode{}
+	for _, node := range resp.Data {
+		nodes[node.CategoryID] = node
+	}
+	return nodes
+}
+
+func createChild(t *testing.T, srv *handlertest.Server, name, planned, parent string) string {
+	t.Helper()
+	var created struct {
+		CategoryID string `json:"categoryId"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusCreated, user, http.MethodPost, "/api/v1/categories", `{"name":"`+name+`","plannedAmount":"`+planned+`","parentId":"`+parent+`"}`), &created)
+	return created.CategoryID
+}
+
+func TestCreateCategory(t *testing.T) {
+	srv := handlertest.New(t)
+
+	id := srv.CreateCategory(t, user, "Rent", "1500.00")
+	zero := srv.CreateCategory(t, user, "Gifts", "0")
+
+	nodes := list(t, srv, "")
+	if node := nodes[id]; node.CategoryName != "Rent" || node.PlannedAmount.String() != "1500.00" || node.Depth != 0 {
+		t.Errorf("Rent = %+v", node)
+	}
+	if node, ok := nodes[zero]; !ok || node.PlannedAmount != 0 {
+		t.Errorf("Gifts = %+v, want a zero plan", node)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"missing name", `{"plannedAmount":10}`, http.StatusBadRequest},
+		{"missing planned amount", `{"name":"Travel"}`, http.StatusBadRequest},
+		{"negative planned amount", `{"name":"Travel","plannedAmount":"-10.00"}`, http.StatusBadRequest},
+		{"too many decimals", `{"name":"Travel","plannedAmount":"10.001"}`, http.StatusBadRequest},
+		{"not JSON", `Travel`, http.StatusBadRequest},
+		{"unknown parent", `{"name":"Travel","plannedAmount":10,"parentId":"00000000-0000-0000-0000-000000000000"}`, http.StatusUnprocessableEntity},
+		{"subcategory", `{"name":"Deposit","plannedAmount":10,"parentId":"` + id + `"}`, http.StatusCreated},
+		{"empty parent is top level", `{"name":"Travel","plannedAmount":10,"parentId":""}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		if w := srv.Do(t, user, http.MethodPost, "/api/v1/categories", tt.body); w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+}
+
+func TestUpdateCategory(t *testing.T) {
+	srv := handlertest.New(t)
+	id := srv.CreateCategory(t, user, "Rent", "1500.00")
+	previous := month.Current().Prev().String()
+	srv.Expect(t, http.StatusOK, user, http.MethodPut, "/api/v1/categories/"+id+"/plans/"+previous, `{"plannedAmount":"1400.00"}`)
+
+	srv.Expect(t, http.StatusOK, user, http.MethodPut, "/api/v1/categories/"+id, `{"name":"Housing","plannedAmount":"1650.00","color":"#123456"}`)
+	node := list(t, srv, "")[id]
+	if node.CategoryName != "Housing" || node.PlannedAmount.String() != "1650.00" || node.Color != "#123456" {
+		t.Errorf("updated category = %+v", node)
+	}
+
+	// Months before the change keep their plan
+	if got := list(t, srv, "?month="+previous)[id].PlannedAmount.String(); got != "1400.00" {
+		t.Errorf("plan of %s = %s, want 1400.00", previous, got)
+	}
+
+	srv.Expect(t, http.StatusOK, user, http.MethodPut, "/api/v1/categories/"+id, `{"name":"Housing","plannedAmount":0}`)
+	if got := list(t, srv, "")[id].PlannedAmount; got != 0 {
+		t.Errorf("plannedAmount = %s, want it cleared to 0", got)
+	}
+
+	tests := []struct {
+		name   string
+		id     string
+		body   string
+		status int
+	}{
+		{"unknown category", "00000000-0000-0000-0000-000000000000", `{"name":"X","plannedAmount":1}`, http.StatusNotFound},
+		{"missing name", id, `{"plannedAmount":1}`, http.StatusBadRequest},
+		{"missing planned amount", id, `{"name":"X"}`, http.StatusBadRequest},
+		{"negative planned amount", id, `{"name":"X","plannedAmount":"-1.00"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := srv.Do(t, user, http.MethodPut, "/api/v1/categories/"+tt.id, tt.body); w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+}
+
+func TestUpdateCategoryKeepsExpenseDescriptions(t *testing.T) {
+	srv := handlertest.New(t)
+	id := srv.CreateCategory(t, user, "Rent", "1500.00")
+	paidID := srv.CreatePaidType(t, user, "Transfer")
+	current := month.Current().String()
+	expenseID := srv.CreateExpense(t, user, `{"categoryId":"`+id+`","paidId":"`+paidID+`","referenceMonth":"`+current+
+		`","spentAmount":"1500.00","paymentDate":"`+current+`-05","description":"October rent"}`)
+
+	srv.Expect(t, http.StatusOK, user, http.MethodPut, "/api/v1/categories/"+id, `{"name":"Rent","plannedAmount":"1500.00","description":"Apartment"}`)
+
+	var resp struct {
+		Expense store.Expense `json:"expense"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/expenses/"+expenseID, ""), &resp)
+	if resp.Expense.Description == nil || *resp.Expense.Description != "October rent" {
+		t.Errorf("expense description = %v, want it untouched", resp.Expense.Description)
+	}
+}
+
+func TestGetCategoriesRollsUpTotals(t *testing.T) {
+	srv := handlertest.New(t)
+	parent := srv.CreateCategory(t, user, "Home", "100.00")
+	child := createChild(t, srv, "Power", "40.00", parent)
+	paidID := srv.CreatePaidType(t, user, "Card")
+	current := month.Current().String()
+	srv.CreateExpense(t, user, `{"categoryId":"`+child+`","paidId":"`+paidID+`","referenceMonth":"`+current+`","spentAmount":"35.50","paymentDate":"`+current+`-05"}`)
+
+	nodes := list(t, srv, "")
+	if node := nodes[parent]; node.TotalPlannedAmount.String() != "140.00" || node.TotalSpentAmount.String() != "35.50" || node.SpentAmount != 0 {
+		t.Errorf("Home = %+v", node)
+	}
+	if node := nodes[child]; node.Depth != 1 || node.SpentAmount.String() != "35.50" || len(node.Path) != 2 {
+		t.Errorf("Power = %+v", node)
+	}
+
+	if w := srv.Do(t, user, http.MethodGet, "/api/v1/categories?month=soon", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bad month: status %d, want 400", w.Code)
+	}
+}
+
+func TestMoveCategory(t *testing.T) {
+	srv := handlertest.New(t)
+	home := srv.CreateCategory(t, user, "Home", "100.00")
+	utilities := createChild(t, srv, "Utilities", "10.00", home)
+	power := createChild(t, srv, "Power", "40.00", utilities)
+	food := srv.CreateCategory(t, user, "Food", "200.00")
+	archived := srv.CreateCategory(t, user, "Old", "0")
+	srv.Expect(t, http.StatusOK, user, http.MethodDelete, "/api/v1/categories/"+archived, "")
+
+	tests := []struct {
+		name   string
+		id     string
+		parent string
+		status int
+	}{
+		{"under itself", home, `"` + home + `"`, http.StatusConflict},
+		{"under its grandchild", home, `"` + power + `"`, http.StatusConflict},
+		{"under its child", utilities, `"` + power + `"`, http.StatusConflict},
+		{"unknown parent", food, `"00000000-0000-0000-0000-000000000000"`, http.StatusUnprocessableEntity},
+		{"archived parent", food, `"` + archived + `"`, http.StatusConflict},
+		{"unknown category", "00000000-0000-0000-0000-000000000000", `"` + home + `"`, http.StatusNotFound},
+		{"subtree to another parent", utilities, `"` + food + `"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		srv.Expect(t, tt.status, user, http.MethodPut, "/api/v1/categories/"+tt.id+"/parent", `{"parentId":`+tt.parent+`}`)
+	}
+
+	// Utilities took Power along; Home only keeps its own plan
+	nodes := list(t, srv, "")
+	if got := nodes[home].TotalPlannedAmount.String(); got != "100.00" {
+		t.Errorf("Home total planned %s, want 100.00", got)
+	}
+	if got := nodes[food].TotalPlannedAmount.String(); got != "250.00" {
+		t.Errorf("Food total planned %s, want 250.00", got)
+	}
+	if node := nodes[power]; node.Depth != 2 || node.Path[0] != "Food" {
+		t.Errorf("Power = depth %d, path %v", node.Depth, node.Path)
+	}
+
+	// An empty parentId moves it back to the top level
+	srv.Expect(t, http.StatusOK, user, http.MethodPut, "/api/v1/categories/"+utilities+"/parent", `{"parentId":""}`)
+	if node := list(t, srv, "")[utilities]; node.Depth != 0 || node.ParentID != nil {
+		t.Errorf("Utilities = depth %d, parent %v", node.Depth, node.ParentID)
+	}
+}
+
+func TestArchivedPlanCountsUntilArchived(t *testing.T) {
+	srv := handlertest.New(t)
+	parent := srv.CreateCategory(t, user, "Home", "100.00")
+	child := createChild(t, srv, "Power", "40.00", parent)
+	srv.Expect(t, http.StatusOK, user, http.MethodDelete, "/api/v1/categories/"+child, "")
+
+	current := month.Current()
+	tests := []struct {
+		month month.YearMonth
+		want  string
+	}{
+		{current, "140.00"},
+		{current.Next(), "100.00"},
+	}
+	for _, tt := range tests {
+		if got := list(t, srv, "?month="+tt.month.String())[parent].TotalPlannedAmount.String(); got != tt.want {
+			t.Errorf("Home in %s: total planned %s, want %s", tt.month, got, tt.want)
+		}
+	}
+}
+
+func TestForceDeleteRemovesSubcategories(t *testing.T) {
+	srv := handlertest.New(t)
+	parent := srv.CreateCategory(t, user, "Home", "100.00")
+	kept := srv.CreateCategory(t, user, "Food", "50.00")
+	child := createChild(t, srv, "Power", "40.00", parent)
+	paidID := srv.CreatePaidType(t, user, "Card")
+	current := month.Current().String()
+	expenseID := srv.CreateExpense(t, user, `{"categoryId":"`+child+`","paidId":"`+paidID+`","referenceMonth":"`+current+`","spentAmount":"35.50","paymentDate":"`+current+`-05"}`)
+
+	srv.Expect(t, http.StatusOK, user, http.MethodDelete, "/api/v1/categories/"+parent+"?force=true", "")
+
+	nodes := list(t, srv, "?includeArchived=true")
+	if _, ok := nodes[child]; ok || len(nodes) != 1 {
+		t.Errorf("left %+v, want only Food", nodes)
+	}
+	if _, ok := nodes[kept]; !ok {
+		t.Errorf("Food is gone")
+	}
+	srv.Expect(t, http.StatusNotFound, user, http.MethodGet, "/api/v1/expenses/"+expenseID, "")
+}
@@ -1,222 +1,467 @@
 package handlers
 
 import (
-	"database/sql"
-	"go-sheet/db"
+	"errors"
+	"fmt"
+	"go-sheet/alerts"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/receipts"
+	"go-sheet/rules"
+	"go-sheet/store"
+	"go-sheet/workspace"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
+// MonthlyExpense is the body of CreateExpense and UpdateExpense. On create
+// categoryId, paidId and statusId may be left out when a categorisation rule
+// fills them in; UpdateExpense requires categoryId and paidId and leaves the
+// status as it is.
+// currency is the one spentAmount was paid in, by default the base currency
+// of the workspace.
 type MonthlyExpense struct {
-	CategoryID     string  `json:"categoryId" binding:"required"`
-	ReferenceMonth string  `json:"referenceMonth" binding:"required"`
-	PaidId         string  `json:"paidId" binding:"required"`
-	SpentAmount    float64 `json:"spentAmount" binding:"required"`
-	PaymentDate    string  `json:"paymentDate" binding:"required"`
-	File           string  `json:"file"`
-}
-
-// Update the MonthlyExpenseResponse struct
-type MonthlyExpenseResponse struct {
-	ExpenseID      string   `json:"expenseId"`
-	CategoryName   string   `json:"categoryName"`
-	ReferenceMonth *string  `json:"referenceMonth"` // colocar * significa que o campo é opcional
-	SpentAmount    *float64 `json:"spentAmount"`
-	PlannedAmount  float64  `json:"plannedAmount"`
-	Difference     *float64 `json:"difference"`
-	PaymentDate    *string  `json:"paymentDate"`
-	File           *string  `json:"file"`
-	PaidId         *string  `json:"paidId"`
-	PaidType       *string  `json:"paidType"`  // Add this field
-	PaidColor      *string  `json:"paidColor"` // Change this to a pointer
-	StatusId       *string  `json:"statusId"`
-	StatusName     *string  `json:"statusName"`
-	Description    *string  `json:"description"`
-}
-
-// ListMonthlyExpenses retrieves all monthly expenses with category details
-func ListMonthlyExpenses(ctx *gin.Context) {
-
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to database"})
-		return
-	}
-	defer conn.Close()
-
-	// Update the query in the ListMonthlyExpenses function
-	query := `
-		SELECT 
-			me.expense_id,
-			c.category_name,
-			me.reference_month,
-			me.spent_amount,
-			me.amount_planned,
-			(me.amount_planned - me.spent_amount) AS difference,
-			me.payment_date,
-			me.file,
-			pt.paid_id,
-			pt.paid_type AS paid_type,
-			pt.paid_color AS paid_color,
-			st.status_id AS status_id,
-			st.status_name AS status_name,
-			me.description AS description
-		FROM 
-			monthly_expenses me
-		JOIN 
-			categories c ON me.category_id = c.category_id
-		LEFT JOIN
-			paid_type pt ON me.paid_id::text = pt.paid_id::text
-		LEFT JOIN
-			status st ON me.status_id::text = st.status_id::text
-		ORDER BY 
-			me.reference_month DESC;
-	`
-
-	rows, err := conn.Query(query)
+	CategoryID     string        `json:"categoryId"`
+	ReferenceMonth string        `json:"referenceMonth" binding:"required"`
+	PaidId         string        `json:"paidId"`
+	SpentAmount    *money.Amount `json:"spentAmount" binding:"required"` // pointer so that 0 is accepted
+	PaymentDate    string        `json:"paymentDate" binding:"required"`
+	File           string        `json:"file"`
+	StatusId       string        `json:"statusId"`
+	Description    string        `json:"description"`
+	Currency       string        `json:"currency"`
+}
+
+// MonthlyExpensePatch holds the fields accepted by PatchExpense; nil fields are left untouched
+type MonthlyExpensePatch struct {
+	CategoryID     *string       `json:"categoryId"`
+	ReferenceMonth *string       `json:"referenceMonth"`
+	PaidId         *string       `json:"paidId"`
+	SpentAmount    *money.Amount `json:"spentAmount"`
+	PaymentDate    *string       `json:"paymentDate"`
+	File           *string       `json:"file"`
+	StatusId       *string       `json:"statusId"`
+	Description    *string       `json:"description"`
+	Currency       *string       `json:"currency"`
+}
+
+// Handler serves the monthly expense routes
+type Handler struct {
+	store    store.ExpenseStore
+	rules    rules.Lister
+	receipts *receipts.Service
+	alerts   *alerts.Service
+}
+
+// NewHandler returns a Handler backed by the given stores. Amounts are
+// reported in the base currency of the workspace. receipts may be nil when uploads are disabled;
+// otherwise expenses with a receipt get its download link in file. Saving
+// an expense checks the budget alerts of its category unless as is nil.
+func NewHandler(s store.ExpenseStore, r rules.Lister, rs *receipts.Service, as *alerts.Service) *Handler {
+	return &Handler{store: s, rules: r, receipts: rs, alerts: as}
+}
+
+// checkAlerts returns the budget alerts raised by saving an expense
+func (h *Handler) checkAlerts(ctx *gin.Context, expenseID string) []store.Alert {
+	if h.alerts == nil {
+		return []store.Alert{}
+	}
+	return h.alerts.Check(ctx.Request.Context(), workspace.ID(ctx), expenseID)
+}
+
+// link fills in the receipt download link of an expense
+func (h *Handler) link(ctx *gin.Context, expense *store.Expense) {
+	if h.receipts != nil {
+		h.receipts.Link(workspace.ID(ctx), expense)
+	}
+}
+
+// ListMonthlyExpenses retrieves one page of monthly expenses with category
+// details. Query parameters:
+//
+//	month=YYYY-MM, or from=YYYY-MM / to=YYYY-MM (inclusive)
+//	categoryId, paidId, statusId
+//	minAmount, maxAmount  bounds on spentAmount
+//	q                     text searched in the description
+//	sort                  referenceMonth, paymentDate, spentAmount, plannedAmount
+//	                      or categoryName; prefix with "-" for descending
+//	                      (default -referenceMonth)
+//	limit                 page size, up to 200 (default 50)
+//	cursor                nextCursor of the previous page
+func (h *Handler) ListMonthlyExpenses(ctx *gin.Context) {
+	query, err := parseExpenseQuery(ctx)
 	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status": "error"})
+		return
+	}
+
+	page, err := h.store.ListExpenses(ctx.Request.Context(), workspace.ID(ctx), query)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "cursor is invalid or does not match sort", "status": "error"})
+		return
+	} else if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query expenses", "details": err.Error()})
 		return
 	}
-	defer rows.Close()
-
-	var expenses []MonthlyExpenseResponse
-
-	for rows.Next() {
-		var expense MonthlyExpenseResponse
-		var spentAmount, difference sql.NullFloat64
-		var paymentDate, file, paidId, paidType, paidColor sql.NullString
-		var referenceMonth sql.NullTime // Mudança aqui
-		var statusId, statusName, description sql.NullString
-		err := rows.Scan(
-			&expense.ExpenseID,
-			&expense.CategoryName,
-			&referenceMonth,
-			&spentAmount,
-			&expense.PlannedAmount,
-			&difference,
-			&paymentDate,
-			&file,
-			&paidId,
-			&paidType,
-			&paidColor,
-			&statusId,
-			&statusName,
-			&description,
-		)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan row", "details": err.Error()})
-			return
-		}
 
-		if spentAmount.Valid {
-			expense.SpentAmount = &spentAmount.Float64
+	for i := range page.Expenses {
+		h.link(ctx, &page.Expenses[i])
+	}
+
+	var nextCursor *string
+	if page.NextCursor != "" {
+		nextCursor = &page.NextCursor
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Expenses retrieved successfully",
+		"currency":   workspace.Currency(ctx),
+		"expenses":   page.Expenses,
+		"nextCursor": nextCursor,
+	})
+}
+
+// parseExpenseQuery reads the filters, order and page of ListMonthlyExpenses
+func parseExpenseQuery(ctx *gin.Context) (store.ExpenseQuery, error) {
+	query := store.ExpenseQuery{
+		CategoryID: ctx.Query("categoryId"),
+		PaidID:     ctx.Query("paidId"),
+		StatusID:   ctx.Query("statusId"),
+		Search:     strings.TrimSpace(ctx.Query("q")),
+	}
+
+	if value := ctx.Query("month"); value != "" {
+		if ctx.Query("from") != "" || ctx.Query("to") != "" {
+			return query, errors.New("use either month or from/to")
 		}
-		if difference.Valid {
-			expense.Difference = &difference.Float64
+		m, err := month.Parse(value)
+		if err != nil {
+			return query, errors.New("month: expected YYYY-MM")
 		}
-		if paymentDate.Valid {
-			expense.PaymentDate = &paymentDate.String
+		query.From, query.To = m, m
+	}
+	for param, dest := range map[string]*month.YearMonth{"from": &query.From, "to": &query.To} {
+		if value := ctx.Query(param); value != "" {
+			m, err := month.Parse(value)
+			if err != nil {
+				return query, fmt.Errorf("%s: expected YYYY-MM", param)
+			}
+			*dest = m
 		}
-		if file.Valid {
-			expense.File = &file.String
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		return query, errors.New("to must not be before from")
+	}
+
+	for param, dest := range map[string]**money.Amount{"minAmount": &query.MinAmount, "maxAmount": &query.MaxAmount} {
+		if value := ctx.Query(param); value != "" {
+			amount, err := money.Parse(value)
+			if err != nil {
+				return query, fmt.Errorf("%s: %w", param, err)
+			}
+			*dest = &amount
 		}
-		if paidId.Valid {
-			expense.PaidId = &paidId.String
+	}
+
+	if value := ctx.Query("sort"); value != "" {
+		query.Desc = strings.HasPrefix(value, "-")
+		query.Sort = store.ExpenseSort(strings.TrimPrefix(value, "-"))
+		if !query.Sort.Valid() {
+			return query, fmt.Errorf("sort: unknown field %q", query.Sort)
 		}
-		if paidType.Valid {
-			expense.PaidType = &paidType.String
+	}
+
+	if value := ctx.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > store.MaxExpenseLimit {
+			return query, fmt.Errorf("limit must be between 1 and %d", store.MaxExpenseLimit)
 		}
-		if paidColor.Valid {
-			expense.PaidColor = &paidColor.String
+		query.Limit = limit
+	}
+
+	if value := ctx.Query("cursor"); value != "" {
+		cursor, err := store.DecodeCursor(value)
+		if err != nil {
+			return query, errors.New("cursor is invalid")
 		}
-		if statusId.Valid {
-			expense.StatusId = &statusId.String
+		query.Cursor = &cursor
+	}
+
+	query.Normalize()
+	return query, nil
+}
+
+// CreateExpense inserts a new monthly expense into the database. Without a
+// categoryId the workspace rules choose the category.
+func (h *Handler) CreateExpense(ctx *gin.Context) {
+	var expense MonthlyExpense
+
+	// Bind the JSON received to the MonthlyExpense struct
+	if err := ctx.ShouldBindJSON(&expense); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status": "error"})
+		return
+	}
+
+	input, ok := parseExpense(ctx, expense)
+	if !ok {
+		return
+	}
+
+	// Os campos que faltam vêm das regras; os enviados nunca são sobrescritos
+	if input.CategoryID == "" || input.PaidID == "" || input.StatusID == "" {
+		engine, err := rules.Load(ctx.Request.Context(), h.rules, workspace.ID(ctx))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load rules", "details": err.Error(), "status": "error"})
+			return
 		}
-		if statusName.Valid {
-			expense.StatusName = &statusName.String
+		result := engine.Apply(rules.Transaction{
+			Description: input.Description,
+			Amount:      input.SpentAmount,
+			PaidID:      input.PaidID,
+			Date:        input.PaymentDate,
+		})
+		if input.CategoryID == "" && result.CategoryID != nil {
+			input.CategoryID = *result.CategoryID
 		}
-		if description.Valid {
-			expense.Description = &description.String
+		if input.PaidID == "" && result.PaidID != nil {
+			input.PaidID = *result.PaidID
 		}
-
-		if referenceMonth.Valid {
-			formattedDate := referenceMonth.Time.Format("2006-01-02")
-			expense.ReferenceMonth = &formattedDate
+		if input.StatusID == "" && result.StatusID != nil {
+			input.StatusID = *result.StatusID
 		}
+	}
+	if input.CategoryID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "categoryId is required: no rule sets a category for this expense", "status": "error"})
+		return
+	}
+	if input.PaidID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "paidId is required: no rule sets a paid type for this expense", "status": "error"})
+		return
+	}
 
-		expenses = append(expenses, expense)
+	expenseID, err := h.store.CreateExpense(ctx.Request.Context(), workspace.ID(ctx), input)
+	if err != nil {
+		respondWithError(ctx, err, "Failed to insert expense")
+		return
 	}
 
-	if err := rows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to iterate over rows", "details": err.Error()})
+	// Return success with the generated UUID
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":    "Expense created successfully",
+		"expense_id": expenseID,
+		"alerts":     h.checkAlerts(ctx, expenseID),
+		"status":     "success",
+	})
+}
+
+// ShowExpense retrieves a single monthly expense by its id
+func (h *Handler) ShowExpense(ctx *gin.Context) {
+	expense, err := h.store.GetExpense(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"))
+	if err != nil {
+		respondWithError(ctx, err, "Failed to query expense")
 		return
 	}
+	h.link(ctx, &expense)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"status":   "success",
-		"message":  "Expenses retrieved successfully",
-		"expenses": expenses,
+		"message":  "Expense retrieved successfully",
+		"currency": workspace.Currency(ctx),
+		"expense":  expense,
 	})
 }
 
-// CreateExpense inserts a new monthly expense into the database
-func CreateExpense(ctx *gin.Context) {
+// UpdateExpense replaces every editable field of an existing monthly expense
+func (h *Handler) UpdateExpense(ctx *gin.Context) {
 	var expense MonthlyExpense
-
-	// Bind the JSON received to the MonthlyExpense struct
 	if err := ctx.ShouldBindJSON(&expense); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status": "error"})
 		return
 	}
+	if expense.CategoryID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "categoryId is required", "status": "error"})
+		return
+	}
+	if expense.PaidId == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "paidId is required", "status": "error"})
+		return
+	}
 
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to database", "status": "error"})
+	input, ok := parseExpense(ctx, expense)
+	if !ok {
 		return
 	}
-	defer conn.Close()
 
-	// Validate and convert the dates
-	refMonth, err := time.Parse("2006-01-02", expense.ReferenceMonth)
+	updated, err := h.store.UpdateExpense(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"), input)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference month format. Use YYYY-MM-DD", "status": "error"})
+		respondWithError(ctx, err, "Failed to update expense")
 		return
 	}
+	h.link(ctx, &updated)
 
-	payDate, err := time.Parse("2006-01-02", expense.PaymentDate)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment date format. Use YYYY-MM-DD", "status": "error"})
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Expense updated successfully",
+		"currency": workspace.Currency(ctx),
+		"expense":  updated,
+		"alerts":   h.checkAlerts(ctx, updated.ExpenseID),
+	})
+}
+
+// PatchExpense updates only the fields present in the request body
+func (h *Handler) PatchExpense(ctx *gin.Context) {
+	var body MonthlyExpensePatch
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status": "error"})
 		return
 	}
 
-	// Fetch amount_planned from the categories table to insert it into the monthly_expenses
-	var amountPlanned float64
-	err = conn.QueryRow(`SELECT amount_planned FROM categories WHERE category_id = $1`, expense.CategoryID).Scan(&amountPlanned)
+	if body.SpentAmount != nil && body.SpentAmount.IsNegative() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "spentAmount must not be negative", "status": "error"})
+		return
+	}
+
+	patch := store.ExpensePatch{
+		CategoryID:  body.CategoryID,
+		PaidID:      body.PaidId,
+		SpentAmount: body.SpentAmount,
+		File:        body.File,
+		StatusID:    body.StatusId,
+		Description: body.Description,
+	}
+	if body.ReferenceMonth != nil {
+		refMonth, err := month.Parse(*body.ReferenceMonth)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference month format. Use YYYY-MM or YYYY-MM-DD", "status": "error"})
+			return
+		}
+		patch.ReferenceMonth = &refMonth
+	}
+	if body.PaymentDate != nil {
+		payDate, err := time.Parse("2006-01-02", *body.PaymentDate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment date format. Use YYYY-MM-DD", "status": "error"})
+			return
+		}
+		patch.PaymentDate = &payDate
+	}
+	if body.Currency != nil {
+		currency, ok := parseCurrency(ctx, *body.Currency)
+		if !ok {
+			return
+		}
+		patch.Currency = &currency
+	}
+
+	updated, err := h.store.PatchExpense(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"), patch)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve planned amount for the category", "status": "error"})
+		respondWithError(ctx, err, "Failed to update expense")
 		return
 	}
+	h.link(ctx, &updated)
 
-	// Generate a UUID for the new expense
-	newUUID := uuid.New()
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Expense updated successfully",
+		"currency": workspace.Currency(ctx),
+		"expense":  updated,
+		"alerts":   h.checkAlerts(ctx, updated.ExpenseID),
+	})
+}
 
-	// Insert into the monthly_expenses table
-	sqlQuery := `INSERT INTO monthly_expenses (expense_id, category_id, reference_month, spent_amount, amount_planned, payment_date, paid_id, file) 
-              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	_, err = conn.Exec(sqlQuery, newUUID, expense.CategoryID, refMonth, expense.SpentAmount, amountPlanned, payDate, expense.PaidId, expense.File)
+// DeleteExpense removes a monthly expense and its receipt
+func (h *Handler) DeleteExpense(ctx *gin.Context) {
+	expense, err := h.store.GetExpense(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert expense", "status": "error"})
+		respondWithError(ctx, err, "Failed to delete expense")
+		return
+	}
+	if err := h.store.DeleteExpense(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id")); err != nil {
+		respondWithError(ctx, err, "Failed to delete expense")
 		return
 	}
+	if h.receipts != nil {
+		h.receipts.Forget(ctx.Request.Context(), expense.ReceiptKey)
+	}
 
-	// Return success with the generated UUID
 	ctx.JSON(http.StatusOK, gin.H{
-		"message":    "Expense created successfully",
-		"expense_id": newUUID,
-		"status":     "success",
+		"status":  "success",
+		"message": "Expense deleted successfully",
 	})
 }
+
+// parseExpense validates the amount and converts the dates of a full expense body,
+// answering with 400 and returning false when they are malformed
+func parseExpense(ctx *gin.Context, expense MonthlyExpense) (store.ExpenseInput, bool) {
+	if expense.SpentAmount.IsNegative() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "spentAmount must not be negative", "status": "error"})
+		return store.ExpenseInput{}, false
+	}
+
+	// Only the month matters: reference_month always holds the first day
+	refMonth, err := month.Parse(expense.ReferenceMonth)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference month format. Use YYYY-MM or YYYY-MM-DD", "status": "error"})
+		return store.ExpenseInput{}, false
+	}
+
+	payDate, err := time.Parse("2006-01-02", expense.PaymentDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment date format. Use YYYY-MM-DD", "status": "error"})
+		return store.ExpenseInput{}, false
+	}
+
+	currency, ok := parseCurrency(ctx, expense.Currency)
+	if !ok {
+		return store.ExpenseInput{}, false
+	}
+
+	return store.ExpenseInput{
+		CategoryID:     expense.CategoryID,
+		ReferenceMonth: refMonth,
+		PaidID:         expense.PaidId,
+		SpentAmount:    *expense.SpentAmount,
+		PaymentDate:    payDate,
+		File:           expense.File,
+		StatusID:       expense.StatusId,
+		Description:    strings.TrimSpace(expense.Description),
+		Currency:       currency,
+	}, true
+}
+
+// parseCurrency validates an optional currency code; empty stands for the
+// base currency
+func parseCurrency(ctx *gin.Context, value string) (money.Currency, bool) {
+	if value == "" {
+		return "", true
+	}
+	currency, err := money.ParseCurrency(value)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "currency: " + err.Error(), "status": "error"})
+		return "", false
+	}
+	return currency, true
+}
+
+// respondWithError maps store errors to HTTP statuses
+func respondWithError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Expense not found", "status": "error"})
+	case errors.Is(err, store.ErrUnknownCategory):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Category not found", "status": "error"})
+	case errors.Is(err, store.ErrCategoryArchived):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Category is archived", "status": "error"})
+	case errors.Is(err, store.ErrUnknownPaidType):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Paid type not found", "status": "error"})
+	case errors.Is(err, store.ErrUnknownStatus):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Status not found", "status": "error"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error(), "status": "error"})
+	}
+}
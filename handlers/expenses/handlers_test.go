@@ -0,0 +1,279 @@
+package handlers_test
+
+import (
+	"go-sheet/handlers/handlertest"
+	"go-sheet/store"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+const user = handlertest.UserA
+
+type expenseResponse struct {
+	Status   string        `json:"status"`
+	Currency string        `json:"currency"`
+	Expense  store.Expense `json:"expense"`
+}
+
+type listResponse struct {
+	Expenses   []store.Expense `json:"expenses"`
+	NextCursor *string         `json:"nextCursor"`
+}
+
+// setup creates a category and a paid type to spend on
+func setup(t *testing.T) (srv *handlertest.Server, categoryID, paidID string) {
+	srv = handlertest.New(t)
+	categoryID = srv.CreateCategory(t, user, "Groceries", "500.00")
+	paidID = srv.CreatePaidType(t, user, "Card")
+	return srv, categoryID, paidID
+}
+
+func expenseBody(categoryID, paidID, month, amount, description string) string {
+	return `{"categoryId":"` + categoryID + `","paidId":"` + paidID + `","referenceMonth":"` + month +
+		`","spentAmount":"` + amount + `","paymentDate":"` + month + `-05","description":"` + description + `"}`
+}
+
+func show(t *testing.T, srv *handlertest.Server, id string) store.Expense {
+	t.Helper()
+	var resp expenseResponse
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/expenses/"+id, ""), &resp)
+	return resp.Expense
+}
+
+func TestCreateAndShowExpense(t *testing.T) {
+	srv, categoryID, paidID := setup(t)
+
+	id := srv.CreateExpense(t, user, expenseBody(categoryID, paidID, "2025-03", "120.50", " Market "))
+
+	var resp expenseResponse
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/expenses/"+id, ""), &resp)
+	expense := resp.Expense
+	if resp.Status != "success" || resp.Currency != "BRL" {
+		t.Errorf("status %q, currency %q", resp.Status, resp.Currency)
+	}
+	if expense.ExpenseID != id || expense.CategoryName != "Groceries" {
+		t.Errorf("expense = %+v", expense)
+	}
+	if expense.SpentAmount == nil || expense.SpentAmount.String() != "120.50" {
+		t.Errorf("spentAmount = %v, want 120.50", expense.SpentAmount)
+	}
+	if expense.PlannedAmount.String() != "500.00" {
+		t.Errorf("plannedAmount = %s, want 500.00", expense.PlannedAmount)
+	}
+	if expense.ReferenceMonth == nil || *expense.ReferenceMonth != "2025-03-01" {
+		t.Errorf("referenceMonth = %v", expense.ReferenceMonth)
+	}
+	if expense.PaidType == nil || *expense.PaidType != "Card" {
+		t.Errorf("paidType = %v", expense.PaidType)
+	}
+	if expense.Description == nil || *expense.Description != "Market" {
+		t.Errorf("description = %v, want it trimmed", expense.Description)
+	}
+}
+
+func TestCreateExpenseValidation(t *testing.T) {
+	srv, categoryID, paidID := setup(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"zero amount", expenseBody(categoryID, paidID, "2025-03", "0", ""), http.StatusOK},
+		{"day in month", `{"categoryId":"` + categoryID + `","paidId":"` + paidID + `","referenceMonth":"2025-03-17","spentAmount":1,"paymentDate":"2025-03-17"}`, http.StatusOK},
+		{"missing amount", `{"categoryId":"` + categoryID + `","paidId":"` + paidID + `","referenceMonth":"2025-03","paymentDate":"2025-03-05"}`, http.StatusBadRequest},
+		{"negative amount", expenseBody(categoryID, paidID, "2025-03", "-1.00", ""), http.StatusBadRequest},
+		{"too many decimals", expenseBody(categoryID, paidID, "2025-03", "1.001", ""), http.StatusBadRequest},
+		{"bad month", expenseBody(categoryID, paidID, "03/2025", "1.00", ""), http.StatusBadRequest},
+		{"bad payment date", `{"categoryId":"` + categoryID + `","paidId":"` + paidID + `","referenceMonth":"2025-03","spentAmount":1,"paymentDate":"05/03/2025"}`, http.StatusBadRequest},
+		{"unknown category", expenseBody("00000000-0000-0000-0000-000000000000", paidID, "2025-03", "1.00", ""), http.StatusBadRequest},
+		{"unknown paid type", expenseBody(categoryID, "00000000-0000-0000-0000-000000000000", "2025-03", "1.00", ""), http.StatusBadRequest},
+		{"no category nor rule", `{"paidId":"` + paidID + `","referenceMonth":"2025-03","spentAmount":1,"paymentDate":"2025-03-05"}`, http.StatusBadRequest},
+		{"no paid type nor rule", `{"categoryId":"` + categoryID + `","referenceMonth":"2025-03","spentAmount":1,"paymentDate":"2025-03-05"}`, http.StatusBadRequest},
+		{"unknown status", `{"categoryId":"` + categoryID + `","paidId":"` + paidID + `","statusId":"00000000-0000-0000-0000-000000000000","referenceMonth":"2025-03","spentAmount":1,"paymentDate":"2025-03-05"}`, http.StatusBadRequest},
+		{"bad currency", `{"categoryId":"` + categoryID + `","paidId":"` + paidID + `","referenceMonth":"2025-03","spentAmount":1,"paymentDate":"2025-03-05","currency":"dollars"}`, http.StatusBadRequest},
+		{"not JSON", `spent 10`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := srv.Do(t, user, http.MethodPost, "/api/v1/expenses", tt.body); w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+}
+
+func TestCreateExpenseFillsFieldsFromRules(t *testing.T) {
+	srv, categoryID, paidID := setup(t)
+	var status struct {
+		Data store.Status `json:"data"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodPost, "/api/v1/status", `{"statusName":"scheduled"}`), &status)
+	srv.Expect(t, http.StatusCreated, user, http.MethodPost, "/api/v1/rules", `{"name":"Market","match":{"description":"market"},"set":{"categoryId":"`+
+		categoryID+`","paidId":"`+paidID+`","statusId":"`+status.Data.ID+`"}}`)
+
+	id := srv.CreateExpense(t, user, `{"referenceMonth":"2025-03","spentAmount":"42.00","paymentDate":"2025-03-05","description":"Corner market"}`)
+	got := show(t, srv, id)
+	if got.CategoryName != "Groceries" || got.PaidType == nil || *got.PaidType != "Card" || got.StatusName == nil || *got.StatusName != "scheduled" {
+		t.Errorf("category %q, paid type %v, status %v; want all three from the rule", got.CategoryName, got.PaidType, got.StatusName)
+	}
+
+	// The fields sent are kept; the rule only fills in the missing ones
+	other := srv.CreatePaidType(t, user, "Cash")
+	id = srv.CreateExpense(t, user, `{"paidId":"`+other+`","referenceMonth":"2025-03","spentAmount":"8.00","paymentDate":"2025-03-06","description":"Market stall"}`)
+	got = show(t, srv, id)
+	if got.CategoryName != "Groceries" || *got.PaidType != "Cash" || got.StatusName == nil || *got.StatusName != "scheduled" {
+		t.Errorf("category %q, paid type %v, status %v; want the Cash paid type kept", got.CategoryName, *got.PaidType, got.StatusName)
+	}
+}
+
+func TestListExpenses(t *testing.T) {
+	srv, categoryID, paidID := setup(t)
+	march := srv.CreateExpense(t, user, expenseBody(categoryID, paidID, "2025-03", "10.00", "bread"))
+	april := srv.CreateExpense(t, user, expenseBody(categoryID, paidID, "2025-04", "20.00", "milk"))
+	otherCategory := srv.CreateCategory(t, user, "Transport", "100.00")
+	bus := srv.CreateExpense(t, user, expenseBody(otherCategory, paidID, "2025-04", "4.40", "bus"))
+
+	ids := func(query string) []string {
+		t.Helper()
+		var resp listResponse
+		handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/expenses"+query, ""), &resp)
+		list := []string{}
+		for _, expense := range resp.Expenses {
+			list = append(list, expense.ExpenseID)
+		}
+		slices.Sort(list)
+		return list
+	}
+	sorted := func(ids ...string) []string {
+		slices.Sort(ids)
+		return ids
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"?month=2025-03", sorted(march)},
+		{"?month=2025-04", sorted(april, bus)},
+		{"?from=2025-03&to=2025-04&categoryId=" + categoryID, sorted(march, april)},
+		{"?month=2025-04&q=milk", sorted(april)},
+		{"?from=2025-03&to=2025-04&minAmount=5.00&maxAmount=15.00", sorted(march)},
+		{"?month=2025-05", sorted()},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("GET /expenses%s = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"?month=March", "?month=2025-03&from=2025-01", "?from=2025-04&to=2025-03", "?minAmount=abc"} {
+		if w := srv.Do(t, user, http.MethodGet, "/api/v1/expenses"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("GET /expenses%s: status %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestListExpensesPages(t *testing.T) {
+	srv, categoryID, paidID := setup(t)
+	for _, amount := range []string{"1.00", "2.00", "3.00"} {
+		srv.CreateExpense(t, user, expenseBody(categoryID, paidID, "2025-03", amount, ""))
+	}
+
+	var first, second listResponse
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/expenses?month=2025-03&sort=spentAmount&limit=2", ""), &first)
+	if len(first.Expenses) != 2 || first.NextCursor == nil {
+		t.Fatalf("first page: %d expenses, cursor %v", len(first.Expenses), first.NextCursor)
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodGet, "/api/v1/expenses?month=2025-03&sort=spentAmount&limit=2&cursor="+url.QueryEscape(*first.NextCursor), ""), &second)
+	if len(second.Expenses) != 1 || second.NextCursor != nil {
+		t.Fatalf("second page: %d expenses, cursor %v", len(second.Expenses), second.NextCursor)
+	}
+	if got := second.Expenses[0].SpentAmount.String(); got != "3.00" {
+		t.Errorf("last expense spent %s, want 3.00", got)
+	}
+
+	if w := srv.Do(t, user, http.MethodGet, "/api/v1/expenses?month=2025-03&cursor=garbage", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bad cursor: status %d, want 400", w.Code)
+	}
+}
+
+func TestUpdateExpense(t *testing.T) {
+	srv, categoryID, paidID := setup(t)
+	id := srv.CreateExpense(t, user, expenseBody(categoryID, paidID, "2025-03", "10.00", "bread"))
+
+	var resp expenseResponse
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, user, http.MethodPut, "/api/v1/expenses/"+id, expenseBody(categoryID, paidID, "2025-04", "12.30", "bread and butter")), &resp)
+	if resp.Expense.SpentAmount.String() != "12.30" || *resp.Expense.ReferenceMonth != "2025-04-01" || *resp.Expense.Description != "bread and butter" {
+		t.Errorf("updated expense = %+v", resp.Expense)
+	}
+	if got := show(t, srv, id); got.SpentAmount.String() != "12.30" || *got.ReferenceMonth != "2025-04-01" {
+		t.Errorf("stored expense = %+v", got)
+	}
+
+	tests := []struct {
+		name   string
+		id     string
+		body   string
+		status int
+	}{
+		{"unknown expense", "00000000-0000-0000-0000-000000000000", expenseBody(categoryID, paidID, "2025-04", "1.00", ""), http.StatusNotFound},
+		{"no category", id, `{"paidId":"` + paidID + `","referenceMonth":"2025-04","spentAmount":1,"paymentDate":"2025-04-05"}`, http.StatusBadRequest},
+		{"missing field", id, `{"categoryId":"` + categoryID + `","referenceMonth":"2025-04","spentAmount":1,"paymentDate":"2025-04-05"}`, http.StatusBadRequest},
+		{"unknown category", id, expenseBody("00000000-0000-0000-0000-000000000000", paidID, "2025-04", "1.00", ""), http.StatusBadRequest},
+		{"negative amount", id, expenseBody(categoryID, paidID, "2025-04", "-1.00", ""), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := srv.Do(t, user, http.MethodPut, "/api/v1/expenses/"+tt.id, tt.body); w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+}
+
+func TestPatchExpense(t *testing.T) {
+	srv, categoryID, paidID := setup(t)
+	id := srv.CreateExpense(t, user, expenseBody(categoryID, paidID, "2025-03", "10.00", "bread"))
+
+	srv.Expect(t, http.StatusOK, user, http.MethodPatch, "/api/v1/expenses/"+id, `{"description":"rye bread"}`)
+	got := show(t, srv, id)
+	if *got.Description != "rye bread" || got.SpentAmount.String() != "10.00" || *got.ReferenceMonth != "2025-03-01" {
+		t.Errorf("after patching the description: %+v", got)
+	}
+
+	srv.Expect(t, http.StatusOK, user, http.MethodPatch, "/api/v1/expenses/"+id, `{"spentAmount":0,"referenceMonth":"2025-05"}`)
+	got = show(t, srv, id)
+	if got.SpentAmount.String() != "0.00" || *got.ReferenceMonth != "2025-05-01" || *got.Description != "rye bread" {
+		t.Errorf("after patching amount and month: %+v", got)
+	}
+
+	tests := []struct {
+		name   string
+		id     string
+		body   string
+		status int
+	}{
+		{"unknown expense", "00000000-0000-0000-0000-000000000000", `{"description":"x"}`, http.StatusNotFound},
+		{"negative amount", id, `{"spentAmount":"-1.00"}`, http.StatusBadRequest},
+		{"bad month", id, `{"referenceMonth":"May"}`, http.StatusBadRequest},
+		{"bad payment date", id, `{"paymentDate":"2025-5-1"}`, http.StatusBadRequest},
+		{"unknown category", id, `{"categoryId":"00000000-0000-0000-0000-000000000000"}`, http.StatusBadRequest},
+		{"unknown status", id, `{"statusId":"00000000-0000-0000-0000-000000000000"}`, http.StatusBadRequest},
+		{"unknown paid type", id, `{"paidId":"00000000-0000-0000-0000-000000000000"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := srv.Do(t, user, http.MethodPatch, "/api/v1/expenses/"+tt.id, tt.body); w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+}
+
+func TestDeleteExpense(t *testing.T) {
+	srv, categoryID, paidID := setup(t)
+	id := srv.CreateExpense(t, user, expenseBody(categoryID, paidID, "2025-03", "10.00", ""))
+	kept := srv.CreateExpense(t, user, expenseBody(categoryID, paidID, "2025-03", "20.00", ""))
+
+	srv.Expect(t, http.StatusOK, user, http.MethodDelete, "/api/v1/expenses/"+id, "")
+	srv.Expect(t, http.StatusNotFound, user, http.MethodGet, "/api/v1/expenses/"+id, "")
+	srv.Expect(t, http.StatusNotFound, user, http.MethodDelete, "/api/v1/expenses/"+id, "")
+	show(t, srv, kept)
+}
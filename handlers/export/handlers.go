@@ -0,0 +1,100 @@
+package export
+
+import (
+	"errors"
+	"go-sheet/month"
+	"go-sheet/sheet"
+	"go-sheet/workspace"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the export routes
+type Handler struct {
+	store sheet.Source
+}
+
+// NewHandler returns a Handler backed by the given store
+func NewHandler(s sheet.Source) *Handler {
+	return &Handler{store: s}
+}
+
+// Export streams the expenses and per-category summary of a month range.
+// Query parameters: format=csv|xlsx (default csv), table=expenses|summary
+// (CSV only, default expenses) and month=YYYY-MM or from=YYYY-MM&to=YYYY-MM
+// (default current month).
+func (h *Handler) Export(ctx *gin.Context) {
+	req, err := parseRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid export parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Content-Type", req.Format.ContentType())
+	ctx.Header("Content-Disposition", `attachment; filename="`+req.FileName()+`"`)
+
+	err = sheet.Write(ctx.Request.Context(), ctx.Writer, h.store, req)
+	if err != nil && !ctx.Writer.Written() {
+		ctx.Header("Content-Disposition", "")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error exporting data",
+			"error":   err.Error(),
+		})
+	} else if err != nil {
+		// O arquivo já começou a ser enviado; só resta interromper
+		log.Printf("export %s: %v", req.FileName(), err)
+		ctx.Abort()
+	}
+}
+
+func parseRequest(ctx *gin.Context) (sheet.Request, error) {
+	req := sheet.Request{WorkspaceID: workspace.ID(ctx), Format: sheet.CSV, Table: sheet.Expenses}
+
+	var err error
+	if value := ctx.Query("format"); value != "" {
+		if req.Format, err = sheet.ParseFormat(value); err != nil {
+			return req, err
+		}
+	}
+	if value := ctx.Query("table"); value != "" {
+		if req.Table, err = sheet.ParseTable(value); err != nil {
+			return req, err
+		}
+	}
+
+	monthParam, from, to := ctx.Query("month"), ctx.Query("from"), ctx.Query("to")
+	switch {
+	case monthParam != "" && (from != "" || to != ""):
+		return req, errors.New("use either month or from/to")
+	case monthParam != "":
+		if req.From, err = month.Parse(monthParam); err != nil {
+			return req, errors.New("month: expected YYYY-MM")
+		}
+		req.To = req.From
+		return req, nil
+	case from == "" && to == "":
+		req.From, req.To = month.Current(), month.Current()
+		return req, nil
+	case from == "" || to == "":
+		return req, errors.New("from and to must be given together")
+	}
+
+	if req.From, err = month.Parse(from); err != nil {
+		return req, errors.New("from: expected YYYY-MM")
+	}
+	if req.To, err = month.Parse(to); err != nil {
+		return req, errors.New("to: expected YYYY-MM")
+	}
+	if req.To.Before(req.From) {
+		return req, errors.New("to must not be before from")
+	}
+
+	return req, nil
+}
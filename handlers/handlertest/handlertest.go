@@ -0,0 +1,129 @@
+// Package handlertest serves the API routes against the memory store, so the
+// handler tests can exercise them over HTTP as the clients do.
+package handlertest
+
+import (
+	"encoding/json"
+	"go-sheet/auth"
+	"go-sheet/config"
+	routes "go-sheet/router"
+	"go-sheet/store/memory"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Users with a personal workspace created on their first request, whose id
+// is their own
+const (
+	UserA = "11111111-1111-1111-1111-111111111111"
+	UserB = "22222222-2222-2222-2222-222222222222"
+)
+
+var secret = []byte("handlertest-secret-handlertest-secret")
+
+// Server is the API routes on a fresh memory store
+type Server struct {
+	Store  *memory.Store
+	router *gin.Engine
+}
+
+// New returns a Server whose workspaces report amounts in BRL
+func New(t testing.TB) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{Store: memory.New(), router: gin.New()}
+	s.Store.SetCurrency("BRL")
+	routes.InitializeRoutes(s.router, routes.Dependencies{
+		Store:    s.Store,
+		Verifier: auth.NewHS256Verifier(secret, "authenticated"),
+		Config:   config.Config{Currency: "BRL"},
+	})
+	return s
+}
+
+// Token signs an access token for userID, as GoTrue would
+func Token(t testing.TB, userID string) string {
+	t.Helper()
+	token, err := auth.SignHS256(secret, auth.Claims{
+		Subject:   userID,
+		Email:     userID + "@example.com",
+		Audience:  []string{"authenticated"},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// Do serves one request made by userID; body, if not empty, is sent as JSON
+func (s *Server) Do(t testing.TB, userID, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+Token(t, userID))
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// Expect is Do that fails the test unless the response has the given status
+func (s *Server) Expect(t testing.TB, status int, userID, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := s.Do(t, userID, method, path, body)
+	if w.Code != status {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, path, w.Code, status, w.Body)
+	}
+	return w
+}
+
+// Decode unmarshals the response body into v
+func Decode(t testing.TB, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	body, _ := io.ReadAll(w.Body)
+	if err := json.Unmarshal(body, v); err != nil {
+		t.Fatalf("decoding %s: %v", body, err)
+	}
+}
+
+// CreatePaidType creates a paid type in userID's workspace and returns its id
+func (s *Server) CreatePaidType(t testing.TB, userID, name string) string {
+	t.Helper()
+	var resp struct {
+		Data []struct {
+			ID string `json:"uuid"`
+		} `json:"data"`
+	}
+	Decode(t, s.Expect(t, http.StatusCreated, userID, http.MethodPost, "/api/v1/paid-types", `{"type":"`+name+`","color":"#000000"}`), &resp)
+	if len(resp.Data) != 1 {
+		t.Fatalf("paid type not returned")
+	}
+	return resp.Data[0].ID
+}
+
+// CreateCategory creates a category in userID's workspace and returns its id
+func (s *Server) CreateCategory(t testing.TB, userID, name, plannedAmount string) string {
+	t.Helper()
+	var resp struct {
+		CategoryID string `json:"categoryId"`
+	}
+	Decode(t, s.Expect(t, http.StatusCreated, userID, http.MethodPost, "/api/v1/categories", `{"name":"`+name+`","plannedAmount":"`+plannedAmount+`","color":"#ffffff"}`), &resp)
+	return resp.CategoryID
+}
+
+// CreateExpense creates an expense from a JSON body and returns its id
+func (s *Server) CreateExpense(t testing.TB, userID, body string) string {
+	t.Helper()
+	var resp struct {
+		ExpenseID string `json:"expense_id"`
+	}
+	Decode(t, s.Expect(t, http.StatusOK, userID, http.MethodPost, "/api/v1/expenses", body), &resp)
+	return resp.ExpenseID
+}
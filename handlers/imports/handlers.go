@@ -0,0 +1,163 @@
+package imports
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-sheet/alerts"
+	"go-sheet/importer"
+	"go-sheet/store"
+	"go-sheet/workspace"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxFileSize is the largest statement accepted by Preview
+const MaxFileSize = 10 << 20
+
+// Handler serves the import routes
+type Handler struct {
+	service *importer.Service
+	alerts  *alerts.Service
+}
+
+// NewHandler returns a Handler backed by the given import service. Committed
+// expenses are checked against the budget alerts unless as is nil.
+func NewHandler(s *importer.Service, as *alerts.Service) *Handler {
+	return &Handler{service: s, alerts: as}
+}
+
+// Preview parses an uploaded statement without importing it. Multipart
+// fields: file (required), format=csv|ofx|qfx|camt053 (guessed from the file
+// when omitted) and mapping, the JSON column mapping of CSV files.
+func (h *Handler) Preview(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, MaxFileSize+1<<20)
+	header, err := ctx.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || err == nil && header.Size > MaxFileSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"status": "error", "error": fmt.Sprintf("File is larger than %d MB", MaxFileSize>>20)})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "A statement must be sent in the file field"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	format, err := parseFormat(ctx, header.Filename, file)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": err.Error()})
+		return
+	}
+
+	var mapping *importer.CSVMapping
+	if value := ctx.PostForm("mapping"); value != "" {
+		mapping = &importer.CSVMapping{}
+		if err := json.Unmarshal([]byte(value), mapping); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "mapping: " + err.Error()})
+			return
+		}
+	}
+
+	entries, err := importer.Parse(format, file, mapping)
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "Could not read the statement",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rows, err := h.service.Preview(ctx.Request.Context(), workspace.ID(ctx), entries)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview import", "details": err.Error()})
+		return
+	}
+
+	counts := map[string]int{importer.RowNew: 0, importer.RowDuplicate: 0, importer.RowSkipped: 0, importer.RowInvalid: 0}
+	for _, row := range rows {
+		counts[row.Status]++
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Statement parsed successfully",
+		"format":  format,
+		"counts":  counts,
+		"rows":    rows,
+	})
+}
+
+// parseFormat reads the format field or guesses it, rewinding file
+func parseFormat(ctx *gin.Context, name string, file io.ReadSeeker) (importer.Format, error) {
+	if value := ctx.PostForm("format"); value != "" {
+		return importer.ParseFormat(value)
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return importer.DetectFormat(name, head[:n])
+}
+
+// CommitRequest carries the previewed rows the user accepted
+type CommitRequest struct {
+	Rows []importer.CommitRow `json:"rows" binding:"required"`
+}
+
+// Commit imports the accepted rows in a single transaction. When any row is
+// rejected nothing is imported and the report tells which rows failed.
+func (h *Handler) Commit(ctx *gin.Context) {
+	var req CommitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": err.Error()})
+		return
+	}
+	if len(req.Rows) == 0 || len(req.Rows) > importer.MaxEntries {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": fmt.Sprintf("rows must hold between 1 and %d rows", importer.MaxEntries)})
+		return
+	}
+
+	report, err := h.service.Commit(ctx.Request.Context(), workspace.ID(ctx), req.Rows)
+	if errors.Is(err, importer.ErrRejected) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "Nothing was imported, fix the rows with errors",
+			"data":    report,
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import expenses", "details": err.Error()})
+		return
+	}
+
+	raised := []store.Alert{}
+	if h.alerts != nil {
+		ids := make([]string, 0, len(report.Rows))
+		for _, row := range report.Rows {
+			ids = append(ids, row.ExpenseID)
+		}
+		raised = h.alerts.Check(ctx.Request.Context(), workspace.ID(ctx), ids...)
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("%d expenses imported", report.Imported),
+		"data":    report,
+		"alerts":  raised,
+	})
+}
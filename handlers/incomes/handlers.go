@@ -0,0 +1,193 @@
+package incomes
+
+import (
+	"errors"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"go-sheet/workspace"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IncomeRequest is the body of CreateIncome and UpdateIncome. Dates are
+// YYYY-MM-DD; referenceMonth (YYYY-MM) defaults to the month of expectedDate
+// and currency to the base currency of the workspace.
+type IncomeRequest struct {
+	Source         string       `json:"source" binding:"required"`
+	Amount         money.Amount `json:"amount" binding:"required"`
+	ReferenceMonth string       `json:"referenceMonth"`
+	ExpectedDate   string       `json:"expectedDate" binding:"required"`
+	ReceivedDate   *string      `json:"receivedDate"`
+	Recurring      bool         `json:"recurring"`
+	Description    string       `json:"description"`
+	Currency       string       `json:"currency"`
+}
+
+// Handler serves the income routes
+type Handler struct {
+	store store.IncomeStore
+}
+
+// NewHandler returns a Handler backed by the given store. Amounts are
+// reported in the base currency of the workspace.
+func NewHandler(s store.IncomeStore) *Handler {
+	return &Handler{store: s}
+}
+
+// ListIncomes returns the incomes of ?month=YYYY-MM, or every income when
+// the parameter is missing
+func (h *Handler) ListIncomes(ctx *gin.Context) {
+	var m month.YearMonth
+	if value := ctx.Query("month"); value != "" {
+		parsed, err := month.Parse(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid month format. Use YYYY-MM"})
+			return
+		}
+		m = parsed
+	}
+
+	incomes, err := h.store.ListIncomes(ctx.Request.Context(), workspace.ID(ctx), m)
+	if err != nil {
+		respondWithError(ctx, err, "Error querying incomes")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Incomes retrieved successfully",
+		"currency": workspace.Currency(ctx),
+		"data":     incomes,
+	})
+}
+
+func (h *Handler) GetIncome(ctx *gin.Context) {
+	income, err := h.store.GetIncome(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"))
+	if err != nil {
+		respondWithError(ctx, err, "Error querying income")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Income retrieved successfully",
+		"currency": workspace.Currency(ctx),
+		"data":     income,
+	})
+}
+
+func (h *Handler) CreateIncome(ctx *gin.Context) {
+	input, ok := bindIncome(ctx)
+	if !ok {
+		return
+	}
+
+	income, err := h.store.CreateIncome(ctx.Request.Context(), workspace.ID(ctx), input)
+	if err != nil {
+		respondWithError(ctx, err, "Error creating income")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Income created successfully",
+		"data":    income,
+	})
+}
+
+func (h *Handler) UpdateIncome(ctx *gin.Context) {
+	input, ok := bindIncome(ctx)
+	if !ok {
+		return
+	}
+
+	income, err := h.store.UpdateIncome(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"), input)
+	if err != nil {
+		respondWithError(ctx, err, "Error updating income")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Income updated successfully",
+		"data":    income,
+	})
+}
+
+func (h *Handler) DeleteIncome(ctx *gin.Context) {
+	if err := h.store.DeleteIncome(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id")); err != nil {
+		respondWithError(ctx, err, "Error deleting income")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Income deleted successfully",
+	})
+}
+
+// bindIncome reads and validates an IncomeRequest, answering with 400 and
+// returning false when it is invalid
+func bindIncome(ctx *gin.Context) (store.IncomeInput, bool) {
+	var req IncomeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Error binding JSON", "error": err.Error()})
+		return store.IncomeInput{}, false
+	}
+
+	badRequest := func(message string) (store.IncomeInput, bool) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": message})
+		return store.IncomeInput{}, false
+	}
+
+	input := store.IncomeInput{
+		Source:      strings.TrimSpace(req.Source),
+		Amount:      req.Amount,
+		Recurring:   req.Recurring,
+		Description: req.Description,
+	}
+	if input.Source == "" {
+		return badRequest("source must not be empty")
+	}
+	if input.Amount <= 0 {
+		return badRequest("amount must be positive")
+	}
+
+	expected, err := time.Parse("2006-01-02", req.ExpectedDate)
+	if err != nil {
+		return badRequest("Invalid expectedDate format. Use YYYY-MM-DD")
+	}
+	input.ExpectedDate = expected
+	input.ReferenceMonth = month.Of(expected)
+	if req.ReferenceMonth != "" {
+		if input.ReferenceMonth, err = month.Parse(req.ReferenceMonth); err != nil {
+			return badRequest("Invalid referenceMonth format. Use YYYY-MM")
+		}
+	}
+	if req.ReceivedDate != nil {
+		received, err := time.Parse("2006-01-02", *req.ReceivedDate)
+		if err != nil {
+			return badRequest("Invalid receivedDate format. Use YYYY-MM-DD")
+		}
+		input.ReceivedDate = &received
+	}
+	if req.Currency != "" {
+		if input.Currency, err = money.ParseCurrency(req.Currency); err != nil {
+			return badRequest("currency: " + err.Error())
+		}
+	}
+
+	return input, true
+}
+
+func respondWithError(ctx *gin.Context, err error, message string) {
+	if errors.Is(err, store.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Income not found"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": message, "error": err.Error()})
+}
@@ -1,32 +1,27 @@
 package paid_type
 
 import (
-	"go-sheet/db"
+	"go-sheet/store"
+	"go-sheet/workspace"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-type PaidType struct {
-	ID        string `json:"uuid"`
-	Type      string `json:"type"`
-	PaidColor string `json:"color"`
-	CreatedAt string `json:"createdAt"`
+type PaidType = store.PaidType
+
+// Handler serves the paid type routes
+type Handler struct {
+	store store.PaidTypeStore
 }
 
-func ListPaidTypes(ctx *gin.Context) {
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Error connecting to database",
-			"error":   err.Error(),
-		})
-		return
-	}
-	defer conn.Close()
+// NewHandler returns a Handler backed by the given store
+func NewHandler(s store.PaidTypeStore) *Handler {
+	return &Handler{store: s}
+}
 
-	rows, err := conn.Query("SELECT * FROM paid_type")
+func (h *Handler) ListPaidTypes(ctx *gin.Context) {
+	paidTypes, err := h.store.ListPaidTypes(ctx.Request.Context(), workspace.ID(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -35,25 +30,7 @@ func ListPaidTypes(ctx *gin.Context) {
 		})
 		return
 	}
-	defer rows.Close()
 
-	var paidTypes []PaidType
-	for rows.Next() {
-		var paidType PaidType
-		err := rows.Scan(&paidType.ID, &paidType.Type, &paidType.PaidColor, &paidType.CreatedAt)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Error scanning database",
-				"error":   err.Error(),
-			})
-			return
-		}
-		paidTypes = append(paidTypes, paidType)
-	}
-	if len(paidTypes) == 0 {
-		paidTypes = []PaidType{}
-	}
 	ctx.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Paid types fetched successfully",
@@ -61,7 +38,7 @@ func ListPaidTypes(ctx *gin.Context) {
 	})
 }
 
-func CreatePaidType(ctx *gin.Context) {
+func (h *Handler) CreatePaidType(ctx *gin.Context) {
 	var paidType PaidType
 
 	if err := ctx.ShouldBindJSON(&paidType); err != nil {
@@ -73,19 +50,7 @@ func CreatePaidType(ctx *gin.Context) {
 		return
 	}
 
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Error connecting to database",
-			"error":   err.Error(),
-		})
-		return
-	}
-	defer conn.Close()
-
-	query := "INSERT INTO paid_type (paid_type, paid_color) VALUES ($1, $2) RETURNING paid_id"
-	err = conn.QueryRow(query, paidType.Type, paidType.PaidColor).Scan(&paidType.ID)
+	paidType, err := h.store.CreatePaidType(ctx.Request.Context(), workspace.ID(ctx), paidType)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -0,0 +1,150 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"go-sheet/money"
+	parser "go-sheet/rates"
+	"go-sheet/store"
+	"go-sheet/workspace"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxFileSize is the largest rate file accepted by ImportRates
+const MaxFileSize = 5 << 20
+
+// CurrencyRequest is the body of SetBaseCurrency
+type CurrencyRequest struct {
+	Currency string `json:"currency" binding:"required"`
+}
+
+// Handler serves the exchange rate and base currency routes
+type Handler struct {
+	store store.CurrencyStore
+}
+
+// NewHandler returns a Handler backed by the given store
+func NewHandler(s store.CurrencyStore) *Handler {
+	return &Handler{store: s}
+}
+
+// ListRates returns the exchange rates of the workspace, newest first.
+// Query parameters: from and to, currency codes.
+func (h *Handler) ListRates(ctx *gin.Context) {
+	var from, to money.Currency
+	for _, param := range []struct {
+		name  string
+		value *money.Currency
+	}{{"from", &from}, {"to", &to}} {
+		value := ctx.Query(param.name)
+		if value == "" {
+			continue
+		}
+		currency, err := money.ParseCurrency(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid " + param.name + " currency", "error": err.Error()})
+			return
+		}
+		*param.value = currency
+	}
+
+	rates, err := h.store.ListRates(ctx.Request.Context(), workspace.ID(ctx), from, to)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Error querying exchange rates", "error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Exchange rates retrieved successfully",
+		"currency": workspace.Currency(ctx),
+		"data":     rates,
+	})
+}
+
+// ImportRates adds or replaces the rates of the request body, a CSV or JSON
+// rate file. The format comes from ?format=csv|json or else from the
+// Content-Type.
+func (h *Handler) ImportRates(ctx *gin.Context) {
+	format, err := requestFormat(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": err.Error()})
+		return
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, MaxFileSize)
+	rates, err := parser.Parse(format, body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"status": "error", "error": fmt.Sprintf("File is larger than %d MB", MaxFileSize>>20)})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "Could not read the exchange rates",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	imported, err := h.store.ImportRates(ctx.Request.Context(), workspace.ID(ctx), rates)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Error saving exchange rates", "error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Exchange rates imported successfully",
+		"data":    gin.H{"imported": imported},
+	})
+}
+
+func requestFormat(ctx *gin.Context) (parser.Format, error) {
+	if value := ctx.Query("format"); value != "" {
+		return parser.ParseFormat(value)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+	switch mediaType {
+	case "text/csv", "application/csv", "text/plain":
+		return parser.CSV, nil
+	case "application/json":
+		return parser.JSON, nil
+	}
+	return "", errors.New("cannot tell the file format, send Content-Type text/csv or application/json, or pass format=csv|json")
+}
+
+// SetBaseCurrency changes the currency the workspace reports amounts in.
+// Amounts recorded without a currency keep the previous one, so every total
+// is converted with the imported rates from then on.
+func (h *Handler) SetBaseCurrency(ctx *gin.Context) {
+	var req CurrencyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Error binding JSON", "error": err.Error()})
+		return
+	}
+	currency, err := money.ParseCurrency(req.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid currency", "error": err.Error()})
+		return
+	}
+
+	err = h.store.SetBaseCurrency(ctx.Request.Context(), workspace.ID(ctx), currency)
+	if errors.Is(err, store.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Workspace not found"})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Error saving base currency", "error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Base currency saved successfully",
+		"data":    gin.H{"workspaceId": workspace.ID(ctx), "baseCurrency": currency},
+	})
+}
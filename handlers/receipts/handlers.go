@@ -0,0 +1,131 @@
+package receipts
+
+import (
+	"errors"
+	"fmt"
+	"go-sheet/blob"
+	service "go-sheet/receipts"
+	"go-sheet/store"
+	"go-sheet/workspace"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the receipt routes
+type Handler struct {
+	service *service.Service
+}
+
+// NewHandler returns a Handler backed by the given receipt service
+func NewHandler(s *service.Service) *Handler {
+	return &Handler{service: s}
+}
+
+// UploadReceipt attaches the PDF, JPEG or PNG sent in the multipart field
+// "file" to an expense, replacing its previous receipt
+func (h *Handler) UploadReceipt(ctx *gin.Context) {
+	limit := h.service.MaxBytes()
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit+1<<20)
+	header, err := ctx.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || err == nil && header.Size > limit {
+		respondTooLarge(ctx, limit)
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "A receipt must be sent in the file field"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	expense, err := h.service.Attach(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"), file, header.Size)
+	if errors.Is(err, service.ErrTooLarge) {
+		respondTooLarge(ctx, limit)
+		return
+	} else if err != nil {
+		respondWithError(ctx, err, "Failed to store receipt")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Receipt attached successfully",
+		"expense": expense,
+	})
+}
+
+// DownloadReceipt streams the receipt of an expense to a signed-in member
+func (h *Handler) DownloadReceipt(ctx *gin.Context) {
+	reader, info, err := h.service.Open(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"))
+	if err != nil {
+		respondWithError(ctx, err, "Failed to read receipt")
+		return
+	}
+	serve(ctx, reader, info)
+}
+
+// DownloadLink streams the receipt a signed link points to. It is served
+// without authentication: the signature is the credential.
+func (h *Handler) DownloadLink(ctx *gin.Context) {
+	reader, info, err := h.service.OpenLink(ctx.Request.Context(), ctx.Param(workspace.Param), ctx.Param("id"),
+		ctx.Query("expires"), ctx.Query("signature"))
+	if err != nil {
+		respondWithError(ctx, err, "Failed to read receipt")
+		return
+	}
+	serve(ctx, reader, info)
+}
+
+// DeleteReceipt removes the receipt of an expense
+func (h *Handler) DeleteReceipt(ctx *gin.Context) {
+	if err := h.service.Detach(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id")); err != nil {
+		respondWithError(ctx, err, "Failed to delete receipt")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Receipt deleted successfully",
+	})
+}
+
+func serve(ctx *gin.Context, reader io.ReadCloser, info blob.Info) {
+	defer reader.Close()
+
+	// Os tipos vêm da lista permitida, mas o navegador não deve adivinhar outro
+	ctx.DataFromReader(http.StatusOK, info.Size, info.ContentType, reader, map[string]string{
+		"Content-Disposition":    fmt.Sprintf(`inline; filename="%s"`, service.FileName(ctx.Param("id"), info)),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=300",
+	})
+	if err := ctx.Errors.Last(); err != nil {
+		log.Printf("receipt %s: %v", ctx.Param("id"), err)
+	}
+}
+
+func respondTooLarge(ctx *gin.Context, limit int64) {
+	ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"status": "error", "error": fmt.Sprintf("Receipt must be at most %d bytes", limit)})
+}
+
+func respondWithError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "error": "Expense not found"})
+	case errors.Is(err, service.ErrNoReceipt):
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "error": "Expense has no receipt"})
+	case errors.Is(err, service.ErrUnsupportedType):
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"status": "error", "error": err.Error()})
+	case errors.Is(err, service.ErrInvalidLink):
+		ctx.JSON(http.StatusForbidden, gin.H{"status": "error", "error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": message, "details": err.Error()})
+	}
+}
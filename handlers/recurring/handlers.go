@@ -0,0 +1,496 @@
+package recurring
+
+import (
+	"errors"
+	"go-sheet/money"
+	engine "go-sheet/recurring"
+	"go-sheet/store"
+	"go-sheet/workspace"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUpcomingDays bounds the range of ListUpcoming
+const maxUpcomingDays = 366
+
+// RecurringRequest is the body of CreateRecurring and UpdateRecurring. Dates
+// are YYYY-MM-DD; endDate and occurrences both end the schedule, whichever
+// comes first. currency defaults to the base currency of the workspace.
+type RecurringRequest struct {
+	CategoryID  string          `json:"categoryId" binding:"required"`
+	PaidID      *string         `json:"paidId"`
+	Amount      money.Amount    `json:"amount"`
+	Currency    string          `json:"currency"`
+	Description string          `json:"description"`
+	Frequency   store.Frequency `json:"frequency" binding:"required"`
+	Every       int             `json:"every"` // default 1
+	DayOfMonth  *int            `json:"dayOfMonth"`
+	StartDate   string          `json:"startDate" binding:"required"`
+	EndDate     *string         `json:"endDate"`
+	Occurrences *int            `json:"occurrences"`
+}
+
+// OccurrenceRequest skips one occurrence or overrides some of its values
+type OccurrenceRequest struct {
+	Skip        bool          `json:"skip"`
+	Amount      *money.Amount `json:"amount"`
+	Description *string       `json:"description"`
+	PaidID      *string       `json:"paidId"`
+}
+
+// RecurringExpense is the JSON form of store.RecurringExpense
+type RecurringExpense struct {
+	ID                  string          `json:"recurringId"`
+	CategoryID          string          `json:"categoryId"`
+	CategoryName        string          `json:"categoryName"`
+	PaidID              *string         `json:"paidId"`
+	Amount              money.Amount    `json:"amount"`
+	Currency            money.Currency  `json:"currency"`
+	Description         string          `json:"description"`
+	Frequency           store.Frequency `json:"frequency"`
+	Every               int             `json:"every"`
+	DayOfMonth          *int            `json:"dayOfMonth"`
+	StartDate           string          `json:"startDate"`
+	EndDate             *string         `json:"endDate"`
+	Occurrences         *int            `json:"occurrences"`
+	MaterializedThrough *string         `json:"materializedThrough"`
+	Exceptions          []Exception     `json:"exceptions"`
+	CreatedAt           time.Time       `json:"createdAt"`
+}
+
+// Exception is the JSON form of store.OccurrenceException
+type Exception struct {
+	Date        string        `json:"date"`
+	Skip        bool          `json:"skip"`
+	Amount      *money.Amount `json:"amount"`
+	Description *string       `json:"description"`
+	PaidID      *string       `json:"paidId"`
+}
+
+// Occurrence is one date of a schedule, exceptions applied. State is
+// scheduled, overridden, skipped or materialized (already an expense).
+type Occurrence struct {
+	RecurringID  string         `json:"recurringId"`
+	CategoryID   string         `json:"categoryId"`
+	CategoryName string         `json:"categoryName"`
+	Date         string         `json:"date"`
+	Amount       money.Amount   `json:"amount"`
+	Currency     money.Currency `json:"currency"`
+	Description  string         `json:"description"`
+	PaidID       *string        `json:"paidId"`
+	State        string         `json:"state"`
+}
+
+// Handler serves the recurring expense routes
+type Handler struct {
+	store  store.RecurringStore
+	runner *engine.Runner
+}
+
+// NewHandler returns a Handler backed by the given store. Saving a recurring
+// expense materialises its due occurrences through runner right away.
+func NewHandler(s store.RecurringStore, runner *engine.Runner) *Handler {
+	return &Handler{store: s, runner: runner}
+}
+
+func (h *Handler) ListRecurring(ctx *gin.Context) {
+	list, err := h.store.ListRecurring(ctx.Request.Context(), workspace.ID(ctx))
+	if err != nil {
+		respondWithError(ctx, err, "Error querying recurring expenses")
+		return
+	}
+
+	views := make([]RecurringExpense, len(list))
+	for i, r := range list {
+		views[i] = view(r, workspace.Currency(ctx))
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Recurring expenses retrieved successfully",
+		"data":    views,
+	})
+}
+
+func (h *Handler) GetRecurring(ctx *gin.Context) {
+	r, err := h.store.GetRecurring(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"))
+	if err != nil {
+		respondWithError(ctx, err, "Error querying recurring expense")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Recurring expense retrieved successfully",
+		"data":    view(r, workspace.Currency(ctx)),
+	})
+}
+
+func (h *Handler) CreateRecurring(ctx *gin.Context) {
+	r, ok := bindRecurring(ctx)
+	if !ok {
+		return
+	}
+
+	r, err := h.store.CreateRecurring(ctx.Request.Context(), workspace.ID(ctx), r)
+	if err != nil {
+		respondWithError(ctx, err, "Error creating recurring expense")
+		return
+	}
+	r, created := h.materialize(ctx, r)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status":   "success",
+		"message":  "Recurring expense created successfully",
+		"data":     view(r, workspace.Currency(ctx)),
+		"expenses": created,
+	})
+}
+
+// UpdateRecurring replaces the schedule. Occurrences already materialised
+// stay as they are; only later dates follow the new values.
+func (h *Handler) UpdateRecurring(ctx *gin.Context) {
+	r, ok := bindRecurring(ctx)
+	if !ok {
+		return
+	}
+
+	r, err := h.store.UpdateRecurring(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"), r)
+	if err != nil {
+		respondWithError(ctx, err, "Error updating recurring expense")
+		return
+	}
+	r, created := h.materialize(ctx, r)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "Recurring expense updated successfully",
+		"data":     view(r, workspace.Currency(ctx)),
+		"expenses": created,
+	})
+}
+
+// DeleteRecurring stops the schedule; expenses already created are kept
+func (h *Handler) DeleteRecurring(ctx *gin.Context) {
+	if err := h.store.DeleteRecurring(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id")); err != nil {
+		respondWithError(ctx, err, "Error deleting recurring expense")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Recurring expense deleted successfully",
+	})
+}
+
+// ListUpcoming returns the occurrences of every recurring expense between
+// from and to (YYYY-MM-DD, inclusive). from defaults to today and to to 90
+// days later; the range may span at most a year.
+func (h *Handler) ListUpcoming(ctx *gin.Context) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := ctx.Query("from"); value != "" {
+		date, ok := parseDate(ctx, value)
+		if !ok {
+			return
+		}
+		from = date
+	}
+	to := from.AddDate(0, 0, 90)
+	if value := ctx.Query("to"); value != "" {
+		date, ok := parseDate(ctx, value)
+		if !ok {
+			return
+		}
+		to = date
+	}
+	if to.Before(from) || to.Sub(from) > maxUpcomingDays*24*time.Hour {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "to must be after from and at most a year later"})
+		return
+	}
+
+	list, err := h.store.ListRecurring(ctx.Request.Context(), workspace.ID(ctx))
+	if err != nil {
+		respondWithError(ctx, err, "Error querying recurring expenses")
+		return
+	}
+
+	occurrences := []Occurrence{}
+	for _, r := range list {
+		for _, date := range engine.Dates(r, from, to) {
+			occurrences = append(occurrences, occurrenceView(r, date, workspace.Currency(ctx)))
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].Date < occurrences[j].Date
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Upcoming occurrences retrieved successfully",
+		"data":    occurrences,
+	})
+}
+
+// SetOccurrence skips or overrides a single occurrence that was not
+// materialised yet
+func (h *Handler) SetOccurrence(ctx *gin.Context) {
+	var req OccurrenceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Error binding JSON", "error": err.Error()})
+		return
+	}
+	if !req.Skip && req.Amount == nil && req.Description == nil && req.PaidID == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Set skip or at least one of amount, description and paidId"})
+		return
+	}
+	if req.Amount != nil && *req.Amount < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "amount must not be negative"})
+		return
+	}
+
+	r, date, ok := h.occurrence(ctx)
+	if !ok {
+		return
+	}
+
+	exception := store.OccurrenceException{Date: date, Skip: req.Skip, Amount: req.Amount, Description: req.Description, PaidID: req.PaidID}
+	if err := h.store.SetOccurrenceException(ctx.Request.Context(), workspace.ID(ctx), r.ID, exception); err != nil {
+		respondWithError(ctx, err, "Error saving occurrence")
+		return
+	}
+	r.Exceptions = append(withoutException(r, date), exception)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Occurrence saved successfully",
+		"data":    occurrenceView(r, date, workspace.Currency(ctx)),
+	})
+}
+
+// ResetOccurrence removes the exception of a single occurrence
+func (h *Handler) ResetOccurrence(ctx *gin.Context) {
+	r, date, ok := h.occurrence(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteOccurrenceException(ctx.Request.Context(), workspace.ID(ctx), r.ID, date); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Occurrence has no exception"})
+			return
+		}
+		respondWithError(ctx, err, "Error resetting occurrence")
+		return
+	}
+	r.Exceptions = withoutException(r, date)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Occurrence reset successfully",
+		"data":    occurrenceView(r, date, workspace.Currency(ctx)),
+	})
+}
+
+// occurrence loads the recurring expense and date of the route, answering
+// with an error and returning false unless the date is a pending occurrence
+func (h *Handler) occurrence(ctx *gin.Context) (store.RecurringExpense, time.Time, bool) {
+	date, ok := parseDate(ctx, ctx.Param("date"))
+	if !ok {
+		return store.RecurringExpense{}, time.Time{}, false
+	}
+
+	r, err := h.store.GetRecurring(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"))
+	if err != nil {
+		respondWithError(ctx, err, "Error querying recurring expense")
+		return store.RecurringExpense{}, time.Time{}, false
+	}
+	if !engine.IsOccurrence(r, date) {
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "The schedule has no occurrence on " + date.Format(time.DateOnly)})
+		return store.RecurringExpense{}, time.Time{}, false
+	}
+	if engine.Materialized(r, date) {
+		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "Occurrence was already created as an expense; edit the expense instead"})
+		return store.RecurringExpense{}, time.Time{}, false
+	}
+
+	return r, date, true
+}
+
+// materialize creates the due occurrences of r and returns r reloaded along
+// with the new expense ids. The recurring expense is saved by then, so a
+// failure is logged rather than answered.
+func (h *Handler) materialize(ctx *gin.Context, r store.RecurringExpense) (store.RecurringExpense, []string) {
+	if h.runner == nil {
+		return r, []string{}
+	}
+
+	ids, err := h.runner.Materialize(ctx.Request.Context(), r, engine.Horizon(time.Now()))
+	if err != nil {
+		log.Printf("recurring: %v", err)
+		return r, []string{}
+	}
+	if reloaded, err := h.store.GetRecurring(ctx.Request.Context(), workspace.ID(ctx), r.ID); err == nil {
+		r = reloaded
+	}
+	return r, ids
+}
+
+// bindRecurring reads and validates a RecurringRequest, answering with 400
+// and returning false when it is invalid
+func bindRecurring(ctx *gin.Context) (store.RecurringExpense, bool) {
+	var req RecurringRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Error binding JSON", "error": err.Error()})
+		return store.RecurringExpense{}, false
+	}
+
+	r := store.RecurringExpense{
+		CategoryID:  req.CategoryID,
+		PaidID:      req.PaidID,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Frequency:   req.Frequency,
+		Every:       req.Every,
+		DayOfMonth:  req.DayOfMonth,
+		Occurrences: req.Occurrences,
+	}
+	if r.Every == 0 {
+		r.Every = 1
+	}
+	if req.Currency != "" {
+		currency, err := money.ParseCurrency(req.Currency)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid currency", "error": err.Error()})
+			return store.RecurringExpense{}, false
+		}
+		r.Currency = currency
+	}
+	start, ok := parseDate(ctx, req.StartDate)
+	if !ok {
+		return store.RecurringExpense{}, false
+	}
+	r.StartDate = start
+	if req.EndDate != nil {
+		end, ok := parseDate(ctx, *req.EndDate)
+		if !ok {
+			return store.RecurringExpense{}, false
+		}
+		r.EndDate = &end
+	}
+
+	if err := engine.Validate(r); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid recurring expense", "error": err.Error()})
+		return store.RecurringExpense{}, false
+	}
+	return r, true
+}
+
+func parseDate(ctx *gin.Context, value string) (time.Time, bool) {
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid date format. Use YYYY-MM-DD"})
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// view renders r; amounts without a currency are in base
+func view(r store.RecurringExpense, base money.Currency) RecurringExpense {
+	v := RecurringExpense{
+		ID:           r.ID,
+		CategoryID:   r.CategoryID,
+		CategoryName: r.CategoryName,
+		PaidID:       r.PaidID,
+		Amount:       r.Amount,
+		Currency:     orBase(r.Currency, base),
+		Description:  r.Description,
+		Frequency:    r.Frequency,
+		Every:        r.Every,
+		DayOfMonth:   r.DayOfMonth,
+		StartDate:    r.StartDate.Format(time.DateOnly),
+		EndDate:      formatDate(r.EndDate),
+		Occurrences:  r.Occurrences,
+		Exceptions:   make([]Exception, len(r.Exceptions)),
+		CreatedAt:    r.CreatedAt,
+
+		MaterializedThrough: formatDate(r.MaterializedThrough),
+	}
+	for i, e := range r.Exceptions {
+		v.Exceptions[i] = Exception{
+			Date:        e.Date.Format(time.DateOnly),
+			Skip:        e.Skip,
+			Amount:      e.Amount,
+			Description: e.Description,
+			PaidID:      e.PaidID,
+		}
+	}
+	return v
+}
+
+func occurrenceView(r store.RecurringExpense, date time.Time, base money.Currency) Occurrence {
+	occurrence, skipped := engine.Resolve(r, date)
+	state := "scheduled"
+	if _, ok := engine.Exception(r, date); ok {
+		state = "overridden"
+	}
+	if skipped {
+		state = "skipped"
+	} else if engine.Materialized(r, date) {
+		state = "materialized"
+	}
+
+	return Occurrence{
+		RecurringID:  r.ID,
+		CategoryID:   r.CategoryID,
+		CategoryName: r.CategoryName,
+		Date:         date.Format(time.DateOnly),
+		Amount:       occurrence.Amount,
+		Currency:     orBase(occurrence.Currency, base),
+		Description:  occurrence.Description,
+		PaidID:       occurrence.PaidID,
+		State:        state,
+	}
+}
+
+// orBase returns the currency of an amount, base when it has none
+func orBase(currency, base money.Currency) money.Currency {
+	if currency == "" {
+		return base
+	}
+	return currency
+}
+
+// withoutException returns the exceptions of r except the one of date
+func withoutException(r store.RecurringExpense, date time.Time) []store.OccurrenceException {
+	return slices.DeleteFunc(slices.Clone(r.Exceptions), func(e store.OccurrenceException) bool {
+		return e.Date.Equal(date)
+	})
+}
+
+func formatDate(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.DateOnly)
+	return &s
+}
+
+func respondWithError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Recurring expense not found"})
+	case errors.Is(err, store.ErrUnknownCategory):
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Category not found"})
+	case errors.Is(err, store.ErrCategoryArchived):
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Category is archived"})
+	case errors.Is(err, store.ErrUnknownPaidType):
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Paid type not found"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": message, "error": err.Error()})
+	}
+}
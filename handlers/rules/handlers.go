@@ -0,0 +1,189 @@
+package rules
+
+import (
+	"errors"
+	"go-sheet/money"
+	engine "go-sheet/rules"
+	"go-sheet/store"
+	"go-sheet/workspace"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RuleRequest is the body of CreateRule and UpdateRule
+type RuleRequest struct {
+	Name     string            `json:"name" binding:"required"`
+	Priority int               `json:"priority"`
+	Enabled  *bool             `json:"enabled"` // default true
+	Match    store.RuleMatch   `json:"match"`
+	Set      store.RuleActions `json:"set"`
+}
+
+// DryRunRequest is a sample transaction; date is YYYY-MM-DD and optional
+type DryRunRequest struct {
+	Description string       `json:"description"`
+	Amount      money.Amount `json:"amount"`
+	PaidID      string       `json:"paidId"`
+	Date        string       `json:"date"`
+}
+
+// Handler serves the categorisation rule routes
+type Handler struct {
+	store store.RuleStore
+}
+
+// NewHandler returns a Handler backed by the given store
+func NewHandler(s store.RuleStore) *Handler {
+	return &Handler{store: s}
+}
+
+// ListRules returns the rules in evaluation order
+func (h *Handler) ListRules(ctx *gin.Context) {
+	list, err := h.store.ListRules(ctx.Request.Context(), workspace.ID(ctx))
+	if err != nil {
+		respondWithError(ctx, err, "Error querying rules")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Rules retrieved successfully",
+		"data":    list,
+	})
+}
+
+func (h *Handler) GetRule(ctx *gin.Context) {
+	rule, err := h.store.GetRule(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"))
+	if err != nil {
+		respondWithError(ctx, err, "Error querying rule")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Rule retrieved successfully",
+		"data":    rule,
+	})
+}
+
+func (h *Handler) CreateRule(ctx *gin.Context) {
+	rule, ok := bindRule(ctx)
+	if !ok {
+		return
+	}
+
+	rule, err := h.store.CreateRule(ctx.Request.Context(), workspace.ID(ctx), rule)
+	if err != nil {
+		respondWithError(ctx, err, "Error creating rule")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Rule created successfully",
+		"data":    rule,
+	})
+}
+
+func (h *Handler) UpdateRule(ctx *gin.Context) {
+	rule, ok := bindRule(ctx)
+	if !ok {
+		return
+	}
+
+	rule, err := h.store.UpdateRule(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id"), rule)
+	if err != nil {
+		respondWithError(ctx, err, "Error updating rule")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Rule updated successfully",
+		"data":    rule,
+	})
+}
+
+func (h *Handler) DeleteRule(ctx *gin.Context) {
+	if err := h.store.DeleteRule(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("id")); err != nil {
+		respondWithError(ctx, err, "Error deleting rule")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Rule deleted successfully",
+	})
+}
+
+// DryRun shows which rules would fire for a sample transaction, and what
+// they would set, without changing anything
+func (h *Handler) DryRun(ctx *gin.Context) {
+	var req DryRunRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Error binding JSON", "error": err.Error()})
+		return
+	}
+
+	transaction := engine.Transaction{Description: req.Description, Amount: req.Amount, PaidID: req.PaidID}
+	if req.Date != "" {
+		date, err := time.Parse(time.DateOnly, req.Date)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		transaction.Date = date
+	}
+
+	rules, err := engine.Load(ctx.Request.Context(), h.store, workspace.ID(ctx))
+	if err != nil {
+		respondWithError(ctx, err, "Error loading rules")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Rules evaluated successfully",
+		"data":    rules.Apply(transaction),
+	})
+}
+
+// bindRule reads and validates a RuleRequest, answering with 400 and
+// returning false when it is invalid
+func bindRule(ctx *gin.Context) (store.Rule, bool) {
+	var req RuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Error binding JSON", "error": err.Error()})
+		return store.Rule{}, false
+	}
+
+	rule := store.Rule{Name: req.Name, Priority: req.Priority, Enabled: true, Match: req.Match, Set: req.Set}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if err := engine.Validate(rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid rule", "error": err.Error()})
+		return store.Rule{}, false
+	}
+
+	return rule, true
+}
+
+func respondWithError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Rule not found"})
+	case errors.Is(err, store.ErrUnknownCategory):
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Category not found"})
+	case errors.Is(err, store.ErrCategoryArchived):
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Category is archived"})
+	case errors.Is(err, store.ErrUnknownPaidType):
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Paid type not found"})
+	case errors.Is(err, store.ErrUnknownStatus):
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Status not found"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": message, "error": err.Error()})
+	}
+}
@@ -1,55 +1,37 @@
 package status
 
 import (
-	"database/sql"
-	"go-sheet/db"
+	"errors"
+	"go-sheet/store"
+	"go-sheet/workspace"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-type Status struct {
-	ID         string `json:"uuid"`
-	StatusName string `json:"statusName"`
+type Status = store.Status
+
+// Handler serves the status routes
+type Handler struct {
+	store store.StatusStore
 }
 
-func ListStatus(ctx *gin.Context) {
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Error connecting to database",
-			"error":   err.Error(),
-		})
-	}
-	defer conn.Close()
+// NewHandler returns a Handler backed by the given store
+func NewHandler(s store.StatusStore) *Handler {
+	return &Handler{store: s}
+}
 
-	sqlQuery := `SELECT * FROM status`
-	rows, err := conn.Query(sqlQuery)
+func (h *Handler) ListStatus(ctx *gin.Context) {
+	statuses, err := h.store.ListStatuses(ctx.Request.Context(), workspace.ID(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Error querying database",
 			"error":   err.Error(),
 		})
+		return
 	}
 
-	var statuses []Status
-	for rows.Next() {
-		var status Status
-		err = rows.Scan(&status.ID, &status.StatusName)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Error scanning database rows",
-				"error":   err.Error(),
-			})
-		}
-		statuses = append(statuses, status)
-	}
-
-	rows.Close()
-
 	ctx.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Status list retrieved successfully",
@@ -57,20 +39,9 @@ func ListStatus(ctx *gin.Context) {
 	})
 }
 
-func CreateStatus(ctx *gin.Context) {
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Error connecting to database",
-			"error":   err.Error(),
-		})
-		return
-	}
-	defer conn.Close()
-
+func (h *Handler) CreateStatus(ctx *gin.Context) {
 	var status Status
-	err = ctx.ShouldBindJSON(&status)
+	err := ctx.ShouldBindJSON(&status)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
@@ -80,29 +51,14 @@ func CreateStatus(ctx *gin.Context) {
 		return
 	}
 
-	// Check if status with the same name already exists
-	var existingID string
-	checkQuery := `SELECT status_id FROM status WHERE status_name = $1`
-	err = conn.QueryRow(checkQuery, status.StatusName).Scan(&existingID)
-	if err == nil {
+	status, err = h.store.CreateStatus(ctx.Request.Context(), workspace.ID(ctx), status.StatusName)
+	if errors.Is(err, store.ErrConflict) {
 		ctx.JSON(http.StatusConflict, gin.H{
 			"status":  "error",
 			"message": "Status with this name already exists",
 		})
 		return
-	} else if err != sql.ErrNoRows {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Error checking existing status",
-			"error":   err.Error(),
-		})
-		return
-	}
-
-	// If no existing status found, proceed with insertion
-	sqlQuery := `INSERT INTO status (status_name) VALUES ($1) RETURNING status_id`
-	err = conn.QueryRow(sqlQuery, status.StatusName).Scan(&status.ID)
-	if err != nil {
+	} else if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Error inserting data into database",
@@ -118,22 +74,23 @@ func CreateStatus(ctx *gin.Context) {
 	})
 }
 
-func DeleteStatus(ctx *gin.Context) {
-	conn, err := db.OpenConnection()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Error connecting to database",
-			"error":   err.Error(),
-		})
-	}
-	defer conn.Close()
-
+func (h *Handler) DeleteStatus(ctx *gin.Context) {
 	statusID := ctx.Param("id")
 
-	sqlQuery := `DELETE FROM status WHERE status_id = $1`
-	_, err = conn.Exec(sqlQuery, statusID)
-	if err != nil {
+	err := h.store.DeleteStatus(ctx.Request.Context(), workspace.ID(ctx), statusID)
+	if errors.Is(err, store.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Status not found",
+		})
+		return
+	} else if errors.Is(err, store.ErrSystemStatus) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Default statuses cannot be deleted",
+		})
+		return
+	} else if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Error deleting status",
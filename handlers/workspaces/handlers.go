@@ -0,0 +1,254 @@
+package workspaces
+
+import (
+	"errors"
+	"go-sheet/auth"
+	"go-sheet/store"
+	"go-sheet/workspace"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Workspace is the body of POST /workspaces
+type Workspace struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MemberRole is the body of PUT /workspaces/:workspace/members/:userId
+type MemberRole struct {
+	Role store.Role `json:"role" binding:"required"`
+}
+
+// Invite is the body of POST /workspaces/:workspace/invites
+type Invite struct {
+	Email string     `json:"email" binding:"required,email"`
+	Role  store.Role `json:"role" binding:"required"`
+}
+
+// Handler serves the workspace, member and invite routes
+type Handler struct {
+	store store.WorkspaceStore
+}
+
+// NewHandler returns a Handler backed by the given store
+func NewHandler(s store.WorkspaceStore) *Handler {
+	return &Handler{store: s}
+}
+
+func (h *Handler) ListWorkspaces(ctx *gin.Context) {
+	identity, _ := auth.IdentityFrom(ctx)
+
+	err := h.store.EnsurePersonalWorkspace(ctx.Request.Context(), identity.UserID, identity.Email)
+	if err != nil {
+		respondWithError(ctx, err, "Error creating personal workspace")
+		return
+	}
+
+	workspaces, err := h.store.ListWorkspaces(ctx.Request.Context(), identity.UserID)
+	if err != nil {
+		respondWithError(ctx, err, "Error querying database")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Workspaces retrieved successfully",
+		"data":    workspaces,
+	})
+}
+
+func (h *Handler) CreateWorkspace(ctx *gin.Context) {
+	var body Workspace
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	identity, _ := auth.IdentityFrom(ctx)
+	created, err := h.store.CreateWorkspace(ctx.Request.Context(), identity.UserID, identity.Email, body.Name)
+	if err != nil {
+		respondWithError(ctx, err, "Error creating workspace")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Workspace created successfully",
+		"data":    created,
+	})
+}
+
+func (h *Handler) ListMembers(ctx *gin.Context) {
+	members, err := h.store.ListMembers(ctx.Request.Context(), workspace.ID(ctx))
+	if err != nil {
+		respondWithError(ctx, err, "Error querying database")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Members retrieved successfully",
+		"data":    members,
+	})
+}
+
+func (h *Handler) UpdateMember(ctx *gin.Context) {
+	var body MemberRole
+	if err := ctx.ShouldBindJSON(&body); err != nil || !body.Role.Valid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   "role must be one of owner, editor or viewer",
+		})
+		return
+	}
+
+	err := h.store.SetMemberRole(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("userId"), body.Role)
+	if err != nil {
+		respondWithError(ctx, err, "Error updating member")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Member updated successfully",
+	})
+}
+
+// RemoveMember lets owners remove anyone and every member leave on their own
+func (h *Handler) RemoveMember(ctx *gin.Context) {
+	userID := ctx.Param("userId")
+	if userID != auth.UserID(ctx) && !workspace.Role(ctx).Allows(store.RoleOwner) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "This action requires the owner role",
+		})
+		return
+	}
+
+	if err := h.store.RemoveMember(ctx.Request.Context(), workspace.ID(ctx), userID); err != nil {
+		respondWithError(ctx, err, "Error removing member")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Member removed successfully",
+	})
+}
+
+// CreateInvite records an invite for the email; it is accepted by whoever
+// signs in with that address. No email is sent.
+func (h *Handler) CreateInvite(ctx *gin.Context) {
+	var body Invite
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if !body.Role.Valid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   "role must be one of owner, editor or viewer",
+		})
+		return
+	}
+
+	invite, err := h.store.CreateInvite(ctx.Request.Context(), workspace.ID(ctx), auth.UserID(ctx), body.Email, body.Role)
+	if err != nil {
+		respondWithError(ctx, err, "Error creating invite")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Invite created successfully",
+		"data":    invite,
+	})
+}
+
+func (h *Handler) ListInvites(ctx *gin.Context) {
+	invites, err := h.store.ListInvites(ctx.Request.Context(), workspace.ID(ctx))
+	if err != nil {
+		respondWithError(ctx, err, "Error querying database")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invites retrieved successfully",
+		"data":    invites,
+	})
+}
+
+func (h *Handler) DeleteInvite(ctx *gin.Context) {
+	if err := h.store.DeleteInvite(ctx.Request.Context(), workspace.ID(ctx), ctx.Param("inviteId")); err != nil {
+		respondWithError(ctx, err, "Error deleting invite")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invite deleted successfully",
+	})
+}
+
+// ListMyInvites lists the invites addressed to the caller's email
+func (h *Handler) ListMyInvites(ctx *gin.Context) {
+	identity, _ := auth.IdentityFrom(ctx)
+
+	invites := []store.Invite{}
+	if identity.Email != "" {
+		var err error
+		invites, err = h.store.ListInvitesFor(ctx.Request.Context(), identity.Email)
+		if err != nil {
+			respondWithError(ctx, err, "Error querying database")
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invites retrieved successfully",
+		"data":    invites,
+	})
+}
+
+func (h *Handler) AcceptInvite(ctx *gin.Context) {
+	identity, _ := auth.IdentityFrom(ctx)
+
+	joined, err := h.store.AcceptInvite(ctx.Request.Context(), ctx.Param("id"), identity.UserID, identity.Email)
+	if err != nil {
+		respondWithError(ctx, err, "Error accepting invite")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invite accepted successfully",
+		"data":    joined,
+	})
+}
+
+// respondWithError maps store errors to HTTP statuses
+func respondWithError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Not found"})
+	case errors.Is(err, store.ErrConflict):
+		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "This email is already a member or invited"})
+	case errors.Is(err, store.ErrLastOwner):
+		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "The workspace must keep at least one owner"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": message, "error": err.Error()})
+	}
+}
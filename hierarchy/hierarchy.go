@@ -0,0 +1,131 @@
+// Package hierarchy arranges the categories of a workspace into a tree and
+// rolls amounts up from subcategories to every ancestor, so "Housing" adds
+// up "Housing > Rent" and "Housing > Electricity".
+package hierarchy
+
+import (
+	"go-sheet/money"
+	"go-sheet/store"
+	"sort"
+)
+
+// Tree indexes categories by id and parent
+type Tree struct {
+	byID     map[string]store.Category
+	children map[string][]string
+	order    []string
+}
+
+// New builds the tree of categories. A parent missing from the list makes
+// its children top-level.
+func New(categories []store.Category) *Tree {
+	t := &Tree{byID: map[string]store.Category{}, children: map[string][]string{}}
+	for _, category := range categories {
+		t.byID[category.CategoryID] = category
+	}
+
+	sorted := append([]store.Category(nil), categories...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].CategoryName != sorted[j].CategoryName {
+			return sorted[i].CategoryName < sorted[j].CategoryName
+		}
+		return sorted[i].CategoryID < sorted[j].CategoryID
+	})
+	for _, category := range sorted {
+		parent := t.parent(category.CategoryID)
+		t.children[parent] = append(t.children[parent], category.CategoryID)
+	}
+
+	// Percorre em profundidade a partir das raízes; o visited protege contra
+	// ciclos que só existiriam em dados corrompidos
+	visited := map[string]bool{}
+	var walk func(id string)
+	walk = func(id string) {
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		t.order = append(t.order, id)
+		for _, child := range t.children[id] {
+			walk(child)
+		}
+	}
+	for _, root := range t.children[""] {
+		walk(root)
+	}
+
+	return t
+}
+
+// parent returns the parent id of a category, "" when it is top-level or
+// its parent is not in the tree
+func (t *Tree) parent(id string) string {
+	category := t.byID[id]
+	if category.ParentID == nil {
+		return ""
+	}
+	if _, ok := t.byID[*category.ParentID]; !ok {
+		return ""
+	}
+	return *category.ParentID
+}
+
+// Order returns the category ids depth-first, parents before their children
+// and siblings by name
+func (t *Tree) Order() []string {
+	return t.order
+}
+
+// Category returns the category with the given id
+func (t *Tree) Category(id string) (store.Category, bool) {
+	category, ok := t.byID[id]
+	return category, ok
+}
+
+// Ancestors returns the ancestors of a category, its parent first
+func (t *Tree) Ancestors(id string) []string {
+	var ancestors []string
+	seen := map[string]bool{id: true}
+	for parent := t.parent(id); parent != "" && !seen[parent]; parent = t.parent(parent) {
+		seen[parent] = true
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// Depth is 0 for top-level categories, 1 for their children and so on
+func (t *Tree) Depth(id string) int {
+	return len(t.Ancestors(id))
+}
+
+// Path returns the names from the top-level ancestor down to the category
+func (t *Tree) Path(id string) []string {
+	ancestors := t.Ancestors(id)
+	path := make([]string, 0, len(ancestors)+1)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		path = append(path, t.byID[ancestors[i]].CategoryName)
+	}
+	return append(path, t.byID[id].CategoryName)
+}
+
+// Amounts are the planned and spent amounts of a category
+type Amounts struct {
+	Planned money.Amount
+	Spent   money.Amount
+}
+
+// RollUp returns, for every category of the tree, its own amounts plus the
+// ones of all its subcategories. Categories missing from own count as zero.
+func (t *Tree) RollUp(own map[string]Amounts) map[string]Amounts {
+	totals := make(map[string]Amounts, len(t.byID))
+	for id := range t.byID {
+		amounts := own[id]
+		for _, target := range append([]string{id}, t.Ancestors(id)...) {
+			total := totals[target]
+			total.Planned = total.Planned.Add(amounts.Planned)
+			total.Spent = total.Spent.Add(amounts.Spent)
+			totals[target] = total
+		}
+	}
+	return totals
+}
@@ -0,0 +1,118 @@
+package hierarchy
+
+import (
+	"go-sheet/money"
+	"go-sheet/store"
+	"slices"
+	"testing"
+)
+
+func category(id, name, parent string) store.Category {
+	c := store.Category{CategoryID: id, CategoryName: name}
+	if parent != "" {
+		c.ParentID = &parent
+	}
+	return c
+}
+
+// home is Home > Utilities > {Power, Water}, Home > Rent, and Food at the
+// top level; Orphan points at a parent that is not in the list
+func home() *Tree {
+	return New([]store.Category{
+		category("water", "Water", "utilities"),
+		category("food", "Food", ""),
+		category("power", "Power", "utilities"),
+		category("rent", "Rent", "home"),
+		category("utilities", "Utilities", "home"),
+		category("home", "Home", ""),
+		category("orphan", "Orphan", "gone"),
+	})
+}
+
+func TestTree(t *testing.T) {
+	tree := home()
+
+	want := []string{"food", "home", "rent", "utilities", "power", "water", "orphan"}
+	if got := tree.Order(); !slices.Equal(got, want) {
+		t.Errorf("Order = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		id        string
+		ancestors []string
+		path      []string
+	}{
+		{"home", nil, []string{"Home"}},
+		{"utilities", []string{"home"}, []string{"Home", "Utilities"}},
+		{"water", []string{"utilities", "home"}, []string{"Home", "Utilities", "Water"}},
+		{"orphan", nil, []string{"Orphan"}},
+	}
+	for _, tt := range tests {
+		if got := tree.Ancestors(tt.id); !slices.Equal(got, tt.ancestors) {
+			t.Errorf("Ancestors(%s) = %v, want %v", tt.id, got, tt.ancestors)
+		}
+		if got := tree.Depth(tt.id); got != len(tt.ancestors) {
+			t.Errorf("Depth(%s) = %d, want %d", tt.id, got, len(tt.ancestors))
+		}
+		if got := tree.Path(tt.id); !slices.Equal(got, tt.path) {
+			t.Errorf("Path(%s) = %v, want %v", tt.id, got, tt.path)
+		}
+	}
+}
+
+func TestRollUp(t *testing.T) {
+	totals := home().RollUp(map[string]Amounts{
+		"home":   {Planned: 10000},
+		"rent":   {Planned: 150000, Spent: 150000},
+		"power":  {Planned: 20000, Spent: 23550},
+		"water":  {Planned: 8000},
+		"food":   {Planned: 90000, Spent: 41000},
+		"orphan": {Spent: 500},
+		// Amounts of categories outside the tree are ignored
+		"gone": {Planned: 1, Spent: 1},
+	})
+
+	want := map[string]Amounts{
+		"home":      {Planned: 188000, Spent: 173550},
+		"utilities": {Planned: 28000, Spent: 23550},
+		"power":     {Planned: 20000, Spent: 23550},
+		"water":     {Planned: 8000},
+		"rent":      {Planned: 150000, Spent: 150000},
+		"food":      {Planned: 90000, Spent: 41000},
+		"orphan":    {Spent: 500},
+	}
+	if len(totals) != len(want) {
+		t.Errorf("got totals for %d categories, want %d", len(totals), len(want))
+	}
+	for id, w := range want {
+		if got := totals[id]; got != w {
+			t.Errorf("%s = %s/%s, want %s/%s", id, got.Planned, got.Spent, w.Planned, w.Spent)
+		}
+	}
+}
+
+func TestCycleDoesNotLoop(t *testing.T) {
+	// The stores refuse to build a cycle; should one exist anyway, the tree
+	// must still terminate and count every amount at most once per category
+	tree := New([]store.Category{
+		category("a", "A", "c"),
+		category("b", "B", "a"),
+		category("c", "C", "b"),
+		category("d", "D", ""),
+	})
+
+	if got := tree.Ancestors("a"); !slices.Equal(got, []string{"c", "b"}) {
+		t.Errorf("Ancestors(a) = %v, want [c b]", got)
+	}
+	if got := tree.Order(); !slices.Equal(got, []string{"d"}) {
+		t.Errorf("Order = %v, want [d]", got)
+	}
+
+	one := Amounts{Planned: money.FromMinor(100), Spent: money.FromMinor(100)}
+	totals := tree.RollUp(map[string]Amounts{"a": one, "b": one, "c": one})
+	for _, id := range []string{"a", "b", "c"} {
+		if got := totals[id]; got.Planned != 300 || got.Spent != 300 {
+			t.Errorf("%s = %+v, want 3.00 planned and spent", id, got)
+		}
+	}
+}
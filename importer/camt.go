@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// camtDocument holds the parts of an ISO 20022 camt.053 statement that
+// become expenses. Tags carry no namespace so every camt.053.001.xx version
+// is read alike.
+type camtDocument struct {
+	Statements []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Amount struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	} `xml:"Amt"`
+	Indicator string `xml:"CdtDbtInd"`
+	// Sts is plain text up to version 05 and <Sts><Cd>…</Cd></Sts> after
+	Status struct {
+		Value string `xml:",chardata"`
+		Code  string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookingDate camtDate `xml:"BookgDt"`
+	ValueDate   camtDate `xml:"ValDt"`
+	Reference   string   `xml:"AcctSvcrRef"`
+	Info        string   `xml:"AddtlNtryInf"`
+	Details     []struct {
+		Reference   string   `xml:"Refs>AcctSvcrRef"`
+		EndToEndID  string   `xml:"Refs>EndToEndId"`
+		Creditor    string   `xml:"RltdPties>Cdtr>Nm"`
+		CreditorPty string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+		Remittance  []string `xml:"RmtInf>Ustrd"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) parse() (time.Time, bool) {
+	value := strings.TrimSpace(d.Date)
+	if value == "" {
+		value = strings.TrimSpace(d.DateTime)
+	}
+	if len(value) < 10 {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(time.DateOnly, value[:10])
+	return date, err == nil
+}
+
+func parseCAMT(r io.Reader) ([]Entry, error) {
+	var doc camtDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("not a camt.053 file: %w", err)
+	}
+
+	entries := []Entry{}
+	for _, statement := range doc.Statements {
+		for _, ntry := range statement.Entries {
+			if len(entries) == MaxEntries {
+				return nil, errTooManyEntries
+			}
+			entries = append(entries, camtToEntry(len(entries)+1, ntry))
+		}
+	}
+
+	return entries, nil
+}
+
+func camtToEntry(line int, ntry camtEntry) Entry {
+	entry := Entry{
+		Line:      line,
+		Currency:  ntry.Amount.Currency,
+		Reference: strings.TrimSpace(ntry.Reference),
+		Pending:   strings.TrimSpace(ntry.Status.Value+ntry.Status.Code) == "PDNG",
+	}
+
+	var remittance []string
+	for _, detail := range ntry.Details {
+		if entry.Reference == "" {
+			entry.Reference = strings.TrimSpace(detail.Reference)
+		}
+		if entry.Reference == "" && detail.EndToEndID != "NOTPROVIDED" {
+			entry.Reference = strings.TrimSpace(detail.EndToEndID)
+		}
+		if entry.Description == "" {
+			entry.Description = strings.TrimSpace(detail.Creditor + detail.CreditorPty)
+		}
+		remittance = append(remittance, detail.Remittance...)
+	}
+	// Sem credor (ex.: tarifas), a descrição vem do texto livre
+	if entry.Description == "" {
+		entry.Description = strings.TrimSpace(strings.Join(remittance, " "))
+	}
+	if entry.Description == "" {
+		entry.Description = strings.TrimSpace(ntry.Info)
+	}
+
+	date, ok := ntry.BookingDate.parse()
+	if !ok {
+		date, ok = ntry.ValueDate.parse()
+	}
+	if !ok {
+		entry.Err = errors.New("entry has no booking or value date")
+		return entry
+	}
+	entry.Date = date
+
+	amount, err := normalizeAmount(ntry.Amount.Value, false)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	if amount.IsNegative() {
+		entry.Err = fmt.Errorf("invalid amount %q", ntry.Amount.Value)
+		return entry
+	}
+	switch strings.TrimSpace(ntry.Indicator) {
+	case "DBIT":
+		entry.Amount = -amount
+	case "CRDT":
+		entry.Amount = amount
+	default:
+		entry.Err = fmt.Errorf("invalid CdtDbtInd %q", ntry.Indicator)
+	}
+
+	return entry
+}
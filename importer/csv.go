@@ -0,0 +1,207 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVMapping tells which columns of a CSV file hold each field. Columns are
+// named by their header (case-insensitive) or by their 1-based position.
+type CSVMapping struct {
+	Date        string `json:"date"`        // default "date"
+	Amount      string `json:"amount"`      // default "amount"
+	Description string `json:"description"` // default "description"
+	Reference   string `json:"reference"`   // optional
+	// DateFormat is either a Go layout or a pattern such as DD/MM/YYYY
+	// (default YYYY-MM-DD)
+	DateFormat string `json:"dateFormat"`
+	// Delimiter is a single character; by default it is guessed from the
+	// first line among ",", ";" and tab
+	Delimiter string `json:"delimiter"`
+	// DecimalComma reads "1.234,56" instead of "1,234.56"
+	DecimalComma bool `json:"decimalComma"`
+	// ExpensesPositive is for files that list spending as positive amounts
+	ExpensesPositive bool `json:"expensesPositive"`
+	// NoHeader means the first line is already data; columns must then be
+	// given by position
+	NoHeader bool `json:"noHeader"`
+}
+
+// dateLayout converts DD/MM/YYYY style patterns into a Go layout
+func (m CSVMapping) dateLayout() string {
+	if m.DateFormat == "" {
+		return time.DateOnly
+	}
+	return strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02").Replace(m.DateFormat)
+}
+
+func (m CSVMapping) delimiter(firstLine []byte) (rune, error) {
+	if m.Delimiter != "" {
+		if m.Delimiter == `\t` {
+			return '\t', nil
+		}
+		r := []rune(m.Delimiter)
+		if len(r) != 1 {
+			return 0, errors.New("delimiter must be a single character")
+		}
+		return r[0], nil
+	}
+
+	best, count := ',', bytes.Count(firstLine, []byte(","))
+	for _, candidate := range []rune{';', '\t'} {
+		if n := bytes.Count(firstLine, []byte(string(candidate))); n > count {
+			best, count = candidate, n
+		}
+	}
+	return best, nil
+}
+
+// csvColumns are the positions of the mapped fields; -1 when not mapped
+type csvColumns struct {
+	date, amount, description, reference int
+}
+
+func (m CSVMapping) columns(header []string) (csvColumns, error) {
+	find := func(field, spec, fallback string, required bool) (int, error) {
+		if spec == "" {
+			spec = fallback
+		}
+		if spec == "" {
+			return -1, nil
+		}
+		if n, err := strconv.Atoi(spec); err == nil {
+			if n < 1 {
+				return -1, fmt.Errorf("%s: column positions start at 1", field)
+			}
+			return n - 1, nil
+		}
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(spec)) {
+				return i, nil
+			}
+		}
+		if !required {
+			return -1, nil
+		}
+		return -1, fmt.Errorf("%s: no column named %q", field, spec)
+	}
+
+	var cols csvColumns
+	var err error
+	if cols.date, err = find("date", m.Date, "date", true); err != nil {
+		return cols, err
+	}
+	if cols.amount, err = find("amount", m.Amount, "amount", true); err != nil {
+		return cols, err
+	}
+	// Sem mapeamento explícito, descrição ausente não é erro
+	if cols.description, err = find("description", m.Description, "description", m.Description != ""); err != nil {
+		return cols, err
+	}
+	if cols.reference, err = find("reference", m.Reference, "", true); err != nil {
+		return cols, err
+	}
+	return cols, nil
+}
+
+func parseCSV(r io.Reader, mapping CSVMapping) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = toUTF8(bytes.TrimPrefix(data, []byte("\ufeff")))
+
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	delimiter, err := mapping.delimiter(firstLine)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var header []string
+	if !mapping.NoHeader {
+		if header, err = reader.Read(); err == io.EOF {
+			return nil, errors.New("the file is empty")
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	cols, err := mapping.columns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := mapping.dateLayout()
+	entries := []Entry{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			entries = append(entries, Entry{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(entries) == MaxEntries {
+			return nil, errTooManyEntries
+		}
+
+		line, _ := reader.FieldPos(0)
+		entries = append(entries, csvEntry(record, line, cols, layout, mapping))
+	}
+
+	return entries, nil
+}
+
+func csvEntry(record []string, line int, cols csvColumns, layout string, mapping CSVMapping) Entry {
+	entry := Entry{Line: line}
+	field := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	entry.Description = field(cols.description)
+	entry.Reference = field(cols.reference)
+
+	if cols.date >= len(record) || cols.amount >= len(record) {
+		entry.Err = fmt.Errorf("expected at least %d columns, found %d", max(cols.date, cols.amount)+1, len(record))
+		return entry
+	}
+
+	date, err := time.Parse(layout, field(cols.date))
+	if err != nil {
+		entry.Err = fmt.Errorf("invalid date %q, expected %s", field(cols.date), layout)
+		return entry
+	}
+	entry.Date = date
+
+	amount, err := normalizeAmount(field(cols.amount), mapping.DecimalComma)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	if mapping.ExpensesPositive {
+		amount = -amount
+	}
+	entry.Amount = amount
+
+	return entry
+}
@@ -0,0 +1,144 @@
+// Package importer reads bank statements (CSV, OFX/QFX and CAMT.053) and
+// turns their debit entries into expenses.
+//
+// An import has two steps. Preview parses a file, suggests a category for
+// each entry and flags entries that look already imported; nothing is
+// written. Commit then creates the rows the user accepted, all of them in a
+// single transaction or none at all.
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-sheet/money"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxEntries bounds the number of entries read from one file
+const MaxEntries = 5000
+
+// Format is a statement file format
+type Format string
+
+const (
+	CSV Format = "csv"
+	// OFX also reads QFX files, which are OFX with a few extra tags
+	OFX     Format = "ofx"
+	CAMT053 Format = "camt053"
+)
+
+// ParseFormat accepts "csv", "ofx", "qfx" and "camt053"
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case CSV, OFX, CAMT053:
+		return f, nil
+	case "qfx":
+		return OFX, nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected csv, ofx, qfx or camt053", s)
+}
+
+// DetectFormat guesses the format of an uploaded file from its name and,
+// for XML files, from its first bytes
+func DetectFormat(name string, head []byte) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv", ".txt":
+		return CSV, nil
+	case ".ofx", ".qfx":
+		return OFX, nil
+	}
+
+	upper := bytes.ToUpper(head)
+	switch {
+	case bytes.Contains(upper, []byte("OFXHEADER")) || bytes.Contains(upper, []byte("<OFX>")):
+		return OFX, nil
+	case bytes.Contains(head, []byte("camt.053")) || bytes.Contains(head, []byte("BkToCstmrStmt")):
+		return CAMT053, nil
+	}
+	return "", errors.New("cannot tell the file format, pass format=csv|ofx|camt053")
+}
+
+// Entry is one transaction of a statement. Amount is signed the way banks
+// sign it: negative amounts left the account. Err is set when the entry
+// could not be read; the other fields then hold whatever was understood.
+type Entry struct {
+	Line        int
+	Date        time.Time
+	Amount      money.Amount
+	Currency    string
+	Description string
+	// Reference identifies the entry within its bank (FITID, AcctSvcrRef)
+	Reference string
+	// Pending entries are not booked yet and are never imported
+	Pending bool
+	Err     error
+}
+
+// Parse reads every entry of a statement. mapping is only used by CSV and
+// may be nil. Errors in single entries are reported in Entry.Err; the error
+// returned means the file as a whole is unreadable.
+func Parse(format Format, r io.Reader, mapping *CSVMapping) ([]Entry, error) {
+	switch format {
+	case CSV:
+		if mapping == nil {
+			mapping = &CSVMapping{}
+		}
+		return parseCSV(r, *mapping)
+	case OFX:
+		return parseOFX(r)
+	case CAMT053:
+		return parseCAMT(r)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// errTooManyEntries is returned by the parsers past MaxEntries
+var errTooManyEntries = fmt.Errorf("a file may hold at most %d entries", MaxEntries)
+
+// toUTF8 reads text that is not valid UTF-8 as Latin-1, the charset of most
+// OFX 1.x files and spreadsheet exports
+func toUTF8(data []byte) []byte {
+	if utf8.Valid(data) {
+		return data
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return []byte(string(runes))
+}
+
+// normalizeAmount turns bank-formatted numbers such as "1.234,56",
+// "(12.00)", "R$ -3,10" or "45.00-" into what money.Parse accepts
+func normalizeAmount(s string, decimalComma bool) (money.Amount, error) {
+	var b strings.Builder
+	negative := false
+	text := strings.TrimSpace(s)
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") || strings.HasSuffix(text, "-") {
+		negative = true
+	}
+	for _, r := range text {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-':
+			negative = true
+		case r == '.' && !decimalComma, r == ',' && decimalComma:
+			b.WriteByte('.')
+		}
+	}
+
+	amount, err := money.Parse(b.String())
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
@@ -0,0 +1,197 @@
+package importer
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// want is the part of an Entry the parser tests compare
+type want struct {
+	date        string
+	amount      string
+	currency    string
+	description string
+	reference   string
+	pending     bool
+	err         bool
+}
+
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func parseFile(t *testing.T, format Format, name string, mapping *CSVMapping) []Entry {
+	t.Helper()
+	entries, err := Parse(format, openFixture(t, name), mapping)
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", name, err)
+	}
+	return entries
+}
+
+func checkEntries(t *testing.T, entries []Entry, wants []want) {
+	t.Helper()
+	if len(entries) != len(wants) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(wants), entries)
+	}
+	for i, w := range wants {
+		e := entries[i]
+		if w.err {
+			if e.Err == nil {
+				t.Errorf("entry %d: no error, want one: %+v", i+1, e)
+			}
+			continue
+		}
+		if e.Err != nil {
+			t.Errorf("entry %d: %v", i+1, e.Err)
+			continue
+		}
+		got := want{e.Date.Format(time.DateOnly), e.Amount.String(), e.Currency, e.Description, e.Reference, e.Pending, false}
+		if got != w {
+			t.Errorf("entry %d = %+v, want %+v", i+1, got, w)
+		}
+	}
+}
+
+func TestParseOFX(t *testing.T) {
+	// An OFX 1.x file in Windows-1252, with unclosed leaf elements
+	entries := parseFile(t, OFX, "statement.ofx", nil)
+	checkEntries(t, entries, []want{
+		{date: "2025-03-05", amount: "-45.90", currency: "BRL", description: "PADARIA CENTRAL", reference: "2025030501"},
+		{date: "2025-03-07", amount: "-1234.56", currency: "BRL", description: "Posto Shell - Combustível & lavagem", reference: "2025030702"},
+		{date: "2025-03-10", amount: "3000.00", currency: "BRL", description: "SALARIO", reference: "2025031003"},
+		{err: true},
+	})
+}
+
+func TestParseOFXErrors(t *testing.T) {
+	for _, content := range []string{"OFXHEADER:100\n\nno body", "<OFX><STMTTRN><TRNAMT"} {
+		if _, err := Parse(OFX, strings.NewReader(content), nil); err == nil {
+			t.Errorf("Parse(%q) succeeded", content)
+		}
+	}
+}
+
+func TestParseCAMT(t *testing.T) {
+	entries := parseFile(t, CAMT053, "statement.camt053.xml", nil)
+	checkEntries(t, entries, []want{
+		// The booking date wins over the value date
+		{date: "2025-03-04", amount: "-12.50", currency: "EUR", description: "Bakery GmbH", reference: "REF-001"},
+		// No creditor: the description comes from the remittance lines
+		{date: "2025-03-06", amount: "-3.00", currency: "EUR", description: "Account fee", reference: "E2E-77"},
+		{date: "2025-03-07", amount: "100.00", currency: "EUR", description: "Refund", pending: true},
+		{err: true},
+	})
+
+	if _, err := Parse(CAMT053, strings.NewReader("<Document><BkToCstmrStmt>"), nil); err == nil {
+		t.Error("Parse of a truncated document succeeded")
+	}
+}
+
+func TestParseCSVLatin1(t *testing.T) {
+	entries := parseFile(t, CSV, "latin1.csv", &CSVMapping{
+		Date:         "data",
+		Amount:       "valor",
+		Description:  "histórico",
+		DateFormat:   "DD/MM/YYYY",
+		DecimalComma: true,
+	})
+	checkEntries(t, entries, []want{
+		{date: "2025-03-05", amount: "-12.30", description: "Café São João"},
+		{date: "2025-03-06", amount: "1250.00", description: "Salário"},
+	})
+}
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain ascii", "plain ascii"},
+		{"já é UTF-8", "já é UTF-8"},
+		{"Caf\xe9 S\xe3o Jo\xe3o", "Café São João"},
+		{"\xa3 10", "£ 10"},
+	}
+	for _, tt := range tests {
+		if got := string(toUTF8([]byte(tt.in))); got != tt.want {
+			t.Errorf("toUTF8(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCSVDelimiter(t *testing.T) {
+	tests := []struct {
+		delimiter string
+		firstLine string
+		want      rune
+		err       bool
+	}{
+		{"", "date,amount,description", ',', false},
+		{"", "date;amount;description", ';', false},
+		{"", "date\tamount\tdescription", '\t', false},
+		// Commas inside the fields do not outvote the real delimiter
+		{"", "date;amount;description, notes;reference", ';', false},
+		{"", "date", ',', false},
+		{"|", "date,amount", '|', false},
+		{`\t`, "date,amount", '\t', false},
+		{";;", "date;amount", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := CSVMapping{Delimiter: tt.delimiter}.delimiter([]byte(tt.firstLine))
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("delimiter(%q) for %q = %q, %v; want %q", tt.delimiter, tt.firstLine, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseCSVGuessesDelimiter(t *testing.T) {
+	content := "date\tamount\tdescription\n2025-03-05\t-10.00\tBus, metro\n"
+	entries, err := Parse(CSV, bytes.NewReader([]byte(content)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, entries, []want{{date: "2025-03-05", amount: "-10.00", description: "Bus, metro"}})
+}
+
+func TestNormalizeAmount(t *testing.T) {
+	tests := []struct {
+		in           string
+		decimalComma bool
+		want         string
+		err          bool
+	}{
+		{"12.30", false, "12.30", false},
+		{"1,234.56", false, "1234.56", false},
+		{"1.234,56", true, "1234.56", false},
+		{"-3,10", true, "-3.10", false},
+		{"R$ -3,10", true, "-3.10", false},
+		{"(12.00)", false, "-12.00", false},
+		{"45.00-", false, "-45.00", false},
+		{" 7 ", false, "7.00", false},
+		// Without DecimalComma the comma is a thousands separator
+		{"1,5", false, "15.00", false},
+		{"12.345", false, "", true},
+		{"abc", false, "", true},
+		{"", false, "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeAmount(tt.in, tt.decimalComma)
+		if tt.err {
+			if err == nil {
+				t.Errorf("normalizeAmount(%q, %v) = %s, want an error", tt.in, tt.decimalComma, got)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("normalizeAmount(%q, %v) = %s, %v; want %s", tt.in, tt.decimalComma, got, err, tt.want)
+		}
+	}
+}
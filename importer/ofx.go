@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// parseOFX reads the STMTTRN aggregates of OFX 1.x (SGML) and 2.x (XML)
+// files. In SGML the leaf elements are not closed, so the document is read
+// as a flat sequence of tags: the text after an opening tag is its value and
+// only STMTTRN boundaries matter.
+func parseOFX(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = toUTF8(data)
+
+	start := bytes.Index(bytes.ToUpper(data), []byte("<OFX>"))
+	if start < 0 {
+		return nil, errors.New("not an OFX file: no <OFX> element")
+	}
+	body := string(data[start:])
+
+	entries := []Entry{}
+	var fields map[string]string
+	currency := ""
+	for body != "" {
+		open := strings.IndexByte(body, '<')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(body[open:], '>')
+		if end < 0 {
+			return nil, errors.New("malformed OFX: unterminated tag")
+		}
+		tag := strings.ToUpper(strings.TrimSpace(body[open+1 : open+end]))
+		body = body[open+end+1:]
+
+		next := strings.IndexByte(body, '<')
+		if next < 0 {
+			next = len(body)
+		}
+		value := html.UnescapeString(strings.TrimSpace(body[:next]))
+
+		switch {
+		case tag == "STMTTRN":
+			fields = map[string]string{}
+		case tag == "/STMTTRN":
+			if fields == nil {
+				continue
+			}
+			if len(entries) == MaxEntries {
+				return nil, errTooManyEntries
+			}
+			entries = append(entries, ofxEntry(len(entries)+1, fields, currency))
+			fields = nil
+		case tag == "CURDEF":
+			currency = value
+		case fields != nil && !strings.HasPrefix(tag, "/") && value != "":
+			if _, seen := fields[tag]; !seen {
+				fields[tag] = value
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+func ofxEntry(line int, fields map[string]string, currency string) Entry {
+	entry := Entry{Line: line, Reference: fields["FITID"], Currency: currency}
+	if c := fields["CURSYM"]; c != "" {
+		entry.Currency = c
+	}
+
+	name, memo := fields["NAME"], fields["MEMO"]
+	switch {
+	case name == "" || strings.EqualFold(name, memo):
+		entry.Description = memo
+	case memo == "":
+		entry.Description = name
+	default:
+		entry.Description = name + " - " + memo
+	}
+
+	// DTPOSTED: YYYYMMDD[HHMMSS[.XXX]][[offset:TZ]]; só a data interessa
+	posted := fields["DTPOSTED"]
+	if len(posted) < 8 {
+		entry.Err = fmt.Errorf("invalid DTPOSTED %q", posted)
+		return entry
+	}
+	date, err := time.Parse("20060102", posted[:8])
+	if err != nil {
+		entry.Err = fmt.Errorf("invalid DTPOSTED %q", posted)
+		return entry
+	}
+	entry.Date = date
+
+	// Some banks write TRNAMT with a decimal comma
+	raw := fields["TRNAMT"]
+	amount, err := normalizeAmount(raw, strings.Contains(raw, ",") && !strings.Contains(raw, "."))
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	entry.Amount = amount
+
+	return entry
+}
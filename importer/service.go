@@ -0,0 +1,391 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/rules"
+	"go-sheet/store"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// historyMonths is how far before a statement Preview looks for expenses
+// with the same description to suggest their category
+const historyMonths = 6
+
+// Store is what an import reads from and writes to
+type Store interface {
+	ListCategories(ctx context.Context, workspaceID string) ([]store.Category, error)
+	rules.Lister
+	store.ImportStore
+	BaseCurrency(ctx context.Context, workspaceID string) (money.Currency, error)
+}
+
+// Service previews and commits imports. Statements must be in the base
+// currency of the workspace.
+type Service struct {
+	store Store
+}
+
+// NewService returns a Service backed by the given store
+func NewService(s Store) *Service {
+	return &Service{store: s}
+}
+
+// Row states reported by Preview
+const (
+	// RowNew is an expense not seen before, ready to be imported
+	RowNew = "new"
+	// RowDuplicate looks like an expense that already exists
+	RowDuplicate = "duplicate"
+	// RowSkipped is a credit or an entry not booked yet
+	RowSkipped = "skipped"
+	// RowInvalid could not be read
+	RowInvalid = "invalid"
+)
+
+// PreviewRow is an entry of the statement as it would be imported. Amount
+// is always positive; skipped credits carry the amount received. RuleID is
+// the categorisation rule that suggested the category, if any.
+type PreviewRow struct {
+	Line                  int           `json:"line"`
+	Status                string        `json:"status"`
+	Date                  *string       `json:"date"`
+	Amount                *money.Amount `json:"amount"`
+	Description           string        `json:"description"`
+	Reference             string        `json:"reference"`
+	SuggestedCategoryID   *string       `json:"suggestedCategoryId"`
+	SuggestedCategoryName *string       `json:"suggestedCategoryName"`
+	SuggestedPaidID       *string       `json:"suggestedPaidId"`
+	SuggestedStatusID     *string       `json:"suggestedStatusId"`
+	RuleID                *string       `json:"ruleId"`
+	DuplicateOf           *string       `json:"duplicateOf"`
+	Message               string        `json:"message,omitempty"`
+}
+
+// Preview classifies every entry and suggests categories; nothing is written
+func (s *Service) Preview(ctx context.Context, workspaceID string, entries []Entry) ([]PreviewRow, error) {
+	var first, last time.Time
+	for _, entry := range entries {
+		if entry.Err != nil {
+			continue
+		}
+		if first.IsZero() || entry.Date.Before(first) {
+			first = entry.Date
+		}
+		if entry.Date.After(last) {
+			last = entry.Date
+		}
+	}
+
+	var fingerprints []store.ExpenseFingerprint
+	var categories []store.Category
+	engine, _ := rules.New(nil)
+	if !first.IsZero() {
+		var err error
+		fingerprints, err = s.store.ExpenseFingerprints(ctx, workspaceID, first.AddDate(0, -historyMonths, 0), last)
+		if err != nil {
+			return nil, err
+		}
+		if categories, err = s.store.ListCategories(ctx, workspaceID); err != nil {
+			return nil, err
+		}
+		if engine, err = rules.Load(ctx, s.store, workspaceID); err != nil {
+			return nil, err
+		}
+	}
+	match := newMatcher(fingerprints, categories)
+	currency, err := s.store.BaseCurrency(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]PreviewRow, 0, len(entries))
+	seenRefs := map[string]int{}
+	for _, entry := range entries {
+		row := PreviewRow{Line: entry.Line, Status: RowNew, Description: entry.Description, Reference: entry.Reference}
+		if entry.Err != nil {
+			row.Status, row.Message = RowInvalid, entry.Err.Error()
+			rows = append(rows, row)
+			continue
+		}
+
+		date := entry.Date.Format(time.DateOnly)
+		amount, shown := -entry.Amount, -entry.Amount
+		if shown.IsNegative() {
+			shown = entry.Amount
+		}
+		row.Date, row.Amount = &date, &shown
+
+		switch {
+		case entry.Currency != "" && entry.Currency != currency.String():
+			row.Status, row.Message = RowInvalid, fmt.Sprintf("amount is in %s, the workspace uses %s", entry.Currency, currency)
+		case entry.Pending:
+			row.Status, row.Message = RowSkipped, "entry is not booked yet"
+		case amount.IsNegative() || amount == 0:
+			row.Status, row.Message = RowSkipped, "credits are not expenses"
+		}
+		if row.Status != RowNew {
+			rows = append(rows, row)
+			continue
+		}
+
+		if line, ok := seenRefs[entry.Reference]; ok && entry.Reference != "" {
+			row.Status, row.Message = RowDuplicate, fmt.Sprintf("same reference as line %d", line)
+		} else if id, ok := match.duplicate(entry.Date, amount, entry.Description, entry.Reference); ok {
+			row.Status, row.DuplicateOf = RowDuplicate, &id
+		}
+		seenRefs[entry.Reference] = entry.Line
+
+		// As regras têm prioridade sobre o histórico e os nomes das categorias
+		result := engine.Apply(rules.Transaction{Description: entry.Description, Amount: amount, Date: entry.Date})
+		row.SuggestedPaidID, row.SuggestedStatusID = result.PaidID, result.StatusID
+		if category, ok := match.categories[deref(result.CategoryID)]; ok {
+			row.SuggestedCategoryID, row.SuggestedCategoryName = &category.CategoryID, &category.CategoryName
+			row.RuleID = &result.CategoryRule.ID
+		} else if category, ok := match.category(entry.Description); ok {
+			row.SuggestedCategoryID, row.SuggestedCategoryName = &category.CategoryID, &category.CategoryName
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// matcher looks entries up among existing expenses and categories
+type matcher struct {
+	byRef      map[string]string
+	byDay      map[string][]store.ExpenseFingerprint
+	used       map[string]bool
+	history    map[string]string
+	categories map[string]store.Category
+	names      []store.Category
+}
+
+func newMatcher(fingerprints []store.ExpenseFingerprint, categories []store.Category) *matcher {
+	m := &matcher{
+		byRef:      map[string]string{},
+		byDay:      map[string][]store.ExpenseFingerprint{},
+		used:       map[string]bool{},
+		history:    map[string]string{},
+		categories: map[string]store.Category{},
+	}
+
+	// Mais recentes por último, para que prevaleçam no histórico
+	sort.SliceStable(fingerprints, func(i, j int) bool {
+		return fingerprints[i].PaymentDate.Before(fingerprints[j].PaymentDate)
+	})
+	for _, fp := range fingerprints {
+		if fp.ImportRef != "" {
+			m.byRef[fp.ImportRef] = fp.ExpenseID
+		}
+		day := fp.PaymentDate.Format(time.DateOnly)
+		m.byDay[day] = append(m.byDay[day], fp)
+		if key := normalize(fp.Description); key != "" {
+			m.history[key] = fp.CategoryID
+		}
+	}
+
+	// Categorias arquivadas não recebem despesas novas, então não são sugeridas
+	for _, category := range categories {
+		if category.ArchivedAt != nil {
+			continue
+		}
+		m.categories[category.CategoryID] = category
+		if normalize(category.CategoryName) != "" {
+			m.names = append(m.names, category)
+		}
+	}
+	// Nomes mais longos são mais específicos ("Mercado Livre" antes de "Mercado")
+	sort.SliceStable(m.names, func(i, j int) bool {
+		return len(m.names[i].CategoryName) > len(m.names[j].CategoryName)
+	})
+
+	return m
+}
+
+// duplicate finds an expense with the same reference or, failing that, one
+// paid the same day with the same amount whose description matches or is
+// empty. Each expense is matched at most once.
+func (m *matcher) duplicate(date time.Time, amount money.Amount, description, reference string) (string, bool) {
+	if id, ok := m.byRef[reference]; ok && reference != "" {
+		m.used[id] = true
+		return id, true
+	}
+
+	key := normalize(description)
+	for _, fp := range m.byDay[date.Format(time.DateOnly)] {
+		if m.used[fp.ExpenseID] || fp.SpentAmount != amount {
+			continue
+		}
+		if existing := normalize(fp.Description); existing == "" || existing == key {
+			m.used[fp.ExpenseID] = true
+			return fp.ExpenseID, true
+		}
+	}
+	return "", false
+}
+
+// category suggests the category last used for the same description, or
+// else one whose name appears in it
+func (m *matcher) category(description string) (store.Category, bool) {
+	key := normalize(description)
+	if key == "" {
+		return store.Category{}, false
+	}
+	if category, ok := m.categories[m.history[key]]; ok {
+		return category, true
+	}
+
+	padded := " " + key + " "
+	for _, category := range m.names {
+		if strings.Contains(padded, " "+normalize(category.CategoryName)+" ") {
+			return category, true
+		}
+	}
+	return store.Category{}, false
+}
+
+// normalize lowercases s and keeps only its words, so "PAG*Padaria 123"
+// and "pag padaria" compare equal
+func normalize(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// CommitRow is a previewed row the user accepted, possibly edited
+type CommitRow struct {
+	Line        int          `json:"line"`
+	Date        string       `json:"date"`
+	Amount      money.Amount `json:"amount"`
+	Description string       `json:"description"`
+	Reference   string       `json:"reference"`
+	CategoryID  string       `json:"categoryId"`
+	PaidID      string       `json:"paidId"`
+	StatusID    string       `json:"statusId"`
+}
+
+// RowResult is the outcome of one CommitRow
+type RowResult struct {
+	Line      int    `json:"line"`
+	ExpenseID string `json:"expenseId,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report lists the outcome of every row of a commit
+type Report struct {
+	Imported int         `json:"imported"`
+	Rows     []RowResult `json:"rows"`
+}
+
+// ErrRejected means nothing was imported; the Report names the failed rows
+var ErrRejected = errors.New("import rejected, nothing was imported")
+
+// Commit imports every row or, when any of them is invalid, none. The
+// workspace rules fill in the category, paid type and status rows leave out.
+func (s *Service) Commit(ctx context.Context, workspaceID string, rows []CommitRow) (Report, error) {
+	engine, err := rules.Load(ctx, s.store, workspaceID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Rows: make([]RowResult, len(rows))}
+	expenses := make([]store.ImportedExpense, len(rows))
+	rejected := false
+	for i, row := range rows {
+		report.Rows[i].Line = row.Line
+		expense, err := row.expense()
+		if err == nil {
+			err = fill(engine, &expense)
+		}
+		if err != nil {
+			report.Rows[i].Error, rejected = err.Error(), true
+			continue
+		}
+		expenses[i] = expense
+	}
+	if rejected {
+		return report, ErrRejected
+	}
+
+	ids, err := s.store.ImportExpenses(ctx, workspaceID, expenses)
+	var importErr *store.ImportError
+	if errors.As(err, &importErr) && importErr.Index < len(rows) {
+		message := importErr.Err.Error()
+		if errors.Is(importErr.Err, store.ErrConflict) {
+			message = "another expense already has this reference"
+		}
+		report.Rows[importErr.Index].Error = message
+		return report, ErrRejected
+	} else if err != nil {
+		return report, err
+	}
+
+	for i, id := range ids {
+		report.Rows[i].ExpenseID = id
+	}
+	report.Imported = len(ids)
+
+	return report, nil
+}
+
+func (r CommitRow) expense() (store.ImportedExpense, error) {
+	date, err := time.Parse(time.DateOnly, r.Date)
+	if err != nil {
+		return store.ImportedExpense{}, errors.New("date: expected YYYY-MM-DD")
+	}
+	if r.Amount.IsNegative() || r.Amount == 0 {
+		return store.ImportedExpense{}, errors.New("amount must be greater than zero")
+	}
+
+	return store.ImportedExpense{
+		CategoryID:  r.CategoryID,
+		PaidID:      r.PaidID,
+		StatusID:    r.StatusID,
+		SpentAmount: r.Amount,
+		PaymentDate: date,
+		Description: strings.TrimSpace(r.Description),
+		ImportRef:   strings.TrimSpace(r.Reference),
+	}, nil
+}
+
+// fill completes the fields an expense left empty from the rules
+func fill(engine *rules.Engine, expense *store.ImportedExpense) error {
+	if expense.CategoryID != "" && expense.PaidID != "" && expense.StatusID != "" {
+		return nil
+	}
+
+	result := engine.Apply(rules.Transaction{
+		Description: expense.Description,
+		Amount:      expense.SpentAmount,
+		PaidID:      expense.PaidID,
+		Date:        expense.PaymentDate,
+	})
+	if expense.CategoryID == "" {
+		expense.CategoryID = deref(result.CategoryID)
+	}
+	if expense.PaidID == "" {
+		expense.PaidID = deref(result.PaidID)
+	}
+	if expense.StatusID == "" {
+		expense.StatusID = deref(result.StatusID)
+	}
+
+	if expense.CategoryID == "" {
+		return errors.New("categoryId is required: no rule sets a category")
+	}
+	return nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"context"
+	"go-sheet/money"
+	"go-sheet/store"
+	"testing"
+	"time"
+)
+
+// fakeStore serves fixed fingerprints; it has no categories nor rules
+type fakeStore struct {
+	store.ImportStore
+	fingerprints []store.ExpenseFingerprint
+}
+
+func (f *fakeStore) ExpenseFingerprints(ctx context.Context, workspaceID string, from, to time.Time) ([]store.ExpenseFingerprint, error) {
+	return f.fingerprints, nil
+}
+
+func (f *fakeStore) ListCategories(ctx context.Context, workspaceID string) ([]store.Category, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) ListRules(ctx context.Context, workspaceID string) ([]store.Rule, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) BaseCurrency(ctx context.Context, workspaceID string) (money.Currency, error) {
+	return "BRL", nil
+}
+
+func day(d int) time.Time {
+	return time.Date(2025, time.March, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPreviewDuplicates(t *testing.T) {
+	s := NewService(&fakeStore{fingerprints: []store.ExpenseFingerprint{
+		{ExpenseID: "by-ref", PaymentDate: day(1), SpentAmount: 999, Description: "Other", ImportRef: "REF-1"},
+		{ExpenseID: "bakery", PaymentDate: day(5), SpentAmount: 4590, Description: "pag*Padaria  Central"},
+		{ExpenseID: "no-description", PaymentDate: day(6), SpentAmount: 2000},
+	}})
+
+	entries := []Entry{
+		// Same reference, even with another date, amount and description
+		{Line: 2, Date: day(3), Amount: -1000, Description: "Bakery", Reference: "REF-1"},
+		// Same day and amount, description equal once normalised
+		{Line: 3, Date: day(5), Amount: -4590, Description: "PAG PADARIA CENTRAL 123"},
+		// The bakery expense was already matched by the line above
+		{Line: 4, Date: day(5), Amount: -4590, Description: "Padaria Central"},
+		// Another day
+		{Line: 5, Date: day(4), Amount: -4590, Description: "Padaria Central"},
+		// Another amount
+		{Line: 6, Date: day(5), Amount: -4591, Description: "Padaria Central"},
+		// The existing expense has no description to compare with
+		{Line: 7, Date: day(6), Amount: -2000, Description: "Uber"},
+		// Another description on the same day and amount
+		{Line: 8, Date: day(6), Amount: -2000, Description: "Taxi"},
+		{Line: 9, Date: day(7), Amount: -500, Description: "Metro", Reference: "REF-2"},
+		// Repeated within the statement itself
+		{Line: 10, Date: day(7), Amount: -500, Description: "Metro", Reference: "REF-2"},
+	}
+	rows, err := s.Preview(context.Background(), "workspace", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wants := []struct {
+		status      string
+		duplicateOf string
+		message     string
+	}{
+		{RowDuplicate, "by-ref", ""},
+		{RowDuplicate, "bakery", ""},
+		{RowNew, "", ""},
+		{RowNew, "", ""},
+		{RowNew, "", ""},
+		{RowDuplicate, "no-description", ""},
+		{RowNew, "", ""},
+		{RowNew, "", ""},
+		{RowDuplicate, "", "same reference as line 9"},
+	}
+	if len(rows) != len(wants) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(wants))
+	}
+	for i, w := range wants {
+		row := rows[i]
+		duplicateOf := ""
+		if row.DuplicateOf != nil {
+			duplicateOf = *row.DuplicateOf
+		}
+		if row.Status != w.status || duplicateOf != w.duplicateOf || row.Message != w.message {
+			t.Errorf("line %d: got %s %q %q, want %s %q %q",
+				row.Line, row.Status, duplicateOf, row.Message, w.status, w.duplicateOf, w.message)
+		}
+	}
+}
+
+func TestPreviewSkipsAndRejects(t *testing.T) {
+	s := NewService(&fakeStore{})
+	entries, err := Parse(OFX, openFixture(t, "statement.ofx"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries = append(entries,
+		Entry{Line: 10, Date: day(8), Amount: -100, Currency: "USD", Description: "Abroad"},
+		Entry{Line: 11, Date: day(9), Amount: -100, Description: "Pending", Pending: true},
+	)
+
+	rows, err := s.Preview(context.Background(), "workspace", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []string
+	for _, row := range rows {
+		statuses = append(statuses, row.Status)
+	}
+	want := []string{RowNew, RowNew, RowSkipped, RowInvalid, RowInvalid, RowSkipped}
+	if len(statuses) != len(want) {
+		t.Fatalf("statuses = %v, want %v", statuses, want)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("statuses = %v, want %v", statuses, want)
+			break
+		}
+	}
+	// Expenses and credits are both shown as positive amounts
+	if got := rows[1].Amount.String(); got != "1234.56" {
+		t.Errorf("amount of an expense = %s, want 1234.56", got)
+	}
+	if got := rows[2].Amount.String(); got != "3000.00" {
+		t.Errorf("amount of a credit = %s, want 3000.00", got)
+	}
+}
@@ -0,0 +1,27 @@
+package money
+
+import "fmt"
+
+// DefaultCurrency is used when no currency is configured
+const DefaultCurrency Currency = "BRL"
+
+// Currency is an ISO 4217 alphabetic code such as BRL or USD
+type Currency string
+
+// ParseCurrency validates a three-letter upper-case currency code
+func ParseCurrency(s string) (Currency, error) {
+	if len(s) != 3 {
+		return "", fmt.Errorf("invalid currency %q, use a three-letter ISO 4217 code", s)
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("invalid currency %q, use a three-letter ISO 4217 code", s)
+		}
+	}
+	return Currency(s), nil
+}
+
+// String returns the currency code
+func (c Currency) String() string {
+	return string(c)
+}
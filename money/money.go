@@ -0,0 +1,218 @@
+// Package money represents amounts exactly, as integer minor units (cents),
+// instead of float64.
+//
+// Amounts travel as fixed-scale decimal strings ("1234.50") in JSON and as
+// NUMERIC(14, 2) in Postgres, so no value is ever rounded on the way.
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimal places kept in an Amount
+const Scale = 2
+
+const unit = 100 // 10^Scale
+
+var (
+	// ErrTooPrecise is returned for amounts with more than Scale decimals
+	ErrTooPrecise = fmt.Errorf("amount has more than %d decimal places", Scale)
+	// ErrNegative is returned by Validate for amounts below zero
+	ErrNegative = errors.New("amount must not be negative")
+	// ErrOutOfRange is returned for amounts that do not fit NUMERIC(14, 2)
+	ErrOutOfRange = errors.New("amount is too large")
+)
+
+// maxAmount is the largest value NUMERIC(14, 2) can hold, in minor units
+const maxAmount = 999_999_999_999_99
+
+// Amount is a quantity of money in minor units, e.g. 1050 is 10.50
+type Amount int64
+
+// FromMinor returns the Amount worth minor units
+func FromMinor(minor int64) Amount {
+	return Amount(minor)
+}
+
+// Parse reads a decimal such as "10", "10.5" or "-3.25". It rejects more
+// than Scale decimals instead of rounding them away.
+func Parse(s string) (Amount, error) {
+	text := strings.TrimSpace(s)
+	negative := false
+	switch {
+	case strings.HasPrefix(text, "-"):
+		negative, text = true, text[1:]
+	case strings.HasPrefix(text, "+"):
+		text = text[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(text, ".")
+	if whole == "" && fraction == "" || !digitsOnly(whole) || !digitsOnly(fraction) || (hasPoint && fraction == "") {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(strings.TrimRight(fraction, "0")) > Scale {
+		return 0, ErrTooPrecise
+	}
+	fraction = (fraction + strings.Repeat("0", Scale))[:Scale]
+
+	if whole == "" {
+		whole = "0"
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > maxAmount/unit {
+		return 0, ErrOutOfRange
+	}
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+
+	amount := units*unit + cents
+	if amount > maxAmount {
+		return 0, ErrOutOfRange
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return Amount(amount), nil
+}
+
+// FromFloat converts a float that is known to carry at most Scale decimals,
+// such as a value that round-tripped through NUMERIC(14, 2)
+func FromFloat(f float64) (Amount, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid amount %v", f)
+	}
+	return Parse(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+func digitsOnly(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor returns the amount in minor units
+func (a Amount) Minor() int64 {
+	return int64(a)
+}
+
+// Add returns a + b
+func (a Amount) Add(b Amount) Amount {
+	return a + b
+}
+
+// Sub returns a - b
+func (a Amount) Sub(b Amount) Amount {
+	return a - b
+}
+
+// IsNegative reports whether a is below zero
+func (a Amount) IsNegative() bool {
+	return a < 0
+}
+
+// Validate rejects negative amounts; input amounts (spent, planned) can never
+// be below zero, only computed differences can
+func (a Amount) Validate() error {
+	if a < 0 {
+		return ErrNegative
+	}
+	return nil
+}
+
+// String formats the amount with exactly Scale decimals, e.g. "-3.50"
+func (a Amount) String() string {
+	sign := ""
+	minor := int64(a)
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/unit, Scale, minor%unit)
+}
+
+// MarshalJSON renders the amount as a fixed-scale string, e.g. "10.50"
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts both "10.50" and 10.50. Numbers are parsed from
+// their literal text, never through float64.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(bytes.TrimSpace(data))
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	} else if strings.ContainsAny(text, "eE") {
+		return fmt.Errorf("invalid amount %s: exponents are not supported", text)
+	}
+
+	amount, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Value stores the amount as a decimal string, which Postgres casts to NUMERIC
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan reads NUMERIC columns, which the driver hands over as text
+func (a *Amount) Scan(src any) error {
+	var err error
+	switch v := src.(type) {
+	case []byte:
+		*a, err = Parse(string(v))
+	case string:
+		*a, err = Parse(v)
+	case int64:
+		*a = Amount(v * unit)
+	case float64:
+		*a, err = FromFloat(v)
+	case nil:
+		return errors.New("cannot scan NULL into money.Amount, use money.NullAmount")
+	default:
+		return fmt.Errorf("cannot scan %T into money.Amount", src)
+	}
+	return err
+}
+
+// NullAmount is an Amount that may be NULL
+type NullAmount struct {
+	Amount Amount
+	Valid  bool
+}
+
+// Scan implements sql.Scanner
+func (n *NullAmount) Scan(src any) error {
+	if src == nil {
+		*n = NullAmount{}
+		return nil
+	}
+	n.Valid = true
+	return n.Amount.Scan(src)
+}
+
+// Ptr returns nil for NULL and a pointer to the amount otherwise
+func (n NullAmount) Ptr() *Amount {
+	if !n.Valid {
+		return nil
+	}
+	amount := n.Amount
+	return &amount
+}
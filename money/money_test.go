@@ -0,0 +1,141 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr error
+	}{
+		{in: "10", want: 1000},
+		{in: "10.5", want: 1050},
+		{in: "10.50", want: 1050},
+		{in: ".75", want: 75},
+		{in: " +3.25 ", want: 325},
+		{in: "-3.25", want: -325},
+		// Zeros past the scale lose nothing
+		{in: "1.2300", want: 123},
+		{in: "999999999999.99", want: maxAmount},
+		{in: "1.234", wantErr: ErrTooPrecise},
+		{in: "0.001", wantErr: ErrTooPrecise},
+		{in: "-0.005", wantErr: ErrTooPrecise},
+		{in: "1000000000000", wantErr: ErrOutOfRange},
+		{in: "99999999999999999999", wantErr: ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "-", ".", "1.", "1,50", "1.2.3", "1e3", "--1", "R$1"} {
+		if got, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %d, want an error", in, got)
+		}
+	}
+}
+
+func TestValidateRejectsNegatives(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr error
+	}{
+		{"0", nil},
+		{"0.01", nil},
+		{"-0.01", ErrNegative},
+		{"-10", ErrNegative},
+	}
+	for _, tt := range tests {
+		var a Amount
+		if err := json.Unmarshal([]byte(`"`+tt.in+`"`), &a); err != nil {
+			t.Fatalf("Unmarshal(%q): %v", tt.in, err)
+		}
+		if err := a.Validate(); err != tt.wantErr {
+			t.Errorf("Validate(%s) = %v, want %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{1050, "10.50"},
+		{-5, "-0.05"},
+		{-123456, "-1234.56"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type body struct {
+		Amount  Amount  `json:"amount"`
+		Planned *Amount `json:"planned"`
+	}
+
+	for _, want := range []Amount{0, 1, 1050, -325, maxAmount} {
+		data, err := json.Marshal(body{Amount: want, Planned: &want})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got body
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", data, err)
+		}
+		if got.Amount != want || got.Planned == nil || *got.Planned != want {
+			t.Errorf("%d came back as %+v from %s", want, got, data)
+		}
+	}
+
+	data, _ := json.Marshal(body{Amount: 1050})
+	if string(data) != `{"amount":"10.50","planned":null}` {
+		t.Errorf("Marshal = %s", data)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: `"10.50"`, want: 1050},
+		// Numbers are read from their text, so 0.1 + 0.2 style noise never appears
+		{in: `0.30`, want: 30},
+		{in: `12`, want: 1200},
+		{in: `null`, want: 0},
+		{in: `"1.005"`, wantErr: true},
+		{in: `1.005`, wantErr: true},
+		{in: `1e2`, wantErr: true},
+		{in: `"abc"`, wantErr: true},
+		{in: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got Amount
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
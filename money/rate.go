@@ -0,0 +1,134 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RateScale is the number of decimal places kept in a Rate, as in the
+// NUMERIC(18, 8) column exchange rates are stored in
+const RateScale = 8
+
+const rateUnit = 100_000_000 // 10^RateScale
+
+// maxRate is the largest value NUMERIC(18, 8) can hold, in rate units
+const maxRate = 999_999_999_999_999_999
+
+// ErrInvalidRate is returned for rates that are not positive decimals
+var ErrInvalidRate = errors.New("rate must be a positive decimal")
+
+// Rate is an exchange rate: what one unit of a currency is worth in another,
+// in units of 10^-RateScale
+type Rate int64
+
+// ParseRate reads a positive decimal such as "5.4321". Like Parse, it
+// rejects more than RateScale decimals instead of rounding them away.
+func ParseRate(s string) (Rate, error) {
+	text := strings.TrimPrefix(strings.TrimSpace(s), "+")
+	whole, fraction, hasPoint := strings.Cut(text, ".")
+	if whole == "" && fraction == "" || !digitsOnly(whole) || !digitsOnly(fraction) || (hasPoint && fraction == "") {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	if len(strings.TrimRight(fraction, "0")) > RateScale {
+		return 0, fmt.Errorf("rate has more than %d decimal places", RateScale)
+	}
+	fraction = (fraction + strings.Repeat("0", RateScale))[:RateScale]
+
+	if whole == "" {
+		whole = "0"
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > maxRate/rateUnit {
+		return 0, fmt.Errorf("rate %q is too large", s)
+	}
+	decimals, _ := strconv.ParseInt(fraction, 10, 64)
+
+	rate := Rate(units*rateUnit + decimals)
+	if rate <= 0 {
+		return 0, ErrInvalidRate
+	}
+	return rate, nil
+}
+
+// Convert returns a, in the currency the rate converts from, in the currency
+// it converts to, rounded half away from zero like ROUND on NUMERIC
+func (r Rate) Convert(a Amount) Amount {
+	product := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(int64(r)))
+	return Amount(roundQuo(product, big.NewInt(rateUnit)))
+}
+
+// ConvertBack is the inverse of Convert: it returns a, in the currency the
+// rate converts to, in the currency it converts from
+func (r Rate) ConvertBack(a Amount) Amount {
+	product := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(rateUnit))
+	return Amount(roundQuo(product, big.NewInt(int64(r))))
+}
+
+// roundQuo returns n/d rounded half away from zero; d is positive
+func roundQuo(n, d *big.Int) int64 {
+	q, m := new(big.Int).QuoRem(n, d, new(big.Int))
+	if m.Abs(m).Mul(m, big.NewInt(2)).Cmp(d) >= 0 {
+		if n.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
+
+// String formats the rate without trailing zeros, e.g. "5.4321"
+func (r Rate) String() string {
+	text := fmt.Sprintf("%d.%0*d", int64(r)/rateUnit, RateScale, int64(r)%rateUnit)
+	return strings.TrimSuffix(strings.TrimRight(text, "0"), ".")
+}
+
+// MarshalJSON renders the rate as a string, e.g. "5.4321"
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON accepts both "5.4321" and 5.4321, parsed from their text
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	text := string(bytes.TrimSpace(data))
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	rate, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+// Value stores the rate as a decimal string, which Postgres casts to NUMERIC
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan reads NUMERIC columns, which the driver hands over as text
+func (r *Rate) Scan(src any) error {
+	var err error
+	switch v := src.(type) {
+	case []byte:
+		*r, err = ParseRate(string(v))
+	case string:
+		*r, err = ParseRate(v)
+	default:
+		return fmt.Errorf("cannot scan %T into money.Rate", src)
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rate
+		wantErr bool
+	}{
+		{in: "1", want: rateUnit},
+		{in: "5.4321", want: 543_210_000},
+		{in: "0.00000001", want: 1},
+		{in: "+0.5", want: rateUnit / 2},
+		{in: "0.123456780", want: 12_345_678},
+		{in: "0.123456789", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "-1.5", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "1.", wantErr: true},
+		{in: "10000000000", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRate(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRate(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		rate   string
+		amount Amount
+		want   Amount
+	}{
+		{"5.4321", 1000, 5432},
+		// 0.10 * 5.45 = 0.545 rounds half away from zero
+		{"5.45", 10, 55},
+		{"5.45", -10, -55},
+		{"5.44", 10, 54},
+		{"0.00000001", 100, 0},
+		// amount * rate overflows int64 before the division
+		{"1000.5", maxAmount, 100_049_999_999_999_000},
+	}
+	for _, tt := range tests {
+		rate, err := ParseRate(tt.rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rate.Convert(tt.amount); got != tt.want {
+			t.Errorf("%s.Convert(%s) = %s, want %s", tt.rate, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestConvertBack(t *testing.T) {
+	tests := []struct {
+		rate   string
+		amount Amount
+		want   Amount
+	}{
+		{"5", 1000, 200},
+		// 1.00 / 3 = 0.333…
+		{"3", 100, 33},
+		// 2.00 / 3 = 0.666…
+		{"3", 200, 67},
+		{"3", -200, -67},
+		// 0.05 / 2 = 0.025 rounds half away from zero
+		{"2", 5, 3},
+		{"2", -5, -3},
+	}
+	for _, tt := range tests {
+		rate, err := ParseRate(tt.rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rate.ConvertBack(tt.amount); got != tt.want {
+			t.Errorf("%s.ConvertBack(%s) = %s, want %s", tt.rate, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestRateJSON(t *testing.T) {
+	rate, _ := ParseRate("5.43210000")
+	data, err := json.Marshal(rate)
+	if err != nil || string(data) != `"5.4321"` {
+		t.Fatalf("Marshal = %s, %v", data, err)
+	}
+	var got Rate
+	if err := json.Unmarshal(data, &got); err != nil || got != rate {
+		t.Errorf("Unmarshal(%s) = %d, %v; want %d", data, got, err, rate)
+	}
+	if err := json.Unmarshal([]byte(`4.2`), &got); err != nil || got.String() != "4.2" {
+		t.Errorf("Unmarshal(4.2) = %s, %v", got, err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package month provides YearMonth, the calendar month that budgets and
+// monthly_expenses.reference_month are keyed by.
+package month
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Layout is the textual form of a YearMonth, as accepted by ?month=
+const Layout = "2006-01"
+
+// YearMonth is a calendar month without a day, time of day or time zone
+type YearMonth struct {
+	Year  int
+	Month time.Month
+}
+
+// New returns the month, normalising out-of-range months (13 becomes
+// January of the next year, 0 December of the previous one)
+func New(year int, m time.Month) YearMonth {
+	return Of(time.Date(year, m, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// Of returns the month containing t, as seen in t's own location
+func Of(t time.Time) YearMonth {
+	return YearMonth{Year: t.Year(), Month: t.Month()}
+}
+
+// Current returns the current month in the server's local time zone
+func Current() YearMonth {
+	return Of(time.Now())
+}
+
+// Parse reads YYYY-MM. For compatibility with payloads that send a date,
+// YYYY-MM-DD is accepted too and its day is ignored.
+func Parse(s string) (YearMonth, error) {
+	if t, err := time.Parse(Layout, s); err == nil {
+		return Of(t), nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return Of(t), nil
+	}
+	return YearMonth{}, fmt.Errorf("invalid month %q, use YYYY-MM", s)
+}
+
+// IsZero reports whether ym is the zero value, i.e. no month was given
+func (ym YearMonth) IsZero() bool {
+	return ym == YearMonth{}
+}
+
+// Start returns midnight UTC of the first day of the month, the value stored
+// in DATE columns
+func (ym YearMonth) Start() time.Time {
+	return time.Date(ym.Year, ym.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// End returns the Start of the next month, so a month covers [Start, End)
+func (ym YearMonth) End() time.Time {
+	return ym.AddMonths(1).Start()
+}
+
+// AddMonths moves n months forward (or backward when n is negative)
+func (ym YearMonth) AddMonths(n int) YearMonth {
+	return New(ym.Year, ym.Month+time.Month(n))
+}
+
+// Next returns the following month
+func (ym YearMonth) Next() YearMonth {
+	return ym.AddMonths(1)
+}
+
+// Prev returns the preceding month
+func (ym YearMonth) Prev() YearMonth {
+	return ym.AddMonths(-1)
+}
+
+// Before reports whether ym comes strictly before other
+func (ym YearMonth) Before(other YearMonth) bool {
+	return ym.Year < other.Year || (ym.Year == other.Year && ym.Month < other.Month)
+}
+
+// After reports whether ym comes strictly after other
+func (ym YearMonth) After(other YearMonth) bool {
+	return other.Before(ym)
+}
+
+// Contains reports whether t, in its own location, falls within the month
+func (ym YearMonth) Contains(t time.Time) bool {
+	return Of(t) == ym
+}
+
+// String formats the month as YYYY-MM
+func (ym YearMonth) String() string {
+	return fmt.Sprintf("%04d-%02d", ym.Year, int(ym.Month))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (ym YearMonth) MarshalText() ([]byte, error) {
+	return []byte(ym.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (ym *YearMonth) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*ym = parsed
+	return nil
+}
+
+// MarshalJSON renders the zero month as null and any other as "YYYY-MM"
+func (ym YearMonth) MarshalJSON() ([]byte, error) {
+	if ym.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(ym.String())
+}
+
+// UnmarshalJSON accepts "YYYY-MM", "YYYY-MM-DD" or null
+func (ym *YearMonth) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*ym = YearMonth{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("month must be a YYYY-MM string: %w", err)
+	}
+	return ym.UnmarshalText([]byte(s))
+}
+
+// Value stores the month as the date of its first day. It is sent as text so
+// Postgres never shifts it through the session time zone.
+func (ym YearMonth) Value() (driver.Value, error) {
+	return ym.Start().Format("2006-01-02"), nil
+}
+
+// Scan reads DATE columns and their textual forms. The date is taken at face
+// value: a DATE has no time zone, so no conversion is applied.
+func (ym *YearMonth) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		*ym = Of(v)
+		return nil
+	case string:
+		return ym.UnmarshalText([]byte(v))
+	case []byte:
+		return ym.UnmarshalText(v)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into month.YearMonth")
+	default:
+		return fmt.Errorf("cannot scan %T into month.YearMonth", src)
+	}
+}
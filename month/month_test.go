@@ -0,0 +1,214 @@
+package month
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    YearMonth
+		wantErr bool
+	}{
+		{in: "2026-10", want: YearMonth{2026, time.October}},
+		{in: "2026-01", want: YearMonth{2026, time.January}},
+		{in: "2026-10-18", want: YearMonth{2026, time.October}},
+		{in: "2026-12-31", want: YearMonth{2026, time.December}},
+		{in: "", wantErr: true},
+		{in: "2026", wantErr: true},
+		{in: "2026-13", wantErr: true},
+		{in: "2026-00", wantErr: true},
+		{in: "2026-1", wantErr: true},
+		{in: "10/2026", wantErr: true},
+		{in: "2026-02-30", wantErr: true},
+		{in: "2026-10-18T00:00:00Z", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		from YearMonth
+		n    int
+		want YearMonth
+	}{
+		{YearMonth{2026, time.October}, 0, YearMonth{2026, time.October}},
+		{YearMonth{2026, time.October}, 2, YearMonth{2026, time.December}},
+		{YearMonth{2026, time.December}, 1, YearMonth{2027, time.January}},
+		{YearMonth{2026, time.November}, 14, YearMonth{2028, time.January}},
+		{YearMonth{2026, time.January}, -1, YearMonth{2025, time.December}},
+		{YearMonth{2026, time.October}, -12, YearMonth{2025, time.October}},
+		{YearMonth{2026, time.March}, -27, YearMonth{2023, time.December}},
+	}
+	for _, tt := range tests {
+		if got := tt.from.AddMonths(tt.n); got != tt.want {
+			t.Errorf("%v.AddMonths(%d) = %v, want %v", tt.from, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestNextPrev(t *testing.T) {
+	tests := []struct {
+		m, next, prev YearMonth
+	}{
+		{YearMonth{2026, time.October}, YearMonth{2026, time.November}, YearMonth{2026, time.September}},
+		{YearMonth{2026, time.December}, YearMonth{2027, time.January}, YearMonth{2026, time.November}},
+		{YearMonth{2026, time.January}, YearMonth{2026, time.February}, YearMonth{2025, time.December}},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Next(); got != tt.next {
+			t.Errorf("%v.Next() = %v, want %v", tt.m, got, tt.next)
+		}
+		if got := tt.m.Prev(); got != tt.prev {
+			t.Errorf("%v.Prev() = %v, want %v", tt.m, got, tt.prev)
+		}
+		if got := tt.m.Next().Prev(); got != tt.m {
+			t.Errorf("%v.Next().Prev() = %v", tt.m, got)
+		}
+	}
+}
+
+func TestOfUsesOwnLocation(t *testing.T) {
+	saoPaulo := time.FixedZone("BRT", -3*60*60)
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	tests := []struct {
+		t    time.Time
+		want YearMonth
+	}{
+		// 23:30 on New Year's Eve in São Paulo is already January in UTC
+		{time.Date(2026, time.December, 31, 23, 30, 0, 0, saoPaulo), YearMonth{2026, time.December}},
+		{time.Date(2026, time.December, 31, 23, 30, 0, 0, saoPaulo).UTC(), YearMonth{2027, time.January}},
+		// 00:30 on the 1st in Tokyo is still the previous month in UTC
+		{time.Date(2026, time.November, 1, 0, 30, 0, 0, tokyo), YearMonth{2026, time.November}},
+		{time.Date(2026, time.November, 1, 0, 30, 0, 0, tokyo).UTC(), YearMonth{2026, time.October}},
+	}
+	for _, tt := range tests {
+		if got := Of(tt.t); got != tt.want {
+			t.Errorf("Of(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+		if !tt.want.Contains(tt.t) {
+			t.Errorf("%v.Contains(%v) = false", tt.want, tt.t)
+		}
+	}
+}
+
+func TestStartEnd(t *testing.T) {
+	m := YearMonth{2026, time.December}
+	if got, want := m.Start(), time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Start() = %v, want %v", got, want)
+	}
+	if got, want := m.End(), time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("End() = %v, want %v", got, want)
+	}
+}
+
+func TestValueScanRoundTrip(t *testing.T) {
+	months := []YearMonth{
+		{2026, time.October},
+		{2026, time.December},
+		{1999, time.January},
+	}
+	for _, m := range months {
+		value, err := m.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := m.Start().Format("2006-01-02"); value != want {
+			t.Errorf("%v.Value() = %v, want %q", m, value, want)
+		}
+
+		sources := []any{value, []byte(value.(string)), m.Start()}
+		for _, src := range sources {
+			var got YearMonth
+			if err := got.Scan(src); err != nil {
+				t.Errorf("Scan(%#v): %v", src, err)
+				continue
+			}
+			if got != m {
+				t.Errorf("Scan(%#v) = %v, want %v", src, got, m)
+			}
+		}
+	}
+}
+
+func TestScanRejects(t *testing.T) {
+	for _, src := range []any{nil, 42, "October"} {
+		var m YearMonth
+		if err := m.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) = %v, want an error", src, m)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type body struct {
+		Month YearMonth `json:"month"`
+	}
+
+	tests := []struct {
+		in      string
+		want    YearMonth
+		out     string
+		wantErr bool
+	}{
+		{in: `{"month":"2026-10"}`, want: YearMonth{2026, time.October}, out: `{"month":"2026-10"}`},
+		{in: `{"month":"2026-10-18"}`, want: YearMonth{2026, time.October}, out: `{"month":"2026-10"}`},
+		{in: `{"month":null}`, out: `{"month":null}`},
+		{in: `{}`, out: `{"month":null}`},
+		{in: `{"month":""}`, wantErr: true},
+		{in: `{"month":202610}`, wantErr: true},
+		{in: `{"month":"2026-13"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var b body
+		err := json.Unmarshal([]byte(tt.in), &b)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if b.Month != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, b.Month, tt.want)
+		}
+		out, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tt.out {
+			t.Errorf("Marshal(%v) = %s, want %s", b.Month, out, tt.out)
+		}
+	}
+}
+
+func TestNullResetsMonth(t *testing.T) {
+	m := YearMonth{2026, time.October}
+	if err := json.Unmarshal([]byte("null"), &m); err != nil {
+		t.Fatal(err)
+	}
+	if !m.IsZero() {
+		t.Errorf("null left %v, want the zero month", m)
+	}
+}
+
+func TestNewNormalises(t *testing.T) {
+	if got, want := New(2026, 13), (YearMonth{2027, time.January}); got != want {
+		t.Errorf("New(2026, 13) = %v, want %v", got, want)
+	}
+	if got, want := New(2026, 0), (YearMonth{2025, time.December}); got != want {
+		t.Errorf("New(2026, 0) = %v, want %v", got, want)
+	}
+}
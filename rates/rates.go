@@ -0,0 +1,188 @@
+// Package rates reads exchange rate files, CSV or JSON, into rates a
+// workspace can import.
+//
+// CSV files have the columns date,from,to,rate, in that order or in the
+// order named by a header line. JSON files hold an array of objects with the
+// same fields, or an object whose "rates" field is that array. Dates are
+// YYYY-MM-DD and a rate says what one unit of from is worth in to.
+package rates
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/store"
+	"io"
+	"strings"
+	"time"
+)
+
+// MaxRates bounds the number of rates read from one file
+const MaxRates = 20000
+
+// Format is a rate file format
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+)
+
+// ParseFormat accepts "csv" and "json"
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case CSV, JSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected csv or json", s)
+}
+
+// columns is the order of the CSV columns when there is no header
+var columns = []string{"date", "from", "to", "rate"}
+
+var errTooManyRates = fmt.Errorf("a file may hold at most %d rates", MaxRates)
+
+// Parse reads every rate of a file. Unlike bank statements a rate file is
+// all or nothing: the first invalid rate fails it, naming its line.
+func Parse(format Format, r io.Reader) ([]store.ExchangeRate, error) {
+	switch format {
+	case CSV:
+		return parseCSV(r)
+	case JSON:
+		return parseJSON(r)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+func parseCSV(r io.Reader) ([]store.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	index := map[string]int{}
+	for i, name := range columns {
+		index[name] = i
+	}
+	rates := []store.ExchangeRate{}
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		if first && isHeader(record) {
+			index = map[string]int{}
+			for i, name := range record {
+				index[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+			for _, name := range columns {
+				if _, ok := index[name]; !ok {
+					return nil, fmt.Errorf("line %d: missing column %q", line, name)
+				}
+			}
+			continue
+		}
+
+		field := func(name string) string {
+			if i := index[name]; i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		rate, err := newRate(field("date"), field("from"), field("to"), field("rate"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(rates) == MaxRates {
+			return nil, errTooManyRates
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// isHeader tells a header line from a rate by its first field, which is a
+// date on every other line
+func isHeader(record []string) bool {
+	_, err := time.Parse(time.DateOnly, strings.TrimSpace(record[0]))
+	return err != nil
+}
+
+// jsonRate is a rate as written in JSON files; rate may be a string or a number
+type jsonRate struct {
+	Date string          `json:"date"`
+	From string          `json:"from"`
+	To   string          `json:"to"`
+	Rate json.RawMessage `json:"rate"`
+}
+
+func parseJSON(r io.Reader) ([]store.ExchangeRate, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []jsonRate
+	if data = bytes.TrimSpace(data); bytes.HasPrefix(data, []byte("{")) {
+		var wrapped struct {
+			Rates []jsonRate `json:"rates"`
+		}
+		err = json.Unmarshal(data, &wrapped)
+		list = wrapped.Rates
+	} else {
+		err = json.Unmarshal(data, &list)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(list) > MaxRates {
+		return nil, errTooManyRates
+	}
+
+	rates := make([]store.ExchangeRate, 0, len(list))
+	for i, item := range list {
+		value := strings.Trim(string(item.Rate), `"`)
+		rate, err := newRate(item.Date, item.From, item.To, value)
+		if err != nil {
+			return nil, fmt.Errorf("rate %d: %w", i+1, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// newRate validates the fields of one rate
+func newRate(date, from, to, value string) (store.ExchangeRate, error) {
+	var rate store.ExchangeRate
+	day, err := time.Parse(time.DateOnly, strings.TrimSpace(date))
+	if err != nil {
+		return rate, errors.New("date: expected YYYY-MM-DD")
+	}
+	rate.Date = day.Format(time.DateOnly)
+
+	if rate.From, err = money.ParseCurrency(strings.ToUpper(strings.TrimSpace(from))); err != nil {
+		return rate, fmt.Errorf("from: %w", err)
+	}
+	if rate.To, err = money.ParseCurrency(strings.ToUpper(strings.TrimSpace(to))); err != nil {
+		return rate, fmt.Errorf("to: %w", err)
+	}
+	if rate.From == rate.To {
+		return rate, errors.New("from and to must be different currencies")
+	}
+
+	if rate.Rate, err = money.ParseRate(strings.TrimSpace(value)); err != nil {
+		return rate, fmt.Errorf("rate: %w", err)
+	}
+	return rate, nil
+}
@@ -0,0 +1,238 @@
+// Package receipts attaches uploaded receipts to expenses.
+//
+// Files are kept in a blob.Store under "<workspace>/<expense>/<uuid>.<ext>"
+// and only PDF, JPEG and PNG are accepted, recognised by their content
+// rather than by the name or type the client sent. Expenses expose their
+// receipt as a signed download link in File; the link expires after the
+// configured TTL and stops working as soon as the receipt is replaced.
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-sheet/blob"
+	"go-sheet/store"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnsupportedType = errors.New("receipt must be a PDF, JPEG or PNG file")
+	ErrTooLarge        = errors.New("receipt is too large")
+	ErrNoReceipt       = errors.New("expense has no receipt")
+	// ErrInvalidLink covers download links that are malformed, forged,
+	// expired or point to a receipt that has since been replaced
+	ErrInvalidLink = errors.New("download link is invalid or expired")
+)
+
+// extensions maps the accepted content types to the extension of their key
+var extensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// Store is what the Service needs from the database
+type Store interface {
+	GetExpense(ctx context.Context, workspaceID, id string) (store.Expense, error)
+	store.ReceiptStore
+}
+
+// Service uploads, serves and links receipts
+type Service struct {
+	blobs     blob.Store
+	store     Store
+	maxBytes  int64
+	key       []byte
+	ttl       time.Duration
+	publicURL string
+	now       func() time.Time
+}
+
+// NewService returns a Service whose download links are signed with key,
+// last for ttl and point below publicURL. Without a key a random one is
+// used, so links do not survive a restart.
+func NewService(blobs blob.Store, s Store, key []byte, ttl time.Duration, maxBytes int64, publicURL string) *Service {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(err)
+		}
+	}
+
+	return &Service{
+		blobs:     blobs,
+		store:     s,
+		maxBytes:  maxBytes,
+		key:       key,
+		ttl:       ttl,
+		publicURL: publicURL,
+		now:       time.Now,
+	}
+}
+
+// MaxBytes is the size limit of a receipt
+func (s *Service) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// Attach stores size bytes read from r as the receipt of an expense,
+// replacing the previous one, and returns the expense with its new link
+func (s *Service) Attach(ctx context.Context, workspaceID, expenseID string, r io.Reader, size int64) (store.Expense, error) {
+	if size > s.maxBytes {
+		return store.Expense{}, ErrTooLarge
+	}
+
+	// http.DetectContentType nunca olha além dos primeiros 512 bytes
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return store.Expense{}, err
+	}
+	head = head[:n]
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	ext, ok := extensions[contentType]
+	if !ok {
+		return store.Expense{}, ErrUnsupportedType
+	}
+
+	// Confere a despesa antes de gravar, para não deixar arquivos órfãos
+	if _, err := s.store.GetExpense(ctx, workspaceID, expenseID); err != nil {
+		return store.Expense{}, err
+	}
+
+	key := workspaceID + "/" + expenseID + "/" + uuid.NewString() + ext
+	if err := s.blobs.Put(ctx, key, io.MultiReader(bytes.NewReader(head), r), size, contentType); err != nil {
+		return store.Expense{}, fmt.Errorf("storing receipt: %w", err)
+	}
+
+	previous, err := s.store.SetReceipt(ctx, workspaceID, expenseID, key)
+	if err != nil {
+		s.Forget(ctx, key)
+		return store.Expense{}, err
+	}
+	s.Forget(ctx, previous)
+
+	expense, err := s.store.GetExpense(ctx, workspaceID, expenseID)
+	if err != nil {
+		return store.Expense{}, err
+	}
+	s.Link(workspaceID, &expense)
+
+	return expense, nil
+}
+
+// Open returns the receipt of an expense; the caller closes the reader
+func (s *Service) Open(ctx context.Context, workspaceID, expenseID string) (io.ReadCloser, blob.Info, error) {
+	expense, err := s.store.GetExpense(ctx, workspaceID, expenseID)
+	if err != nil {
+		return nil, blob.Info{}, err
+	}
+	return s.open(ctx, expense.ReceiptKey)
+}
+
+// OpenLink returns the receipt a download link points to, after checking
+// its signature and expiry
+func (s *Service) OpenLink(ctx context.Context, workspaceID, expenseID, expires, signature string) (io.ReadCloser, blob.Info, error) {
+	expense, err := s.store.GetExpense(ctx, workspaceID, expenseID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, blob.Info{}, ErrInvalidLink
+	} else if err != nil {
+		return nil, blob.Info{}, err
+	}
+
+	if expense.ReceiptKey == "" || !s.valid(workspaceID, expenseID, expense.ReceiptKey, expires, signature) {
+		return nil, blob.Info{}, ErrInvalidLink
+	}
+	return s.open(ctx, expense.ReceiptKey)
+}
+
+func (s *Service) open(ctx context.Context, key string) (io.ReadCloser, blob.Info, error) {
+	if key == "" {
+		return nil, blob.Info{}, ErrNoReceipt
+	}
+
+	reader, info, err := s.blobs.Get(ctx, key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, blob.Info{}, ErrNoReceipt
+	}
+	return reader, info, err
+}
+
+// Detach removes the receipt of an expense
+func (s *Service) Detach(ctx context.Context, workspaceID, expenseID string) error {
+	previous, err := s.store.SetReceipt(ctx, workspaceID, expenseID, "")
+	if err != nil {
+		return err
+	}
+	if previous == "" {
+		return ErrNoReceipt
+	}
+
+	s.Forget(ctx, previous)
+	return nil
+}
+
+// Forget deletes a receipt blob that no expense points to anymore. Failures
+// are only logged: a leftover file is harmless.
+func (s *Service) Forget(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		log.Printf("deleting receipt %s: %v", key, err)
+	}
+}
+
+// FileName is the name offered to browsers when downloading a receipt
+func FileName(expenseID string, info blob.Info) string {
+	contentType, _, _ := mime.ParseMediaType(info.ContentType)
+	return "receipt-" + expenseID + extensions[contentType]
+}
+
+// Link points expense.File at a signed download link when the expense has
+// a receipt; expenses without one keep the File the client set
+func (s *Service) Link(workspaceID string, expense *store.Expense) {
+	if expense.ReceiptKey == "" {
+		return
+	}
+
+	expires := strconv.FormatInt(s.now().Add(s.ttl).Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.sign(workspaceID, expense.ExpenseID, expense.ReceiptKey, expires)},
+	}
+	link := s.publicURL + "/api/v1/receipts/" + workspaceID + "/" + expense.ExpenseID + "?" + query.Encode()
+	expense.File = &link
+}
+
+// sign covers the receipt key too, so replacing or removing the receipt
+// revokes the links handed out for it
+func (s *Service) sign(workspaceID, expenseID, key, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(workspaceID + "\n" + expenseID + "\n" + key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Service) valid(workspaceID, expenseID, key, expires, signature string) bool {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > unix {
+		return false
+	}
+
+	expected := s.sign(workspaceID, expenseID, key, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
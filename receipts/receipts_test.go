@@ -0,0 +1,224 @@
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go-sheet/blob"
+	"go-sheet/store"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	workspace = "ws-1"
+	expenseID = "expense-1"
+)
+
+var (
+	pngFile = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR receipt")
+	pdfFile = []byte("%PDF-1.4\n% receipt")
+)
+
+// fakeStore holds the receipt keys of the expenses it knows
+type fakeStore struct {
+	receipts map[string]string
+}
+
+func (f *fakeStore) GetExpense(ctx context.Context, workspaceID, id string) (store.Expense, error) {
+	key, ok := f.receipts[workspaceID+"/"+id]
+	if !ok {
+		return store.Expense{}, store.ErrNotFound
+	}
+	return store.Expense{ExpenseID: id, ReceiptKey: key}, nil
+}
+
+func (f *fakeStore) SetReceipt(ctx context.Context, workspaceID, expenseID, key string) (string, error) {
+	previous, ok := f.receipts[workspaceID+"/"+expenseID]
+	if !ok {
+		return "", store.ErrNotFound
+	}
+	f.receipts[workspaceID+"/"+expenseID] = key
+	return previous, nil
+}
+
+type fixture struct {
+	service *Service
+	store   *fakeStore
+	blobs   *blob.Local
+	now     time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{
+		store: &fakeStore{receipts: map[string]string{workspace + "/" + expenseID: "", workspace + "/other": ""}},
+		blobs: blob.NewLocal(t.TempDir()),
+		now:   time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC),
+	}
+	f.service = NewService(f.blobs, f.store, []byte("test-signing-key"), time.Hour, 1024, "https://api.example.com")
+	f.service.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *fixture) attach(t *testing.T, content []byte) store.Expense {
+	t.Helper()
+	expense, err := f.service.Attach(context.Background(), workspace, expenseID, bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	return expense
+}
+
+// linkQuery returns the expires and signature of the expense's download link
+func linkQuery(t *testing.T, expense store.Expense) (string, string) {
+	t.Helper()
+	if expense.File == nil {
+		t.Fatal("expense has no download link")
+	}
+	link, err := url.Parse(*expense.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/api/v1/receipts/" + workspace + "/" + expenseID; link.Host != "api.example.com" || link.Path != want {
+		t.Errorf("link = %s, want it on api.example.com%s", link, want)
+	}
+	return link.Query().Get("expires"), link.Query().Get("signature")
+}
+
+func TestAttach(t *testing.T) {
+	f := newFixture(t)
+
+	expense := f.attach(t, pngFile)
+	first := expense.ReceiptKey
+	if !strings.HasPrefix(first, workspace+"/"+expenseID+"/") || !strings.HasSuffix(first, ".png") {
+		t.Errorf("receipt key = %q", first)
+	}
+
+	r, info, err := f.service.Open(context.Background(), workspace, expenseID)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	body, _ := io.ReadAll(r)
+	r.Close()
+	if !bytes.Equal(body, pngFile) || info.ContentType != "image/png" {
+		t.Errorf("Open = %q, %+v; want the whole PNG", body, info)
+	}
+	if name := FileName(expenseID, info); name != "receipt-"+expenseID+".png" {
+		t.Errorf("FileName = %q", name)
+	}
+
+	// Replacing the receipt deletes the previous blob
+	if second := f.attach(t, pdfFile).ReceiptKey; !strings.HasSuffix(second, ".pdf") {
+		t.Errorf("receipt key = %q, want a PDF", second)
+	}
+	if _, _, err := f.blobs.Get(context.Background(), first); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("previous receipt: err = %v, want it deleted", err)
+	}
+
+	if _, err := f.service.Attach(context.Background(), workspace, "missing", bytes.NewReader(pngFile), int64(len(pngFile))); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Attach to a missing expense: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAttachRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		size    int64
+		want    error
+	}{
+		{"plain text", []byte("just some text"), 14, ErrUnsupportedType},
+		{"html named like a pdf", []byte("<html><body>%PDF-1.4</body></html>"), 34, ErrUnsupportedType},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), 10, ErrUnsupportedType},
+		{"empty", nil, 0, ErrUnsupportedType},
+		{"too large", pngFile, 1025, ErrTooLarge},
+		{"too large and not an image", bytes.Repeat([]byte("x"), 2048), 2048, ErrTooLarge},
+	}
+	for _, tt := range tests {
+		f := newFixture(t)
+		_, err := f.service.Attach(context.Background(), workspace, expenseID, bytes.NewReader(tt.content), tt.size)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if key := f.store.receipts[workspace+"/"+expenseID]; key != "" {
+			t.Errorf("%s: receipt %q was recorded", tt.name, key)
+		}
+	}
+}
+
+func TestOpenLink(t *testing.T) {
+	f := newFixture(t)
+	expense := f.attach(t, pdfFile)
+	expires, signature := linkQuery(t, expense)
+	if want := strconv.FormatInt(f.now.Add(time.Hour).Unix(), 10); expires != want {
+		t.Errorf("expires = %s, want %s", expires, want)
+	}
+
+	r, info, err := f.service.OpenLink(context.Background(), workspace, expenseID, expires, signature)
+	if err != nil {
+		t.Fatalf("OpenLink: %v", err)
+	}
+	r.Close()
+	if info.ContentType != "application/pdf" {
+		t.Errorf("content type = %q", info.ContentType)
+	}
+
+	tampered := []byte(signature)
+	tampered[0] ^= 1
+	tests := []struct {
+		name               string
+		workspace, expense string
+		expires, signature string
+	}{
+		{"tampered signature", workspace, expenseID, expires, string(tampered)},
+		{"missing signature", workspace, expenseID, expires, ""},
+		{"extended expiry", workspace, expenseID, strconv.FormatInt(f.now.Add(48*time.Hour).Unix(), 10), signature},
+		{"malformed expiry", workspace, expenseID, "tomorrow", signature},
+		{"other expense", workspace, "other", expires, signature},
+		{"other workspace", "ws-2", expenseID, expires, signature},
+	}
+	for _, tt := range tests {
+		if _, _, err := f.service.OpenLink(context.Background(), tt.workspace, tt.expense, tt.expires, tt.signature); !errors.Is(err, ErrInvalidLink) {
+			t.Errorf("%s: err = %v, want ErrInvalidLink", tt.name, err)
+		}
+	}
+}
+
+func TestOpenLinkExpires(t *testing.T) {
+	f := newFixture(t)
+	expires, signature := linkQuery(t, f.attach(t, pngFile))
+
+	f.now = f.now.Add(time.Hour)
+	if r, _, err := f.service.OpenLink(context.Background(), workspace, expenseID, expires, signature); err != nil {
+		t.Fatalf("OpenLink at the expiry: %v", err)
+	} else {
+		r.Close()
+	}
+
+	f.now = f.now.Add(time.Second)
+	if _, _, err := f.service.OpenLink(context.Background(), workspace, expenseID, expires, signature); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("OpenLink after the expiry: err = %v, want ErrInvalidLink", err)
+	}
+}
+
+func TestOpenLinkRevokedByReplacing(t *testing.T) {
+	f := newFixture(t)
+	expires, signature := linkQuery(t, f.attach(t, pngFile))
+
+	f.attach(t, pdfFile)
+	if _, _, err := f.service.OpenLink(context.Background(), workspace, expenseID, expires, signature); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("link to a replaced receipt: err = %v, want ErrInvalidLink", err)
+	}
+
+	expires, signature = linkQuery(t, f.attach(t, pdfFile))
+	if err := f.service.Detach(context.Background(), workspace, expenseID); err != nil {
+		t.Fatalf("Detach: %v", err)
+	}
+	if _, _, err := f.service.OpenLink(context.Background(), workspace, expenseID, expires, signature); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("link to a removed receipt: err = %v, want ErrInvalidLink", err)
+	}
+}
@@ -0,0 +1,236 @@
+// Package recurring expands the schedules of recurring expenses into dates
+// and materialises the due ones as pending monthly_expenses rows, either on
+// demand (the recurring command) or periodically from the API process.
+//
+// An occurrence is due as soon as its month starts, so the expenses of the
+// current month are visible, still pending, before they are paid. Each
+// recurring expense remembers the last date materialised, which makes runs
+// idempotent and keeps later edits and exceptions from rewriting the past.
+package recurring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-sheet/alerts"
+	"go-sheet/month"
+	"go-sheet/store"
+	"log"
+	"time"
+)
+
+// Validate checks the schedule and amount of a recurring expense
+func Validate(r store.RecurringExpense) error {
+	var errs []error
+	if r.CategoryID == "" {
+		errs = append(errs, errors.New("categoryId is required"))
+	}
+	if r.Amount < 0 {
+		errs = append(errs, errors.New("amount must not be negative"))
+	}
+	if !r.Frequency.Valid() {
+		errs = append(errs, fmt.Errorf("frequency must be %s, %s or %s", store.Weekly, store.Monthly, store.Yearly))
+	}
+	if r.Every < 1 {
+		errs = append(errs, errors.New("every must be at least 1"))
+	}
+	if r.DayOfMonth != nil {
+		if r.Frequency == store.Weekly {
+			errs = append(errs, errors.New("dayOfMonth only applies to monthly and yearly schedules"))
+		} else if *r.DayOfMonth < 1 || *r.DayOfMonth > 31 {
+			errs = append(errs, errors.New("dayOfMonth must be between 1 and 31"))
+		}
+	}
+	if r.StartDate.IsZero() {
+		errs = append(errs, errors.New("startDate is required"))
+	}
+	if r.EndDate != nil && r.EndDate.Before(r.StartDate) {
+		errs = append(errs, errors.New("endDate must not be before startDate"))
+	}
+	if r.Occurrences != nil && *r.Occurrences < 1 {
+		errs = append(errs, errors.New("occurrences must be at least 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// Dates returns the occurrences of r between from and to, both inclusive
+func Dates(r store.RecurringExpense, from, to time.Time) []time.Time {
+	dates := []time.Time{}
+	if r.Every < 1 || !r.Frequency.Valid() {
+		return dates
+	}
+
+	counted := 0
+	for n := 0; ; n++ {
+		date := nth(r, n)
+		// Um dia fixo anterior ao início cai antes de startDate no primeiro mês
+		if date.Before(r.StartDate) {
+			continue
+		}
+		if date.After(to) || r.EndDate != nil && date.After(*r.EndDate) {
+			return dates
+		}
+		if counted++; r.Occurrences != nil && counted > *r.Occurrences {
+			return dates
+		}
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+}
+
+// nth returns the n-th date of the schedule, counting from StartDate
+func nth(r store.RecurringExpense, n int) time.Time {
+	start := r.StartDate
+	if r.Frequency == store.Weekly {
+		return start.AddDate(0, 0, 7*r.Every*n)
+	}
+
+	day := start.Day()
+	if r.DayOfMonth != nil {
+		day = *r.DayOfMonth
+	}
+	m := month.Of(start).AddMonths(r.Every * n)
+	if r.Frequency == store.Yearly {
+		m = month.Of(start).AddMonths(12 * r.Every * n)
+	}
+	last := m.End().AddDate(0, 0, -1).Day()
+	return time.Date(m.Year, m.Month, min(day, last), 0, 0, 0, 0, time.UTC)
+}
+
+// IsOccurrence reports whether the schedule of r falls on date
+func IsOccurrence(r store.RecurringExpense, date time.Time) bool {
+	return len(Dates(r, date, date)) == 1
+}
+
+// Exception returns the exception of date, if any
+func Exception(r store.RecurringExpense, date time.Time) (store.OccurrenceException, bool) {
+	for _, e := range r.Exceptions {
+		if e.Date.Equal(date) {
+			return e, true
+		}
+	}
+	return store.OccurrenceException{}, false
+}
+
+// Resolve applies the exception of date, if any, to the values of r.
+// skipped is true when the occurrence must not become an expense.
+func Resolve(r store.RecurringExpense, date time.Time) (occurrence store.Occurrence, skipped bool) {
+	occurrence = store.Occurrence{Date: date, Amount: r.Amount, Currency: r.Currency, Description: r.Description, PaidID: r.PaidID}
+
+	e, ok := Exception(r, date)
+	if !ok {
+		return occurrence, false
+	}
+	if e.Amount != nil {
+		occurrence.Amount = *e.Amount
+	}
+	if e.Description != nil {
+		occurrence.Description = *e.Description
+	}
+	if e.PaidID != nil {
+		occurrence.PaidID = e.PaidID
+	}
+	return occurrence, e.Skip
+}
+
+// Materialized reports whether date was already copied to monthly_expenses
+func Materialized(r store.RecurringExpense, date time.Time) bool {
+	return r.MaterializedThrough != nil && !date.After(*r.MaterializedThrough)
+}
+
+// Horizon returns the last date due at t: the last day of its month
+func Horizon(t time.Time) time.Time {
+	return month.Of(t).End().AddDate(0, 0, -1)
+}
+
+// Runner materialises due occurrences through a store.RecurringStore
+type Runner struct {
+	store  store.RecurringStore
+	alerts *alerts.Service
+	now    func() time.Time
+}
+
+// Result reports what one run created
+type Result struct {
+	Through   time.Time
+	Recurring int
+	Created   int
+}
+
+// NewRunner returns a Runner backed by the given store. as may be nil to
+// skip budget alerts, as the command line does.
+func NewRunner(s store.RecurringStore, as *alerts.Service) *Runner {
+	return &Runner{store: s, alerts: as, now: time.Now}
+}
+
+// Materialize creates the pending expenses of r up to through and returns
+// their ids. Skipped occurrences only move the watermark.
+func (r *Runner) Materialize(ctx context.Context, rec store.RecurringExpense, through time.Time) ([]string, error) {
+	from := rec.StartDate
+	if rec.MaterializedThrough != nil {
+		from = rec.MaterializedThrough.AddDate(0, 0, 1)
+	}
+
+	occurrences := []store.Occurrence{}
+	for _, date := range Dates(rec, from, through) {
+		if occurrence, skipped := Resolve(rec, date); !skipped {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+
+	ids, err := r.store.MaterializeRecurring(ctx, rec, occurrences, through)
+	if err != nil {
+		return nil, fmt.Errorf("recurring %s: %w", rec.ID, err)
+	}
+	if len(ids) > 0 && r.alerts != nil {
+		r.alerts.Check(ctx, rec.WorkspaceID, ids...)
+	}
+	return ids, nil
+}
+
+// Run materialises every recurring expense due through the given date. A
+// failing recurring expense does not stop the others.
+func (r *Runner) Run(ctx context.Context, through time.Time) (Result, error) {
+	result := Result{Through: through}
+	due, err := r.store.DueRecurring(ctx, through)
+	if err != nil {
+		return result, fmt.Errorf("recurring: %w", err)
+	}
+
+	var errs []error
+	for _, rec := range due {
+		ids, err := r.Materialize(ctx, rec, through)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Recurring++
+		result.Created += len(ids)
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// Start materialises the current month immediately and then every interval
+// until ctx is cancelled
+func (r *Runner) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := r.Run(ctx, Horizon(r.now()))
+		if err != nil {
+			log.Printf("recurring: %v", err)
+		}
+		if result.Created > 0 {
+			log.Printf("recurring: created %d pending expense(s) through %s", result.Created, result.Through.Format(time.DateOnly))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package recurring
+
+import (
+	"context"
+	"go-sheet/store"
+	"slices"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func dates(list ...string) []time.Time {
+	out := []time.Time{}
+	for _, s := range list {
+		out = append(out, date(s))
+	}
+	return out
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestDates(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        store.RecurringExpense
+		from, to string
+		want     []time.Time
+	}{
+		{
+			name: "monthly on the 31st moves back in shorter months",
+			r:    store.RecurringExpense{Frequency: store.Monthly, Every: 1, StartDate: date("2025-01-31")},
+			from: "2025-01-01", to: "2025-04-30",
+			want: dates("2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30"),
+		},
+		{
+			name: "dayOfMonth 31 in a leap year",
+			r:    store.RecurringExpense{Frequency: store.Monthly, Every: 1, DayOfMonth: ptr(31), StartDate: date("2024-01-15")},
+			from: "2024-01-01", to: "2024-03-31",
+			want: dates("2024-01-31", "2024-02-29", "2024-03-31"),
+		},
+		{
+			name: "dayOfMonth before the start day begins next month",
+			r:    store.RecurringExpense{Frequency: store.Monthly, Every: 1, DayOfMonth: ptr(5), StartDate: date("2025-01-15")},
+			from: "2025-01-01", to: "2025-03-31",
+			want: dates("2025-02-05", "2025-03-05"),
+		},
+		{
+			name: "every two months",
+			r:    store.RecurringExpense{Frequency: store.Monthly, Every: 2, StartDate: date("2025-01-10")},
+			from: "2025-01-01", to: "2025-06-30",
+			want: dates("2025-01-10", "2025-03-10", "2025-05-10"),
+		},
+		{
+			name: "every two weeks",
+			r:    store.RecurringExpense{Frequency: store.Weekly, Every: 2, StartDate: date("2025-03-03")},
+			from: "2025-03-01", to: "2025-03-31",
+			want: dates("2025-03-03", "2025-03-17", "2025-03-31"),
+		},
+		{
+			name: "yearly on the 29th of February",
+			r:    store.RecurringExpense{Frequency: store.Yearly, Every: 1, StartDate: date("2024-02-29")},
+			from: "2025-01-01", to: "2028-12-31",
+			want: dates("2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"),
+		},
+		{
+			name: "occurrences count from the start, not from",
+			r:    store.RecurringExpense{Frequency: store.Monthly, Every: 1, StartDate: date("2025-01-10"), Occurrences: ptr(3)},
+			from: "2025-03-01", to: "2025-12-31",
+			want: dates("2025-03-10"),
+		},
+		{
+			name: "occurrences skip the days before the start",
+			r:    store.RecurringExpense{Frequency: store.Monthly, Every: 1, DayOfMonth: ptr(5), StartDate: date("2025-01-15"), Occurrences: ptr(2)},
+			from: "2025-01-01", to: "2025-12-31",
+			want: dates("2025-02-05", "2025-03-05"),
+		},
+		{
+			name: "endDate is inclusive",
+			r:    store.RecurringExpense{Frequency: store.Weekly, Every: 1, StartDate: date("2025-03-03"), EndDate: ptr(date("2025-03-17"))},
+			from: "2025-01-01", to: "2025-12-31",
+			want: dates("2025-03-03", "2025-03-10", "2025-03-17"),
+		},
+		{
+			name: "window before the start",
+			r:    store.RecurringExpense{Frequency: store.Monthly, Every: 1, StartDate: date("2025-05-01")},
+			from: "2025-01-01", to: "2025-04-30",
+			want: dates(),
+		},
+		{
+			name: "invalid schedule",
+			r:    store.RecurringExpense{Frequency: store.Monthly, Every: 0, StartDate: date("2025-01-01")},
+			from: "2025-01-01", to: "2025-12-31",
+			want: dates(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Dates(tt.r, date(tt.from), date(tt.to))
+			if !slices.EqualFunc(got, tt.want, time.Time.Equal) {
+				t.Errorf("Dates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeStore keeps the watermark like the real stores but, unlike them,
+// creates every occurrence it is given, so a run that asks twice for the
+// same date shows up as a repeated expense
+type fakeStore struct {
+	store.RecurringStore
+	recurring store.RecurringExpense
+	created   []time.Time
+}
+
+func (f *fakeStore) DueRecurring(ctx context.Context, through time.Time) ([]store.RecurringExpense, error) {
+	if m := f.recurring.MaterializedThrough; m != nil && !m.Before(through) {
+		return nil, nil
+	}
+	return []store.RecurringExpense{f.recurring}, nil
+}
+
+func (f *fakeStore) MaterializeRecurring(ctx context.Context, r store.RecurringExpense, occurrences []store.Occurrence, through time.Time) ([]string, error) {
+	ids := []string{}
+	for _, occurrence := range occurrences {
+		f.created = append(f.created, occurrence.Date)
+		ids = append(ids, occurrence.Date.Format(time.DateOnly))
+	}
+	f.recurring.MaterializedThrough = &through
+	return ids, nil
+}
+
+func TestRunMaterializesEachDateOnce(t *testing.T) {
+	fake := &fakeStore{recurring: store.RecurringExpense{
+		ID:        "rent",
+		Frequency: store.Weekly,
+		Every:     1,
+		StartDate: date("2025-03-03"),
+		Exceptions: []store.OccurrenceException{
+			{Date: date("2025-03-17"), Skip: true},
+		},
+	}}
+	runner := NewRunner(fake, nil)
+	ctx := context.Background()
+
+	steps := []struct {
+		through string
+		created int
+	}{
+		{"2025-03-10", 2},
+		// Same horizon again: nothing is due
+		{"2025-03-10", 0},
+		// The skipped 17th only moves the watermark
+		{"2025-03-20", 0},
+		{"2025-03-31", 2},
+		// An earlier horizon finds nothing left to create
+		{"2025-03-15", 0},
+		{"2025-03-31", 0},
+	}
+	for _, step := range steps {
+		result, err := runner.Run(ctx, date(step.through))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Created != step.created {
+			t.Errorf("through %s: created %d, want %d", step.through, result.Created, step.created)
+		}
+	}
+
+	want := dates("2025-03-03", "2025-03-10", "2025-03-24", "2025-03-31")
+	if !slices.EqualFunc(fake.created, want, time.Time.Equal) {
+		t.Errorf("created %v, want %v", fake.created, want)
+	}
+	if !Materialized(fake.recurring, date("2025-03-31")) || Materialized(fake.recurring, date("2025-04-01")) {
+		t.Errorf("MaterializedThrough = %v, want 2025-03-31", fake.recurring.MaterializedThrough)
+	}
+}
+
+func TestHorizon(t *testing.T) {
+	if got := Horizon(time.Date(2024, time.February, 10, 15, 0, 0, 0, time.UTC)); !got.Equal(date("2024-02-29")) {
+		t.Errorf("Horizon = %v, want 2024-02-29", got)
+	}
+}
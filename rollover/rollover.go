@@ -0,0 +1,81 @@
+// Package rollover creates the planned monthly_expenses rows of each month,
+// either on demand (the rollover command) or periodically from the API
+// process.
+package rollover
+
+import (
+	"context"
+	"fmt"
+	"go-sheet/month"
+	"go-sheet/store"
+	"log"
+	"time"
+)
+
+// Runner creates planned rows through a store.RolloverStore
+type Runner struct {
+	store store.RolloverStore
+	now   func() time.Time
+}
+
+// Result reports how many planned rows a month received
+type Result struct {
+	Month   month.YearMonth
+	Created int
+}
+
+// NewRunner returns a Runner backed by the given store
+func NewRunner(s store.RolloverStore) *Runner {
+	return &Runner{store: s, now: time.Now}
+}
+
+// Run creates the planned rows of month m
+func (r *Runner) Run(ctx context.Context, m month.YearMonth) (Result, error) {
+	created, err := r.store.EnsurePlannedExpenses(ctx, m)
+	if err != nil {
+		return Result{Month: m}, fmt.Errorf("rollover %s: %w", m, err)
+	}
+
+	return Result{Month: m, Created: created}, nil
+}
+
+// Backfill runs every month from from to to, both inclusive
+func (r *Runner) Backfill(ctx context.Context, from, to month.YearMonth) ([]Result, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("backfill range ends (%s) before it starts (%s)", to, from)
+	}
+
+	var results []Result
+	for m := from; !m.After(to); m = m.Next() {
+		result, err := r.Run(ctx, m)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Start runs the current month immediately and then every interval until
+// ctx is cancelled. Because the rollover is idempotent, the interval only
+// bounds how late after a month boundary the new rows appear.
+func (r *Runner) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := r.Run(ctx, month.Of(r.now()))
+		if err != nil {
+			log.Printf("rollover: %v", err)
+		} else if result.Created > 0 {
+			log.Printf("rollover: created %d planned expense(s) for %s", result.Created, result.Month)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
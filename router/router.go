@@ -1,22 +1,38 @@
 package routes
 
 import (
+	"go-sheet/alerts"
+	"go-sheet/auth"
+	"go-sheet/config"
+	"go-sheet/receipts"
+	"go-sheet/store"
+	"go-sheet/workspace"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-func Initialize(r *gin.Engine) {
+// Dependencies is everything the routes need to serve requests
+type Dependencies struct {
+	Store    store.Store
+	Verifier auth.Verifier
+	Config   config.Config
+	// Receipts handles receipt uploads; nil disables them
+	Receipts *receipts.Service
+	// Dispatcher sends budget alerts; with nil they are only recorded
+	Dispatcher alerts.Dispatcher
+}
 
-	server := gin.Default()
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:3000"} // Substitua pela URL do seu frontend
-	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.AllowCredentials = true
+func Initialize(server *gin.Engine, deps Dependencies) error {
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = deps.Config.HTTP.AllowedOrigins
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", workspace.Header}
+	corsConfig.AllowCredentials = true
 
-	server.Use(cors.New(config))
+	server.Use(cors.New(corsConfig))
 
-	InitializeRoutes(server)
+	InitializeRoutes(server, deps)
 
-	server.Run(":8080")
+	return server.Run(deps.Config.HTTP.Addr)
 }
@@ -1,41 +1,171 @@
 package routes
 
 import (
+	"go-sheet/alerts"
+	"go-sheet/auth"
+	handlersAlerts "go-sheet/handlers/alerts"
 	handlersAnalytic "go-sheet/handlers/analytic"
 	handlersCategories "go-sheet/handlers/categories"
 	handlersExpenses "go-sheet/handlers/expenses"
+	handlersExport "go-sheet/handlers/export"
+	handlersImports "go-sheet/handlers/imports"
+	handlersIncomes "go-sheet/handlers/incomes"
 	handlersPaidType "go-sheet/handlers/paid_type"
+	handlersRates "go-sheet/handlers/rates"
+	handlersReceipts "go-sheet/handlers/receipts"
+	handlersRecurring "go-sheet/handlers/recurring"
+	handlersRules "go-sheet/handlers/rules"
 	handlersStatus "go-sheet/handlers/status"
+	handlersWorkspaces "go-sheet/handlers/workspaces"
+	"go-sheet/importer"
+	"go-sheet/recurring"
+	"go-sheet/store"
+	"go-sheet/workspace"
 
 	"github.com/gin-gonic/gin"
 )
 
-func InitializeRoutes(router *gin.Engine) {
-	v1 := router.Group("/api/v1")
+func InitializeRoutes(router *gin.Engine, deps Dependencies) {
+	st := deps.Store
+	workspaces := handlersWorkspaces.NewHandler(st)
+	rates := handlersRates.NewHandler(st)
+
+	v1 := router.Group("/api/v1", auth.Middleware(deps.Verifier))
 	{
-		v1.GET("/expenses", handlersExpenses.ListMonthlyExpenses)
-		v1.POST("/expenses", handlersExpenses.CreateExpense)
-		// v1.GET("/expenses/:id", handlersExpenses.ShowExpense)
-		// v1.PUT("/expenses/:id", handlersExpenses.UpdateExpense)
-		// v1.DELETE("/expenses/:id", handlersExpenses.DeleteExpense)
-
-		// Categories
-		v1.GET("/categories", handlersCategories.GetCategories)
-		v1.POST("/categories", handlersCategories.CreateCategory)
-		v1.DELETE("/categories/:id", handlersCategories.DeleteCategory)
-		v1.PUT("/categories/:id", handlersCategories.UpdateCategory)
-		// Paid Types
-		v1.GET("/paid-types", handlersPaidType.ListPaidTypes)
-		v1.POST("/paid-types", handlersPaidType.CreatePaidType)
-
-		// Status
-		v1.GET("/status", handlersStatus.ListStatus)
-		v1.POST("/status", handlersStatus.CreateStatus)
-		v1.DELETE("/status/:id", handlersStatus.DeleteStatus)
-
-		// Analytic
-		v1.GET("/dashboard/analytic/total", handlersAnalytic.GetAnalyticTotal)
-		v1.GET("/dashboard/analytic/pending-payments", handlersAnalytic.GetPendingPayment)
+		// Workspaces the caller belongs to, and invites addressed to them
+		v1.GET("/workspaces", workspaces.ListWorkspaces)
+		v1.POST("/workspaces", workspaces.CreateWorkspace)
+		v1.GET("/invites", workspaces.ListMyInvites)
+		v1.POST("/invites/:id/accept", workspaces.AcceptInvite)
+	}
+
+	// Signed receipt links are opened straight by browsers, so they carry no
+	// bearer token; the signature in the query string stands in for it
+	if deps.Receipts != nil {
+		receipts := handlersReceipts.NewHandler(deps.Receipts)
+		router.GET("/api/v1/receipts/:"+workspace.Param+"/:id", receipts.DownloadLink)
 	}
 
+	// Budget routes act on the workspace from the X-Workspace-ID header (or
+	// the personal one) and, identically, under /workspaces/:workspace
+	selected := workspace.Middleware(st)
+	budgetRoutes(v1.Group("", selected), deps)
+
+	scoped := v1.Group("/workspaces/:"+workspace.Param, selected)
+	budgetRoutes(scoped, deps)
+	{
+		owner := workspace.Require(store.RoleOwner)
+
+		scoped.GET("/members", workspaces.ListMembers)
+		scoped.PUT("/members/:userId", owner, workspaces.UpdateMember)
+		scoped.DELETE("/members/:userId", workspaces.RemoveMember)
+
+		scoped.GET("/invites", owner, workspaces.ListInvites)
+		scoped.POST("/invites", owner, workspaces.CreateInvite)
+		scoped.DELETE("/invites/:inviteId", owner, workspaces.DeleteInvite)
+
+		scoped.PUT("/currency", owner, rates.SetBaseCurrency)
+	}
+}
+
+// budgetRoutes registers the budget data routes; viewers may read, writes
+// need the editor role
+func budgetRoutes(group *gin.RouterGroup, deps Dependencies) {
+	st := deps.Store
+	alertService := alerts.NewService(st, deps.Dispatcher)
+	expenses := handlersExpenses.NewHandler(st, st, deps.Receipts, alertService)
+	categories := handlersCategories.NewHandler(st, deps.Receipts)
+	paidTypes := handlersPaidType.NewHandler(st)
+	status := handlersStatus.NewHandler(st)
+	analytic := handlersAnalytic.NewHandler(st)
+	export := handlersExport.NewHandler(st)
+	rules := handlersRules.NewHandler(st)
+	imports := handlersImports.NewHandler(importer.NewService(st), alertService)
+	budgetAlerts := handlersAlerts.NewHandler(st)
+	incomes := handlersIncomes.NewHandler(st)
+	rates := handlersRates.NewHandler(st)
+	recurringExpenses := handlersRecurring.NewHandler(st, recurring.NewRunner(st, alertService))
+
+	editor := workspace.Require(store.RoleEditor)
+
+	group.GET("/expenses", expenses.ListMonthlyExpenses)
+	group.POST("/expenses", editor, expenses.CreateExpense)
+	group.GET("/expenses/:id", expenses.ShowExpense)
+	group.PUT("/expenses/:id", editor, expenses.UpdateExpense)
+	group.PATCH("/expenses/:id", editor, expenses.PatchExpense)
+	group.DELETE("/expenses/:id", editor, expenses.DeleteExpense)
+
+	// Receipts
+	if deps.Receipts != nil {
+		receipts := handlersReceipts.NewHandler(deps.Receipts)
+		group.POST("/expenses/:id/receipt", editor, receipts.UploadReceipt)
+		group.GET("/expenses/:id/receipt", receipts.DownloadReceipt)
+		group.DELETE("/expenses/:id/receipt", editor, receipts.DeleteReceipt)
+	}
+
+	// Categories
+	group.GET("/categories", categories.GetCategories)
+	group.POST("/categories", editor, categories.CreateCategory)
+	group.DELETE("/categories/:id", editor, categories.DeleteCategory)
+	group.PUT("/categories/:id", editor, categories.UpdateCategory)
+	group.PUT("/categories/:id/parent", editor, categories.MoveCategory)
+	group.POST("/categories/:id/unarchive", editor, categories.UnarchiveCategory)
+	group.GET("/categories/:id/plans", categories.ListPlans)
+	group.PUT("/categories/:id/plans/:month", editor, categories.SetPlan)
+	group.GET("/categories/:id/thresholds", budgetAlerts.GetThresholds)
+	group.PUT("/categories/:id/thresholds", editor, budgetAlerts.SetThresholds)
+	// Incomes
+	group.GET("/incomes", incomes.ListIncomes)
+	group.POST("/incomes", editor, incomes.CreateIncome)
+	group.GET("/incomes/:id", incomes.GetIncome)
+	group.PUT("/incomes/:id", editor, incomes.UpdateIncome)
+	group.DELETE("/incomes/:id", editor, incomes.DeleteIncome)
+
+	// Paid Types
+	group.GET("/paid-types", paidTypes.ListPaidTypes)
+	group.POST("/paid-types", editor, paidTypes.CreatePaidType)
+
+	// Status
+	group.GET("/status", status.ListStatus)
+	group.POST("/status", editor, status.CreateStatus)
+	group.DELETE("/status/:id", editor, status.DeleteStatus)
+
+	// Analytic
+	group.GET("/dashboard/analytic/total", analytic.GetAnalyticTotal)
+	group.GET("/dashboard/analytic/pending-payments", analytic.GetPendingPayment)
+	group.GET("/dashboard/analytic/series", analytic.GetAnalyticSeries)
+	group.GET("/dashboard/analytic/categories", analytic.GetCategoryBreakdown)
+
+	// Export
+	group.GET("/export", export.Export)
+
+	// Categorisation rules
+	group.GET("/rules", rules.ListRules)
+	group.POST("/rules", editor, rules.CreateRule)
+	group.POST("/rules/dry-run", rules.DryRun)
+	group.GET("/rules/:id", rules.GetRule)
+	group.PUT("/rules/:id", editor, rules.UpdateRule)
+	group.DELETE("/rules/:id", editor, rules.DeleteRule)
+
+	// Budget alerts
+	group.GET("/alerts", budgetAlerts.ListAlerts)
+	group.POST("/alerts/:id/acknowledge", editor, budgetAlerts.AcknowledgeAlert)
+
+	// Recurring expenses
+	group.GET("/recurring", recurringExpenses.ListRecurring)
+	group.POST("/recurring", editor, recurringExpenses.CreateRecurring)
+	group.GET("/recurring/upcoming", recurringExpenses.ListUpcoming)
+	group.GET("/recurring/:id", recurringExpenses.GetRecurring)
+	group.PUT("/recurring/:id", editor, recurringExpenses.UpdateRecurring)
+	group.DELETE("/recurring/:id", editor, recurringExpenses.DeleteRecurring)
+	group.PUT("/recurring/:id/occurrences/:date", editor, recurringExpenses.SetOccurrence)
+	group.DELETE("/recurring/:id/occurrences/:date", editor, recurringExpenses.ResetOccurrence)
+
+	// Import
+	group.POST("/imports/preview", editor, imports.Preview)
+	group.POST("/imports/commit", editor, imports.Commit)
+
+	// Exchange rates
+	group.GET("/exchange-rates", rates.ListRates)
+	group.POST("/exchange-rates/import", editor, rates.ImportRates)
 }
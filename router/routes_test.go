@@ -0,0 +1,140 @@
+package routes_test
+
+import (
+	"go-sheet/handlers/handlertest"
+	"go-sheet/store"
+	"net/http"
+	"testing"
+)
+
+const (
+	alice = handlertest.UserA
+	bob   = handlertest.UserB
+)
+
+// aliceData is what alice owns in alice's personal workspace
+type aliceData struct {
+	categoryID, paidID, expenseID, statusID string
+}
+
+func seed(t *testing.T, srv *handlertest.Server) aliceData {
+	t.Helper()
+	var d aliceData
+	d.categoryID = srv.CreateCategory(t, alice, "Rent", "1500.00")
+	d.paidID = srv.CreatePaidType(t, alice, "Transfer")
+	d.expenseID = srv.CreateExpense(t, alice, `{"categoryId":"`+d.categoryID+`","paidId":"`+d.paidID+
+		`","referenceMonth":"2025-03","spentAmount":"1500.00","paymentDate":"2025-03-05","description":"March rent"}`)
+
+	var status struct {
+		Data store.Status `json:"data"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, alice, http.MethodPost, "/api/v1/status", `{"statusName":"scheduled"}`), &status)
+	d.statusID = status.Data.ID
+	return d
+}
+
+func TestOtherUsersDataIsNotFound(t *testing.T) {
+	srv := handlertest.New(t)
+	d := seed(t, srv)
+
+	expenseBody := `{"categoryId":"` + d.categoryID + `","paidId":"` + d.paidID +
+		`","referenceMonth":"2025-03","spentAmount":"1.00","paymentDate":"2025-03-05"}`
+	tests := []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, "/api/v1/expenses/" + d.expenseID, ""},
+		{http.MethodPut, "/api/v1/expenses/" + d.expenseID, expenseBody},
+		{http.MethodPatch, "/api/v1/expenses/" + d.expenseID, `{"description":"mine now"}`},
+		{http.MethodDelete, "/api/v1/expenses/" + d.expenseID, ""},
+		{http.MethodPut, "/api/v1/categories/" + d.categoryID, `{"name":"Mine","plannedAmount":"1.00"}`},
+		{http.MethodDelete, "/api/v1/categories/" + d.categoryID, ""},
+		{http.MethodDelete, "/api/v1/categories/" + d.categoryID + "?force=true", ""},
+		{http.MethodDelete, "/api/v1/status/" + d.statusID, ""},
+		// Naming alice's workspace does not help either
+		{http.MethodGet, "/api/v1/workspaces/" + alice + "/expenses/" + d.expenseID, ""},
+		{http.MethodDelete, "/api/v1/workspaces/" + alice + "/expenses/" + d.expenseID, ""},
+	}
+	for _, tt := range tests {
+		if w := srv.Do(t, bob, tt.method, tt.path, tt.body); w.Code != http.StatusNotFound {
+			t.Errorf("bob %s %s: status %d, want 404: %s", tt.method, tt.path, w.Code, w.Body)
+		}
+	}
+
+	// alice still has everything, untouched
+	var expense struct {
+		Expense store.Expense `json:"expense"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, alice, http.MethodGet, "/api/v1/expenses/"+d.expenseID, ""), &expense)
+	if expense.Expense.SpentAmount.String() != "1500.00" || expense.Expense.CategoryName != "Rent" || *expense.Expense.Description != "March rent" {
+		t.Errorf("alice's expense changed: %+v", expense.Expense)
+	}
+
+	var categories struct {
+		Data []store.Category `json:"data"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, alice, http.MethodGet, "/api/v1/categories", ""), &categories)
+	if len(categories.Data) != 1 || categories.Data[0].CategoryName != "Rent" || categories.Data[0].ArchivedAt != nil {
+		t.Errorf("alice's categories changed: %+v", categories.Data)
+	}
+
+	var statuses struct {
+		Data []store.Status `json:"data"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, alice, http.MethodGet, "/api/v1/status", ""), &statuses)
+	found := false
+	for _, status := range statuses.Data {
+		found = found || status.ID == d.statusID
+	}
+	if !found {
+		t.Errorf("alice's status %s is gone", d.statusID)
+	}
+}
+
+func TestOtherUsersDataIsNotListed(t *testing.T) {
+	srv := handlertest.New(t)
+	seed(t, srv)
+
+	var expenses struct {
+		Expenses []store.Expense `json:"expenses"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, bob, http.MethodGet, "/api/v1/expenses?month=2025-03", ""), &expenses)
+	if len(expenses.Expenses) != 0 {
+		t.Errorf("bob lists %d expenses, want none", len(expenses.Expenses))
+	}
+
+	var categories struct {
+		Data []store.Category `json:"data"`
+	}
+	handlertest.Decode(t, srv.Expect(t, http.StatusOK, bob, http.MethodGet, "/api/v1/categories", ""), &categories)
+	if len(categories.Data) != 0 {
+		t.Errorf("bob lists %d categories, want none", len(categories.Data))
+	}
+
+	srv.Expect(t, http.StatusNotFound, bob, http.MethodGet, "/api/v1/workspaces/"+alice+"/categories", "")
+}
+
+func TestOtherUsersIDsAreRejectedAsReferences(t *testing.T) {
+	srv := handlertest.New(t)
+	d := seed(t, srv)
+
+	// bob spends in bob's own workspace but points at alice's category, paid
+	// type and status
+	category := srv.CreateCategory(t, bob, "Food", "300.00")
+	paidID := srv.CreatePaidType(t, bob, "Card")
+	expenseID := srv.CreateExpense(t, bob, `{"categoryId":"`+category+`","paidId":"`+paidID+
+		`","referenceMonth":"2025-03","spentAmount":"12.00","paymentDate":"2025-03-05"}`)
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/api/v1/expenses", `{"categoryId":"` + d.categoryID + `","paidId":"` + paidID + `","referenceMonth":"2025-03","spentAmount":"1.00","paymentDate":"2025-03-05"}`},
+		{http.MethodPost, "/api/v1/expenses", `{"categoryId":"` + category + `","paidId":"` + d.paidID + `","referenceMonth":"2025-03","spentAmount":"1.00","paymentDate":"2025-03-05"}`},
+		{http.MethodPatch, "/api/v1/expenses/" + expenseID, `{"categoryId":"` + d.categoryID + `"}`},
+		{http.MethodPatch, "/api/v1/expenses/" + expenseID, `{"statusId":"` + d.statusID + `"}`},
+	}
+	for _, tt := range tests {
+		if w := srv.Do(t, bob, tt.method, tt.path, tt.body); w.Code != http.StatusBadRequest {
+			t.Errorf("bob %s %s with alice's ids: status %d, want 400: %s", tt.method, tt.path, w.Code, w.Body)
+		}
+	}
+}
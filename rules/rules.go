@@ -0,0 +1,186 @@
+// Package rules fills in the category, paid type and status of expenses that
+// arrive without them, from the workspace's categorisation rules.
+//
+// Rules are evaluated in the order store.RuleStore lists them. Each field is
+// taken from the first enabled rule that matches and sets it, so a rule with
+// a low priority may set the category while a later one sets the status.
+// Conditions are always checked against the transaction as given, never
+// against fields filled in by earlier rules.
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/store"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxPatternLength bounds description patterns
+const MaxPatternLength = 500
+
+// Lister is where an Engine loads its rules from
+type Lister interface {
+	ListRules(ctx context.Context, workspaceID string) ([]store.Rule, error)
+}
+
+// Transaction is what the rules are evaluated against
+type Transaction struct {
+	Description string
+	Amount      money.Amount
+	PaidID      string
+	// Date is the payment date; day conditions never match a zero Date
+	Date time.Time
+}
+
+// Result holds the fields the rules set and which rule set each of them
+type Result struct {
+	CategoryID   *string     `json:"categoryId"`
+	PaidID       *string     `json:"paidId"`
+	StatusID     *string     `json:"statusId"`
+	CategoryRule *store.Rule `json:"categoryRule"`
+	PaidRule     *store.Rule `json:"paidRule"`
+	StatusRule   *store.Rule `json:"statusRule"`
+	// Matched lists every enabled rule that matched, in evaluation order
+	Matched []store.Rule `json:"matched"`
+}
+
+// Engine evaluates a workspace's rules
+type Engine struct {
+	rules []compiled
+}
+
+type compiled struct {
+	rule    store.Rule
+	pattern *regexp.Regexp
+}
+
+// New prepares rules, given in evaluation order; disabled rules are dropped
+func New(rules []store.Rule) (*Engine, error) {
+	engine := &Engine{}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		c := compiled{rule: rule}
+		if rule.Match.Description != nil {
+			pattern, err := compile(*rule.Match.Description)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+			c.pattern = pattern
+		}
+		engine.rules = append(engine.rules, c)
+	}
+	return engine, nil
+}
+
+// Load returns an Engine with the rules of a workspace
+func Load(ctx context.Context, lister Lister, workspaceID string) (*Engine, error) {
+	list, err := lister.ListRules(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return New(list)
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// Apply evaluates every rule against t
+func (e *Engine) Apply(t Transaction) Result {
+	result := Result{Matched: []store.Rule{}}
+	for i := range e.rules {
+		c := &e.rules[i]
+		if !c.matches(t) {
+			continue
+		}
+		result.Matched = append(result.Matched, c.rule)
+
+		set := c.rule.Set
+		if result.CategoryID == nil && set.CategoryID != nil {
+			result.CategoryID, result.CategoryRule = set.CategoryID, &c.rule
+		}
+		if result.PaidID == nil && set.PaidID != nil {
+			result.PaidID, result.PaidRule = set.PaidID, &c.rule
+		}
+		if result.StatusID == nil && set.StatusID != nil {
+			result.StatusID, result.StatusRule = set.StatusID, &c.rule
+		}
+	}
+	return result
+}
+
+func (c *compiled) matches(t Transaction) bool {
+	match := c.rule.Match
+	if c.pattern != nil && !c.pattern.MatchString(t.Description) {
+		return false
+	}
+	if match.MinAmount != nil && t.Amount < *match.MinAmount {
+		return false
+	}
+	if match.MaxAmount != nil && t.Amount > *match.MaxAmount {
+		return false
+	}
+	if match.PaidID != nil && *match.PaidID != t.PaidID {
+		return false
+	}
+	if match.DayFrom == nil && match.DayTo == nil {
+		return true
+	}
+	if t.Date.IsZero() {
+		return false
+	}
+
+	day := t.Date.Day()
+	switch {
+	case match.DayTo == nil:
+		return day >= *match.DayFrom
+	case match.DayFrom == nil:
+		return day <= *match.DayTo
+	case *match.DayFrom <= *match.DayTo:
+		return day >= *match.DayFrom && day <= *match.DayTo
+	default:
+		// Intervalo que atravessa a virada do mês, ex.: 25 a 5
+		return day >= *match.DayFrom || day <= *match.DayTo
+	}
+}
+
+// Validate checks a rule before it is stored
+func Validate(rule store.Rule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return errors.New("name is required")
+	}
+
+	match, set := rule.Match, rule.Set
+	if match.Description != nil {
+		if len(*match.Description) > MaxPatternLength {
+			return fmt.Errorf("match.description must be at most %d characters", MaxPatternLength)
+		}
+		if _, err := compile(*match.Description); err != nil {
+			return fmt.Errorf("match.description: %w", err)
+		}
+	}
+	for name, amount := range map[string]*money.Amount{"match.minAmount": match.MinAmount, "match.maxAmount": match.MaxAmount} {
+		if amount != nil && amount.IsNegative() {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	if match.MinAmount != nil && match.MaxAmount != nil && *match.MinAmount > *match.MaxAmount {
+		return errors.New("match.minAmount must not exceed match.maxAmount")
+	}
+	for name, day := range map[string]*int{"match.dayFrom": match.DayFrom, "match.dayTo": match.DayTo} {
+		if day != nil && (*day < 1 || *day > 31) {
+			return fmt.Errorf("%s must be between 1 and 31", name)
+		}
+	}
+	if set.CategoryID == nil && set.PaidID == nil && set.StatusID == nil {
+		return errors.New("set must hold at least one of categoryId, paidId and statusId")
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package rules
+
+import (
+	"go-sheet/money"
+	"go-sheet/store"
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func ruleID(r *store.Rule) string {
+	if r == nil {
+		return ""
+	}
+	return r.ID
+}
+
+func on(day int) time.Time {
+	return time.Date(2025, time.March, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestApplyFirstMatchSetsEachField(t *testing.T) {
+	engine, err := New([]store.Rule{
+		{ID: "disabled", Enabled: false, Set: store.RuleActions{CategoryID: ptr("never")}},
+		{ID: "status", Enabled: true, Set: store.RuleActions{StatusID: ptr("paid")}},
+		{ID: "market", Enabled: true, Match: store.RuleMatch{Description: ptr("mercado")}, Set: store.RuleActions{CategoryID: ptr("food"), PaidID: ptr("card")}},
+		{ID: "other-market", Enabled: true, Match: store.RuleMatch{Description: ptr("mercado")}, Set: store.RuleActions{CategoryID: ptr("shopping"), StatusID: ptr("pending")}},
+		{ID: "fallback", Enabled: true, Set: store.RuleActions{CategoryID: ptr("misc")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description                        string
+		category, paid, status             string
+		categoryRule, paidRule, statusRule string
+		matched                            int
+	}{
+		{"Mercado Livre", "food", "card", "paid", "market", "market", "status", 4},
+		{"Padaria", "misc", "", "paid", "fallback", "", "status", 2},
+	}
+	for _, tt := range tests {
+		result := engine.Apply(Transaction{Description: tt.description})
+		got := []string{deref(result.CategoryID), deref(result.PaidID), deref(result.StatusID), ruleID(result.CategoryRule), ruleID(result.PaidRule), ruleID(result.StatusRule)}
+		want := []string{tt.category, tt.paid, tt.status, tt.categoryRule, tt.paidRule, tt.statusRule}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: got %v, want %v", tt.description, got, want)
+				break
+			}
+		}
+		if len(result.Matched) != tt.matched {
+			t.Errorf("%s: %d rules matched, want %d", tt.description, len(result.Matched), tt.matched)
+		}
+	}
+}
+
+func TestApplyConditions(t *testing.T) {
+	tests := []struct {
+		name  string
+		match store.RuleMatch
+		tx    Transaction
+		want  bool
+	}{
+		{"regex is case-insensitive", store.RuleMatch{Description: ptr(`^uber\b`)}, Transaction{Description: "UBER *TRIP"}, true},
+		{"regex anchors", store.RuleMatch{Description: ptr(`^uber\b`)}, Transaction{Description: "Pag Uber"}, false},
+		{"regex alternatives", store.RuleMatch{Description: ptr(`netflix|spotify`)}, Transaction{Description: "SPOTIFY P1"}, true},
+		{"regex on an empty description", store.RuleMatch{Description: ptr(`.+`)}, Transaction{}, false},
+		{"amount inside the range", store.RuleMatch{MinAmount: ptr(money.Amount(1000)), MaxAmount: ptr(money.Amount(5000))}, Transaction{Amount: 5000}, true},
+		{"amount above the range", store.RuleMatch{MinAmount: ptr(money.Amount(1000)), MaxAmount: ptr(money.Amount(5000))}, Transaction{Amount: 5001}, false},
+		{"paid type", store.RuleMatch{PaidID: ptr("card")}, Transaction{PaidID: "cash"}, false},
+		{"day range", store.RuleMatch{DayFrom: ptr(5), DayTo: ptr(10)}, Transaction{Date: on(10)}, true},
+		{"day outside the range", store.RuleMatch{DayFrom: ptr(5), DayTo: ptr(10)}, Transaction{Date: on(11)}, false},
+		{"wrapping range, end of month", store.RuleMatch{DayFrom: ptr(25), DayTo: ptr(5)}, Transaction{Date: on(28)}, true},
+		{"wrapping range, start of month", store.RuleMatch{DayFrom: ptr(25), DayTo: ptr(5)}, Transaction{Date: on(3)}, true},
+		{"wrapping range, middle of month", store.RuleMatch{DayFrom: ptr(25), DayTo: ptr(5)}, Transaction{Date: on(15)}, false},
+		{"only dayFrom", store.RuleMatch{DayFrom: ptr(20)}, Transaction{Date: on(31)}, true},
+		{"only dayTo", store.RuleMatch{DayTo: ptr(10)}, Transaction{Date: on(11)}, false},
+		{"day condition without a date", store.RuleMatch{DayFrom: ptr(1), DayTo: ptr(31)}, Transaction{}, false},
+	}
+	for _, tt := range tests {
+		engine, err := New([]store.Rule{{ID: "rule", Enabled: true, Match: tt.match, Set: store.RuleActions{CategoryID: ptr("category")}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := engine.Apply(tt.tx).CategoryID != nil; got != tt.want {
+			t.Errorf("%s: matched %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsBadPatterns(t *testing.T) {
+	_, err := New([]store.Rule{{ID: "bad", Enabled: true, Match: store.RuleMatch{Description: ptr("(")}}})
+	if err == nil {
+		t.Error("New accepted an invalid regular expression")
+	}
+	// Disabled rules are never compiled
+	if _, err := New([]store.Rule{{ID: "bad", Match: store.RuleMatch{Description: ptr("(")}}}); err != nil {
+		t.Errorf("New with a disabled rule: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	set := store.RuleActions{CategoryID: ptr("category")}
+	tests := []struct {
+		name    string
+		rule    store.Rule
+		wantErr bool
+	}{
+		{"valid", store.Rule{Name: "Uber", Match: store.RuleMatch{Description: ptr("uber"), DayFrom: ptr(25), DayTo: ptr(5)}, Set: set}, false},
+		{"no name", store.Rule{Name: " ", Set: set}, true},
+		{"bad pattern", store.Rule{Name: "r", Match: store.RuleMatch{Description: ptr("[")}, Set: set}, true},
+		{"negative amount", store.Rule{Name: "r", Match: store.RuleMatch{MinAmount: ptr(money.Amount(-1))}, Set: set}, true},
+		{"min above max", store.Rule{Name: "r", Match: store.RuleMatch{MinAmount: ptr(money.Amount(2)), MaxAmount: ptr(money.Amount(1))}, Set: set}, true},
+		{"day out of range", store.Rule{Name: "r", Match: store.RuleMatch{DayTo: ptr(32)}, Set: set}, true},
+		{"sets nothing", store.Rule{Name: "r"}, true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.rule); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package sheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// csvWriter writes a single table
+type csvWriter struct {
+	w      *csv.Writer
+	tables int
+	record []string
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Table(name string, header []string) error {
+	if c.tables++; c.tables > 1 {
+		return errors.New("a CSV file holds a single table")
+	}
+	return c.w.Write(header)
+}
+
+func (c *csvWriter) Row(cells []Cell) error {
+	c.record = c.record[:0]
+	for _, cell := range cells {
+		value := cell.Value
+		// Spreadsheet apps evaluate text starting with these as formulas
+		if !cell.Numeric && value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+			value = "'" + value
+		}
+		c.record = append(c.record, value)
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,227 @@
+// Package sheet exports expenses and per-category summaries as CSV or XLSX.
+//
+// Exports are streamed: expenses are read page by page through
+// store.ExpenseStore and written as they arrive, so memory use does not grow
+// with the size of the export.
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"io"
+)
+
+// Format is an export file format
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// ParseFormat accepts "csv" and "xlsx"
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case CSV, XLSX:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected csv or xlsx", s)
+}
+
+// ContentType is the MIME type of the format
+func (f Format) ContentType() string {
+	if f == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Table is one of the tables of an export. XLSX files hold every table as a
+// separate sheet; a CSV file holds only the one requested.
+type Table string
+
+const (
+	Expenses Table = "expenses"
+	Summary  Table = "summary"
+)
+
+// ParseTable accepts "expenses" and "summary"
+func ParseTable(s string) (Table, error) {
+	switch t := Table(s); t {
+	case Expenses, Summary:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown table %q, expected expenses or summary", s)
+}
+
+// Source is what an export reads from
+type Source interface {
+	ListExpenses(ctx context.Context, workspaceID string, query store.ExpenseQuery) (store.ExpensePage, error)
+	CategoryTotals(ctx context.Context, workspaceID string, from, to month.YearMonth) ([]store.CategoryTotal, error)
+}
+
+// Request describes an export of the months From to To, both inclusive
+type Request struct {
+	WorkspaceID string
+	From, To    month.YearMonth
+	Format      Format
+	// Table selects the table of a CSV export (default Expenses)
+	Table Table
+}
+
+// FileName suggests a name for the exported file
+func (r Request) FileName() string {
+	name := "go-sheet-" + r.From.String()
+	if r.To != r.From {
+		name += "_" + r.To.String()
+	}
+	if r.Format == CSV {
+		table := r.Table
+		if table == "" {
+			table = Expenses
+		}
+		name += "-" + string(table)
+	}
+	return name + "." + string(r.Format)
+}
+
+// Cell is a spreadsheet value; numeric cells are written as numbers in XLSX
+type Cell struct {
+	Value   string
+	Numeric bool
+}
+
+func text(s string) Cell {
+	return Cell{Value: s}
+}
+
+func optional(s *string) Cell {
+	if s == nil {
+		return Cell{}
+	}
+	return Cell{Value: *s}
+}
+
+func amount(a money.Amount) Cell {
+	return Cell{Value: a.String(), Numeric: true}
+}
+
+func optionalAmount(a *money.Amount) Cell {
+	if a == nil {
+		return Cell{}
+	}
+	return amount(*a)
+}
+
+// tableWriter writes one or more tables in a file format
+type tableWriter interface {
+	Table(name string, header []string) error
+	Row(cells []Cell) error
+	Close() error
+}
+
+var expenseHeader = []string{
+	"expenseId", "categoryName", "referenceMonth", "spentAmount", "plannedAmount", "difference",
+	"paymentDate", "file", "paidId", "paidType", "paidColor", "statusId", "statusName", "description",
+}
+
+var summaryHeader = []string{"categoryId", "categoryName", "plannedAmount", "spentAmount", "difference"}
+
+// Write streams the export to w. Nothing is written when the first read from
+// src fails, so callers can still report the error.
+func Write(ctx context.Context, w io.Writer, src Source, req Request) error {
+	if req.Table == "" {
+		req.Table = Expenses
+	}
+	withExpenses := req.Format == XLSX || req.Table == Expenses
+	withSummary := req.Format == XLSX || req.Table == Summary
+
+	var totals []store.CategoryTotal
+	if withSummary {
+		var err error
+		if totals, err = src.CategoryTotals(ctx, req.WorkspaceID, req.From, req.To); err != nil {
+			return err
+		}
+	}
+
+	query := store.ExpenseQuery{
+		From:  req.From,
+		To:    req.To,
+		Sort:  store.SortReferenceMonth,
+		Limit: store.MaxExpenseLimit,
+	}
+	var page store.ExpensePage
+	if withExpenses {
+		var err error
+		if page, err = src.ListExpenses(ctx, req.WorkspaceID, query); err != nil {
+			return err
+		}
+	}
+
+	var out tableWriter
+	if req.Format == XLSX {
+		out = newXLSXWriter(w)
+	} else {
+		out = newCSVWriter(w)
+	}
+
+	if withExpenses {
+		if err := out.Table("Expenses", expenseHeader); err != nil {
+			return err
+		}
+		for {
+			for _, expense := range page.Expenses {
+				if err := out.Row(expenseRow(expense)); err != nil {
+					return err
+				}
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			cursor, err := store.DecodeCursor(page.NextCursor)
+			if err != nil {
+				return err
+			}
+			query.Cursor = &cursor
+			if page, err = src.ListExpenses(ctx, req.WorkspaceID, query); err != nil {
+				return err
+			}
+		}
+	}
+
+	if withSummary {
+		if err := out.Table("Summary", summaryHeader); err != nil {
+			return err
+		}
+		for _, total := range totals {
+			row := []Cell{text(total.CategoryID), text(total.CategoryName), amount(total.Planned), amount(total.Spent), amount(total.Difference)}
+			if err := out.Row(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	return out.Close()
+}
+
+func expenseRow(e store.Expense) []Cell {
+	return []Cell{
+		text(e.ExpenseID),
+		text(e.CategoryName),
+		optional(e.ReferenceMonth),
+		optionalAmount(e.SpentAmount),
+		amount(e.PlannedAmount),
+		optionalAmount(e.Difference),
+		optional(e.PaymentDate),
+		optional(e.File),
+		optional(e.PaidId),
+		optional(e.PaidType),
+		optional(e.PaidColor),
+		optional(e.StatusId),
+		optional(e.StatusName),
+		optional(e.Description),
+	}
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go-sheet/money"
+	"go-sheet/month"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed or were issued
+// for a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ExpenseSort is a field expenses can be ordered by
+type ExpenseSort string
+
+const (
+	SortReferenceMonth ExpenseSort = "referenceMonth"
+	SortPaymentDate    ExpenseSort = "paymentDate"
+	SortSpentAmount    ExpenseSort = "spentAmount"
+	SortPlannedAmount  ExpenseSort = "plannedAmount"
+	SortCategoryName   ExpenseSort = "categoryName"
+)
+
+// Valid reports whether s is a sortable field
+func (s ExpenseSort) Valid() bool {
+	switch s {
+	case SortReferenceMonth, SortPaymentDate, SortSpentAmount, SortPlannedAmount, SortCategoryName:
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultExpenseLimit is the page size when none is requested
+	DefaultExpenseLimit = 50
+	// MaxExpenseLimit caps the page size
+	MaxExpenseLimit = 200
+)
+
+// ExpenseQuery filters, orders and pages ListExpenses. Zero fields do not
+// filter. Expenses without a payment date or spent amount sort as the
+// smallest values; ties are broken by expense id.
+type ExpenseQuery struct {
+	// From and To bound reference_month, both inclusive
+	From, To   month.YearMonth
+	CategoryID string
+	PaidID     string
+	StatusID   string
+	// MinAmount and MaxAmount bound the spent amount in the base currency,
+	// both inclusive
+	MinAmount *money.Amount
+	MaxAmount *money.Amount
+	// Search matches description case-insensitively
+	Search string
+
+	Sort  ExpenseSort
+	Desc  bool
+	Limit int
+	// Cursor continues after the last expense of a previous page
+	Cursor *Cursor
+}
+
+// Normalize fills in the default order and page size
+func (q *ExpenseQuery) Normalize() {
+	if q.Sort == "" {
+		q.Sort, q.Desc = SortReferenceMonth, true
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultExpenseLimit
+	}
+	if q.Limit > MaxExpenseLimit {
+		q.Limit = MaxExpenseLimit
+	}
+}
+
+// ExpensePage is one page of ListExpenses; NextCursor is empty on the last page
+type ExpensePage struct {
+	Expenses   []Expense
+	NextCursor string
+}
+
+// Cursor is the position after the last expense of a page: its sort key, in
+// a store specific format, and its id
+type Cursor struct {
+	Sort ExpenseSort `json:"s"`
+	Desc bool        `json:"d,omitempty"`
+	Key  string      `json:"k"`
+	ID   string      `json:"i"`
+}
+
+// Encode renders the cursor as an opaque URL-safe token
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode
+func DecodeCursor(token string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(token))
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || !c.Sort.Valid() || c.ID == "" {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Matches reports whether the cursor was issued for the query's order
+func (c Cursor) Matches(q ExpenseQuery) bool {
+	return c.Sort == q.Sort && c.Desc == q.Desc
+}
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) AlertThresholds(ctx context.Context, workspaceID, categoryID string) ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.findCategory(workspaceID, categoryID)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	return append([]int{}, s.categories[i].thresholds...), nil
+}
+
+func (s *Store) SetAlertThresholds(ctx context.Context, workspaceID, categoryID string, percents []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findCategory(workspaceID, categoryID)
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	thresholds := slices.Clone(percents)
+	slices.Sort(thresholds)
+	s.categories[i].thresholds = slices.Compact(thresholds)
+
+	return nil
+}
+
+func (s *Store) EvaluateAlerts(ctx context.Context, workspaceID string, expenseIDs []string) ([]store.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type key struct {
+		categoryID string
+		month      month.YearMonth
+	}
+	var touched []key
+	for _, id := range expenseIDs {
+		i, ok := s.findExpense(workspaceID, id)
+		if !ok {
+			continue
+		}
+		k := key{s.expenses[i].categoryID, s.expenses[i].referenceMonth}
+		if !slices.Contains(touched, k) {
+			touched = append(touched, k)
+		}
+	}
+
+	raised := []store.Alert{}
+	for _, k := range touched {
+		c, ok := s.findCategory(workspaceID, k.categoryID)
+		if !ok {
+			continue
+		}
+
+		var spent, planned money.Amount
+		hasPlanned := false
+		for _, record := range s.expenses {
+			if record.workspace != workspaceID || record.categoryID != k.categoryID || record.referenceMonth != k.month {
+				continue
+			}
+			if amount := s.spent(record); amount != nil {
+				spent = spent.Add(*amount)
+			}
+			if record.isPlanned {
+				planned = planned.Add(record.plannedAmount)
+				hasPlanned = true
+			}
+		}
+		if !hasPlanned {
+			planned = s.categories[c].planFor(k.month)
+		}
+		if planned <= 0 {
+			continue
+		}
+
+		for _, percent := range s.categories[c].thresholds {
+			if int64(spent)*100 < int64(planned)*int64(percent) || s.hasAlert(k.categoryID, k.month, percent) {
+				continue
+			}
+			alert := store.Alert{
+				ID:             uuid.NewString(),
+				WorkspaceID:    workspaceID,
+				CategoryID:     k.categoryID,
+				ReferenceMonth: k.month,
+				Threshold:      percent,
+				SpentAmount:    spent,
+				PlannedAmount:  planned,
+				CreatedAt:      s.now(),
+			}
+			s.alerts = append(s.alerts, alert)
+			raised = append(raised, s.renderAlert(alert))
+		}
+	}
+
+	sort.SliceStable(raised, func(i, j int) bool {
+		a, b := raised[i], raised[j]
+		if a.CategoryName != b.CategoryName {
+			return a.CategoryName < b.CategoryName
+		}
+		if a.ReferenceMonth != b.ReferenceMonth {
+			return a.ReferenceMonth.Before(b.ReferenceMonth)
+		}
+		return a.Threshold < b.Threshold
+	})
+
+	return raised, nil
+}
+
+func (s *Store) hasAlert(categoryID string, m month.YearMonth, threshold int) bool {
+	for _, alert := range s.alerts {
+		if alert.CategoryID == categoryID && alert.ReferenceMonth == m && alert.Threshold == threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// renderAlert fills in the category name, as the join does in store/postgres
+func (s *Store) renderAlert(alert store.Alert) store.Alert {
+	if i, ok := s.findCategory(alert.WorkspaceID, alert.CategoryID); ok {
+		alert.CategoryName = s.categories[i].name
+	}
+	return alert
+}
+
+func (s *Store) ListAlerts(ctx context.Context, workspaceID string, query store.AlertQuery) ([]store.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alerts := []store.Alert{}
+	for _, alert := range s.alerts {
+		if alert.WorkspaceID != workspaceID ||
+			!query.Month.IsZero() && alert.ReferenceMonth != query.Month ||
+			query.CategoryID != "" && alert.CategoryID != query.CategoryID ||
+			query.Unacknowledged && alert.AcknowledgedAt != nil {
+			continue
+		}
+		alerts = append(alerts, s.renderAlert(alert))
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if !alerts[i].CreatedAt.Equal(alerts[j].CreatedAt) {
+			return alerts[i].CreatedAt.After(alerts[j].CreatedAt)
+		}
+		return alerts[i].Threshold > alerts[j].Threshold
+	})
+
+	return alerts, nil
+}
+
+func (s *Store) AcknowledgeAlert(ctx context.Context, workspaceID, id, userID string) (store.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.alerts {
+		alert := &s.alerts[i]
+		if alert.WorkspaceID != workspaceID || alert.ID != id {
+			continue
+		}
+		if alert.AcknowledgedAt == nil {
+			alert.AcknowledgedAt = ptr(s.now())
+			alert.AcknowledgedBy = ptr(userID)
+		}
+		return s.renderAlert(*alert), nil
+	}
+
+	return store.Alert{}, store.ErrNotFound
+}
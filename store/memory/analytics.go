@@ -0,0 +1,264 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"math"
+	"sort"
+	"time"
+)
+
+func (s *Store) Totals(ctx context.Context, workspaceID string, m month.YearMonth) (store.MonthTotals, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var totals store.MonthTotals
+	for _, record := range s.expenses {
+		if record.workspace != workspaceID || record.referenceMonth != m {
+			continue
+		}
+		if record.isPlanned {
+			totals.Planned = totals.Planned.Add(record.plannedAmount)
+		}
+		if spent := s.spent(record); spent != nil {
+			totals.Spent = totals.Spent.Add(*spent)
+		} else if s.unconverted(record) {
+			totals.Unconverted++
+		}
+	}
+
+	for _, record := range s.incomes {
+		if record.workspace != workspaceID || record.ReferenceMonth != m {
+			continue
+		}
+		if amount := s.renderIncome(record).Amount; amount != nil {
+			totals.Income = totals.Income.Add(*amount)
+		}
+	}
+
+	totals.Difference = totals.Planned.Sub(totals.Spent)
+	totals.Net = totals.Income.Sub(totals.Spent)
+
+	return totals, nil
+}
+
+func (s *Store) PendingPayments(ctx context.Context, workspaceID string, m month.YearMonth) ([]store.PendingPayment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	payments := []store.PendingPayment{}
+	pending, ok := s.findStatusByName("", "pending")
+	if !ok {
+		return payments, nil
+	}
+	for _, record := range s.expenses {
+		if record.workspace != workspaceID || record.referenceMonth != m || deref(record.statusID) != pending.ID {
+			continue
+		}
+		expense, ok := s.render(record)
+		if !ok || expense.StatusName == nil {
+			continue
+		}
+
+		payment := store.PendingPayment{
+			ExpenseID:      record.id,
+			CategoryID:     record.categoryID,
+			CategoryName:   expense.CategoryName,
+			ReferenceMonth: record.referenceMonth.Start().Format("2006-01-02"),
+			PlannedAmount:  record.plannedAmount,
+			PaymentDate:    time.Time{}.Format("2006-01-02"),
+			StatusName:     *expense.StatusName,
+		}
+		if spent := s.spent(record); spent != nil {
+			payment.SpentAmount = *spent
+		}
+		if record.paymentDate != nil {
+			payment.PaymentDate = record.paymentDate.Format("2006-01-02")
+		}
+		if record.description != nil {
+			payment.Description = *record.description
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, nil
+}
+
+func (s *Store) CategoryTotals(ctx context.Context, workspaceID string, from, to month.YearMonth) ([]store.CategoryTotal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byCategory := map[string]*store.CategoryTotal{}
+	totals := []*store.CategoryTotal{}
+	for _, record := range s.expenses {
+		if record.workspace != workspaceID || record.referenceMonth.Before(from) || record.referenceMonth.After(to) {
+			continue
+		}
+		i, ok := s.findCategory(record.workspace, record.categoryID)
+		if !ok {
+			continue
+		}
+		total := byCategory[record.categoryID]
+		if total == nil {
+			total = &store.CategoryTotal{CategoryID: record.categoryID, CategoryName: s.categories[i].name}
+			byCategory[record.categoryID] = total
+			totals = append(totals, total)
+		}
+		if record.isPlanned {
+			total.Planned = total.Planned.Add(record.plannedAmount)
+		}
+		if spent := s.spent(record); spent != nil {
+			total.Spent = total.Spent.Add(*spent)
+		} else if s.unconverted(record) {
+			total.Unconverted++
+		}
+	}
+
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].CategoryName != totals[j].CategoryName {
+			return totals[i].CategoryName < totals[j].CategoryName
+		}
+		return totals[i].CategoryID < totals[j].CategoryID
+	})
+
+	result := make([]store.CategoryTotal, len(totals))
+	for i, total := range totals {
+		total.Difference = total.Planned.Sub(total.Spent)
+		result[i] = *total
+	}
+
+	return result, nil
+}
+
+func (s *Store) MonthlySeries(ctx context.Context, workspaceID string, from, to month.YearMonth) ([]store.MonthPoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	spent := func(m month.YearMonth) money.Amount {
+		var total money.Amount
+		for _, record := range s.expenses {
+			if spent := s.spent(record); record.workspace == workspaceID && record.referenceMonth == m && spent != nil {
+				total = total.Add(*spent)
+			}
+		}
+		return total
+	}
+
+	points := []store.MonthPoint{}
+	for m := from; !m.After(to); m = m.Next() {
+		point := store.MonthPoint{Month: m}
+		for _, record := range s.expenses {
+			if record.workspace != workspaceID || record.referenceMonth != m {
+				continue
+			}
+			if record.isPlanned {
+				point.Planned = point.Planned.Add(record.plannedAmount)
+			}
+			if spent := s.spent(record); spent != nil {
+				point.Spent = point.Spent.Add(*spent)
+			} else if s.unconverted(record) {
+				point.Unconverted++
+			}
+		}
+		point.Difference = point.Planned.Sub(point.Spent)
+		point.MonthOverMonth = delta(point.Spent, spent(m.AddMonths(-1)))
+		point.YearOverYear = delta(point.Spent, spent(m.AddMonths(-12)))
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+func (s *Store) CategoryBreakdown(ctx context.Context, workspaceID string, m month.YearMonth) ([]store.CategoryShare, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type usage struct {
+		share                       store.CategoryShare
+		previousMonth, previousYear money.Amount
+		current                     bool
+	}
+	byCategory := map[string]*usage{}
+	var list []*usage
+	for _, record := range s.expenses {
+		if record.workspace != workspaceID {
+			continue
+		}
+		i, ok := s.findCategory(record.workspace, record.categoryID)
+		if !ok {
+			continue
+		}
+		u := byCategory[record.categoryID]
+		if u == nil {
+			u = &usage{share: store.CategoryShare{CategoryID: record.categoryID, CategoryName: s.categories[i].name}}
+			byCategory[record.categoryID] = u
+			list = append(list, u)
+		}
+
+		var spent money.Amount
+		if amount := s.spent(record); amount != nil {
+			spent = *amount
+		}
+		switch record.referenceMonth {
+		case m:
+			u.current = true
+			u.share.Spent = u.share.Spent.Add(spent)
+			if s.unconverted(record) {
+				u.share.Unconverted++
+			}
+			if record.isPlanned {
+				u.share.Planned = u.share.Planned.Add(record.plannedAmount)
+			}
+		case m.AddMonths(-1):
+			u.previousMonth = u.previousMonth.Add(spent)
+		case m.AddMonths(-12):
+			u.previousYear = u.previousYear.Add(spent)
+		}
+	}
+
+	var total money.Amount
+	shares := []store.CategoryShare{}
+	for _, u := range list {
+		if !u.current {
+			continue
+		}
+		u.share.Difference = u.share.Planned.Sub(u.share.Spent)
+		u.share.MonthOverMonth = delta(u.share.Spent, u.previousMonth)
+		u.share.YearOverYear = delta(u.share.Spent, u.previousYear)
+		total = total.Add(u.share.Spent)
+		shares = append(shares, u.share)
+	}
+	for i := range shares {
+		if total != 0 {
+			shares[i].Percent = percent(shares[i].Spent, total)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Spent != shares[j].Spent {
+			return shares[i].Spent > shares[j].Spent
+		}
+		if shares[i].CategoryName != shares[j].CategoryName {
+			return shares[i].CategoryName < shares[j].CategoryName
+		}
+		return shares[i].CategoryID < shares[j].CategoryID
+	})
+
+	return shares, nil
+}
+
+// delta mirrors the change columns of store/postgres
+func delta(current, previous money.Amount) store.Delta {
+	d := store.Delta{Previous: previous, Change: current.Sub(previous)}
+	if previous != 0 {
+		d.Percent = ptr(percent(d.Change, previous))
+	}
+	return d
+}
+
+// percent returns part*100/whole rounded to two decimals, half away from
+// zero like ROUND on NUMERIC
+func percent(part, whole money.Amount) float64 {
+	return math.Round(float64(part)*100/float64(whole)*100) / 100
+}
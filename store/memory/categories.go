@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"go-sheet/month"
+	"go-sheet/store"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) ListCategories(ctx context.Context, workspaceID string) ([]store.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := []store.Category{}
+	for _, record := range s.categories {
+		if record.workspace != workspaceID {
+			continue
+		}
+		categories = append(categories, store.Category{
+			CategoryID:    record.id,
+			CategoryName:  record.name,
+			ParentID:      optionalString(deref(record.parentID)),
+			PlannedAmount: record.planFor(month.Of(s.now())),
+			Color:         record.color,
+			ArchivedAt:    record.archivedAt,
+		})
+	}
+
+	return categories, nil
+}
+
+func (s *Store) CreateCategory(ctx context.Context, workspaceID string, input store.CategoryInput) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending, ok := s.findStatusByName("", "pending")
+	if !ok {
+		return "", errors.New("get pending status: not found")
+	}
+	if input.ParentID != nil {
+		if _, err := s.activeCategory(workspaceID, *input.ParentID); err != nil {
+			return "", err
+		}
+	}
+
+	record := categoryRecord{
+		id:            uuid.NewString(),
+		workspace:     workspaceID,
+		name:          input.Name,
+		parentID:      optionalString(deref(input.ParentID)),
+		plannedAmount: input.PlannedAmount,
+		plans:         []store.CategoryPlan{{Month: month.Of(s.now()), PlannedAmount: input.PlannedAmount}},
+		color:         input.Color,
+		description:   input.Description,
+		createdAt:     s.now(),
+	}
+	s.categories = append(s.categories, record)
+	s.expenses = append(s.expenses, expenseRecord{
+		id:             uuid.NewString(),
+		workspace:      workspaceID,
+		categoryID:     record.id,
+		referenceMonth: month.Of(s.now()),
+		plannedAmount:  input.PlannedAmount,
+		statusID:       ptr(pending.ID),
+		description:    ptr(input.Description),
+		isPlanned:      true,
+	})
+
+	return record.id, nil
+}
+
+func (s *Store) UpdateCategory(ctx context.Context, workspaceID, id string, input store.CategoryInput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findCategory(workspaceID, id)
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	record := &s.categories[i]
+	record.name = input.Name
+	record.plannedAmount = input.PlannedAmount
+	record.color = input.Color
+	record.description = input.Description
+
+	currentMonth := month.Of(s.now())
+	for i := range s.expenses {
+		if s.expenses[i].categoryID == id && s.expenses[i].referenceMonth == currentMonth && s.expenses[i].isPlanned {
+			s.expenses[i].description = ptr(input.Description)
+		}
+	}
+	s.setPlan(i, currentMonth, input.PlannedAmount)
+
+	return nil
+}
+
+func (s *Store) ArchiveCategory(ctx context.Context, workspaceID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findCategory(workspaceID, id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	for _, category := range s.categories {
+		if id == deref(category.parentID) && category.archivedAt == nil {
+			return store.ErrHasSubcategories
+		}
+	}
+
+	if s.categories[i].archivedAt == nil {
+		s.categories[i].archivedAt = ptr(s.now())
+	}
+	return nil
+}
+
+func (s *Store) UnarchiveCategory(ctx context.Context, workspaceID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findCategory(workspaceID, id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if parent := s.categories[i].parentID; parent != nil {
+		if j, ok := s.findCategory(workspaceID, *parent); ok && s.categories[j].archivedAt != nil {
+			return store.ErrCategoryArchived
+		}
+	}
+
+	s.categories[i].archivedAt = nil
+	return nil
+}
+
+func (s *Store) DeleteCategory(ctx context.Context, workspaceID, id string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.findCategory(workspaceID, id); !ok {
+		return nil, store.ErrNotFound
+	}
+
+	// A subárvore inteira sai junto: a categoria e todas as subcategorias
+	subtree := map[string]bool{id: true}
+	for grew := true; grew; {
+		grew = false
+		for _, category := range s.categories {
+			if category.workspace == workspaceID && !subtree[category.id] && subtree[deref(category.parentID)] {
+				subtree[category.id] = true
+				grew = true
+			}
+		}
+	}
+	s.categories = slices.DeleteFunc(s.categories, func(record categoryRecord) bool { return subtree[record.id] })
+
+	receiptKeys := []string{}
+	s.expenses = slices.DeleteFunc(s.expenses, func(record expenseRecord) bool {
+		if !subtree[record.categoryID] {
+			return false
+		}
+		if record.receiptKey != "" {
+			receiptKeys = append(receiptKeys, record.receiptKey)
+		}
+		return true
+	})
+
+	// ON DELETE CASCADE em budget_alerts.category_id
+	s.alerts = slices.DeleteFunc(s.alerts, func(alert store.Alert) bool { return subtree[alert.CategoryID] })
+
+	// ON DELETE CASCADE em recurring_expenses.category_id
+	s.recurring = slices.DeleteFunc(s.recurring, func(r store.RecurringExpense) bool { return subtree[r.CategoryID] })
+
+	// ON DELETE SET NULL em categorization_rules.set_category_id
+	for i := range s.rules {
+		if subtree[deref(s.rules[i].Set.CategoryID)] {
+			s.rules[i].Set.CategoryID = nil
+		}
+	}
+
+	return receiptKeys, nil
+}
+
+func (s *Store) MoveCategory(ctx context.Context, workspaceID, id string, parentID *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findCategory(workspaceID, id)
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	if parentID != nil {
+		if _, err := s.activeCategory(workspaceID, *parentID); err != nil {
+			return err
+		}
+		// Sobe a partir do novo pai até a raiz procurando a própria categoria
+		for ancestor := *parentID; ancestor != ""; {
+			j, ok := s.findCategory(workspaceID, ancestor)
+			if !ok {
+				return store.ErrUnknownCategory
+			}
+			if ancestor == id {
+				return store.ErrCategoryCycle
+			}
+			ancestor = deref(s.categories[j].parentID)
+		}
+	}
+
+	s.categories[i].parentID = optionalString(deref(parentID))
+	return nil
+}
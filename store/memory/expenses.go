@@ -0,0 +1,368 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"go-sheet/store"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// render joins an expense with its category, paid type and status. The
+// second result is false when the category is gone, matching the inner join
+// used by the Postgres store.
+func (s *Store) render(record expenseRecord) (store.Expense, bool) {
+	categoryIndex, ok := s.findCategory(record.workspace, record.categoryID)
+	if !ok {
+		return store.Expense{}, false
+	}
+
+	expense := store.Expense{
+		ExpenseID:      record.id,
+		CategoryName:   s.categories[categoryIndex].name,
+		ReferenceMonth: ptr(record.referenceMonth.Start().Format("2006-01-02")),
+		SpentAmount:    s.spent(record),
+		PlannedAmount:  record.plannedAmount,
+		File:           record.file,
+		Description:    record.description,
+		Currency:       record.currency,
+		OriginalAmount: record.spentAmount,
+		ReceiptKey:     record.receiptKey,
+	}
+	if expense.SpentAmount != nil {
+		expense.Difference = ptr(record.plannedAmount.Sub(*expense.SpentAmount))
+	}
+	if expense.Currency == "" {
+		expense.Currency = s.baseCurrency(record.workspace)
+	}
+	if record.paymentDate != nil {
+		expense.PaymentDate = ptr(record.paymentDate.Format(time.RFC3339Nano))
+	}
+	if record.paidID != nil {
+		for _, paidType := range s.paidTypes {
+			if paidType.ID == *record.paidID {
+				expense.PaidId = ptr(paidType.ID)
+				expense.PaidType = ptr(paidType.Type)
+				expense.PaidColor = ptr(paidType.PaidColor)
+			}
+		}
+	}
+	if record.statusID != nil {
+		for _, status := range s.statuses {
+			if status.ID == *record.statusID {
+				expense.StatusId = ptr(status.ID)
+				expense.StatusName = ptr(status.StatusName)
+			}
+		}
+	}
+
+	return expense, true
+}
+
+// expenseKey is the value an expense is sorted by: amount sorts use num,
+// the others text. Missing dates and amounts sort lowest, like in Postgres.
+type expenseKey struct {
+	num  int64
+	text string
+}
+
+func (k expenseKey) compare(other expenseKey) int {
+	if c := cmp.Compare(k.num, other.num); c != 0 {
+		return c
+	}
+	return strings.Compare(k.text, other.text)
+}
+
+func (k expenseKey) String() string {
+	return strconv.FormatInt(k.num, 10) + ":" + k.text
+}
+
+func parseExpenseKey(s string) (expenseKey, error) {
+	num, text, ok := strings.Cut(s, ":")
+	n, err := strconv.ParseInt(num, 10, 64)
+	if !ok || err != nil {
+		return expenseKey{}, store.ErrInvalidCursor
+	}
+	return expenseKey{num: n, text: text}, nil
+}
+
+func (s *Store) sortKey(record expenseRecord, by store.ExpenseSort) expenseKey {
+	switch by {
+	case store.SortPaymentDate:
+		if record.paymentDate == nil {
+			return expenseKey{}
+		}
+		return expenseKey{text: record.paymentDate.Format("2006-01-02")}
+	case store.SortSpentAmount:
+		spent := s.spent(record)
+		if spent == nil {
+			return expenseKey{num: -1}
+		}
+		return expenseKey{num: spent.Minor()}
+	case store.SortPlannedAmount:
+		return expenseKey{num: record.plannedAmount.Minor()}
+	case store.SortCategoryName:
+		i, _ := s.findCategory(record.workspace, record.categoryID)
+		return expenseKey{text: s.categories[i].name}
+	}
+	return expenseKey{text: record.referenceMonth.Start().Format("2006-01-02")}
+}
+
+// matches applies the filters of query, except the cursor
+func (s *Store) matches(record expenseRecord, query store.ExpenseQuery) bool {
+	if _, ok := s.findCategory(record.workspace, record.categoryID); !ok {
+		return false
+	}
+	if !query.From.IsZero() && record.referenceMonth.Before(query.From) {
+		return false
+	}
+	if !query.To.IsZero() && record.referenceMonth.After(query.To) {
+		return false
+	}
+	if query.CategoryID != "" && record.categoryID != query.CategoryID {
+		return false
+	}
+	if query.PaidID != "" && (record.paidID == nil || *record.paidID != query.PaidID) {
+		return false
+	}
+	if query.StatusID != "" && (record.statusID == nil || *record.statusID != query.StatusID) {
+		return false
+	}
+	if spent := s.spent(record); query.MinAmount != nil && (spent == nil || *spent < *query.MinAmount) ||
+		query.MaxAmount != nil && (spent == nil || *spent > *query.MaxAmount) {
+		return false
+	}
+	if query.Search != "" && (record.description == nil ||
+		!strings.Contains(strings.ToLower(*record.description), strings.ToLower(query.Search))) {
+		return false
+	}
+	return true
+}
+
+func (s *Store) ListExpenses(ctx context.Context, workspaceID string, query store.ExpenseQuery) (store.ExpensePage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query.Normalize()
+	direction := 1
+	if query.Desc {
+		direction = -1
+	}
+
+	type keyed struct {
+		record expenseRecord
+		key    expenseKey
+	}
+	var rows []keyed
+	for _, record := range s.expenses {
+		if record.workspace == workspaceID && s.matches(record, query) {
+			rows = append(rows, keyed{record: record, key: s.sortKey(record, query.Sort)})
+		}
+	}
+	compare := func(a keyed, key expenseKey, id string) int {
+		if c := a.key.compare(key); c != 0 {
+			return c * direction
+		}
+		return strings.Compare(a.record.id, id) * direction
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return compare(rows[i], rows[j].key, rows[j].record.id) < 0
+	})
+
+	if query.Cursor != nil {
+		if !query.Cursor.Matches(query) {
+			return store.ExpensePage{}, store.ErrInvalidCursor
+		}
+		after, err := parseExpenseKey(query.Cursor.Key)
+		if err != nil {
+			return store.ExpensePage{}, err
+		}
+		start := sort.Search(len(rows), func(i int) bool {
+			return compare(rows[i], after, query.Cursor.ID) > 0
+		})
+		rows = rows[start:]
+	}
+
+	page := store.ExpensePage{Expenses: []store.Expense{}}
+	for i, row := range rows {
+		if i == query.Limit {
+			last := rows[i-1]
+			page.NextCursor = store.Cursor{Sort: query.Sort, Desc: query.Desc, Key: last.key.String(), ID: last.record.id}.Encode()
+			break
+		}
+		expense, _ := s.render(row.record)
+		page.Expenses = append(page.Expenses, expense)
+	}
+
+	return page, nil
+}
+
+func (s *Store) GetExpense(ctx context.Context, workspaceID, id string) (store.Expense, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.getExpense(workspaceID, id)
+}
+
+func (s *Store) getExpense(workspaceID, id string) (store.Expense, error) {
+	i, ok := s.findExpense(workspaceID, id)
+	if !ok {
+		return store.Expense{}, store.ErrNotFound
+	}
+	expense, ok := s.render(s.expenses[i])
+	if !ok {
+		return store.Expense{}, store.ErrNotFound
+	}
+	return expense, nil
+}
+
+// keepsArchived tells whether err only means that the expense stays in its
+// archived category, which editing an old expense may do
+func (s *Store) keepsArchived(err error, workspaceID, id, categoryID string) bool {
+	i, ok := s.findExpense(workspaceID, id)
+	return errors.Is(err, store.ErrCategoryArchived) && ok && s.expenses[i].categoryID == categoryID
+}
+
+func (s *Store) CreateExpense(ctx context.Context, workspaceID string, input store.ExpenseInput) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	categoryIndex, err := s.activeCategory(workspaceID, input.CategoryID)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := s.findPaidType(workspaceID, input.PaidID); !ok {
+		return "", store.ErrUnknownPaidType
+	}
+	if input.StatusID != "" {
+		if _, ok := s.findStatus(workspaceID, input.StatusID); !ok {
+			return "", store.ErrUnknownStatus
+		}
+	}
+
+	record := expenseRecord{
+		id:             uuid.NewString(),
+		workspace:      workspaceID,
+		categoryID:     input.CategoryID,
+		referenceMonth: input.ReferenceMonth,
+		spentAmount:    ptr(input.SpentAmount),
+		plannedAmount:  s.categories[categoryIndex].planFor(input.ReferenceMonth),
+		paymentDate:    ptr(input.PaymentDate),
+		file:           ptr(input.File),
+		paidID:         ptr(input.PaidID),
+		statusID:       optionalString(input.StatusID),
+		description:    optionalString(input.Description),
+		currency:       input.Currency,
+	}
+	s.expenses = append(s.expenses, record)
+
+	return record.id, nil
+}
+
+func (s *Store) UpdateExpense(ctx context.Context, workspaceID, id string, input store.ExpenseInput) (store.Expense, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.getExpense(workspaceID, id); err != nil {
+		return store.Expense{}, err
+	}
+	categoryIndex, err := s.activeCategory(workspaceID, input.CategoryID)
+	if err != nil && !s.keepsArchived(err, workspaceID, id, input.CategoryID) {
+		return store.Expense{}, err
+	}
+	if _, ok := s.findPaidType(workspaceID, input.PaidID); !ok {
+		return store.Expense{}, store.ErrUnknownPaidType
+	}
+
+	i, _ := s.findExpense(workspaceID, id)
+	record := &s.expenses[i]
+	record.categoryID = input.CategoryID
+	record.referenceMonth = input.ReferenceMonth
+	record.spentAmount = ptr(input.SpentAmount)
+	record.plannedAmount = s.categories[categoryIndex].planFor(input.ReferenceMonth)
+	record.paymentDate = ptr(input.PaymentDate)
+	record.paidID = ptr(input.PaidID)
+	record.file = ptr(input.File)
+	record.description = optionalString(input.Description)
+	record.currency = input.Currency
+
+	return s.getExpense(workspaceID, id)
+}
+
+func (s *Store) PatchExpense(ctx context.Context, workspaceID, id string, patch store.ExpensePatch) (store.Expense, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.getExpense(workspaceID, id); err != nil {
+		return store.Expense{}, err
+	}
+	if patch.PaidID != nil {
+		if _, ok := s.findPaidType(workspaceID, *patch.PaidID); !ok {
+			return store.Expense{}, store.ErrUnknownPaidType
+		}
+	}
+	if patch.StatusID != nil {
+		if _, ok := s.findStatus(workspaceID, *patch.StatusID); !ok {
+			return store.Expense{}, store.ErrUnknownStatus
+		}
+	}
+
+	i, _ := s.findExpense(workspaceID, id)
+	record := &s.expenses[i]
+
+	if patch.CategoryID != nil {
+		_, err := s.activeCategory(workspaceID, *patch.CategoryID)
+		if err != nil && !s.keepsArchived(err, workspaceID, id, *patch.CategoryID) {
+			return store.Expense{}, err
+		}
+		record.categoryID = *patch.CategoryID
+	}
+	if patch.ReferenceMonth != nil {
+		record.referenceMonth = *patch.ReferenceMonth
+	}
+	if patch.CategoryID != nil || patch.ReferenceMonth != nil {
+		categoryIndex, _ := s.findCategory(workspaceID, record.categoryID)
+		record.plannedAmount = s.categories[categoryIndex].planFor(record.referenceMonth)
+	}
+	if patch.PaymentDate != nil {
+		record.paymentDate = ptr(*patch.PaymentDate)
+	}
+	if patch.SpentAmount != nil {
+		record.spentAmount = ptr(*patch.SpentAmount)
+	}
+	if patch.PaidID != nil {
+		record.paidID = ptr(*patch.PaidID)
+	}
+	if patch.File != nil {
+		record.file = ptr(*patch.File)
+	}
+	if patch.StatusID != nil {
+		record.statusID = ptr(*patch.StatusID)
+	}
+	if patch.Description != nil {
+		record.description = ptr(*patch.Description)
+	}
+	if patch.Currency != nil {
+		record.currency = *patch.Currency
+	}
+
+	return s.getExpense(workspaceID, id)
+}
+
+func (s *Store) DeleteExpense(ctx context.Context, workspaceID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findExpense(workspaceID, id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	s.expenses = append(s.expenses[:i], s.expenses[i+1:]...)
+
+	return nil
+}
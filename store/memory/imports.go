@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/month"
+	"go-sheet/store"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) ExpenseFingerprints(ctx context.Context, workspaceID string, from, to time.Time) ([]store.ExpenseFingerprint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fingerprints := []store.ExpenseFingerprint{}
+	for _, record := range s.expenses {
+		if record.workspace != workspaceID || record.isPlanned || record.spentAmount == nil || record.paymentDate == nil {
+			continue
+		}
+		if record.paymentDate.Before(from) || record.paymentDate.After(to) {
+			continue
+		}
+		fp := store.ExpenseFingerprint{
+			ExpenseID:   record.id,
+			CategoryID:  record.categoryID,
+			PaymentDate: *record.paymentDate,
+			SpentAmount: *record.spentAmount,
+			ImportRef:   record.importRef,
+		}
+		if record.description != nil {
+			fp.Description = *record.description
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+
+	return fingerprints, nil
+}
+
+func (s *Store) ImportExpenses(ctx context.Context, workspaceID string, expenses []store.ImportedExpense) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paid, _ := s.findStatusByName("", "paid")
+	refs := map[string]bool{}
+	for _, record := range s.expenses {
+		if record.workspace == workspaceID && record.importRef != "" {
+			refs[record.importRef] = true
+		}
+	}
+
+	// Tudo é validado antes de inserir, para que a importação seja atômica
+	records := make([]expenseRecord, len(expenses))
+	for i, expense := range expenses {
+		categoryIndex, err := s.activeCategory(workspaceID, expense.CategoryID)
+		if err != nil {
+			return nil, &store.ImportError{Index: i, Err: err}
+		}
+		record := expenseRecord{
+			id:             uuid.NewString(),
+			workspace:      workspaceID,
+			categoryID:     expense.CategoryID,
+			referenceMonth: month.Of(expense.PaymentDate),
+			spentAmount:    ptr(expense.SpentAmount),
+			plannedAmount:  s.categories[categoryIndex].planFor(month.Of(expense.PaymentDate)),
+			paymentDate:    ptr(expense.PaymentDate),
+			statusID:       ptr(paid.ID),
+			description:    optionalString(expense.Description),
+			importRef:      expense.ImportRef,
+		}
+		if expense.PaidID != "" {
+			if _, ok := s.findPaidType(workspaceID, expense.PaidID); !ok {
+				return nil, &store.ImportError{Index: i, Err: store.ErrUnknownPaidType}
+			}
+			record.paidID = ptr(expense.PaidID)
+		}
+		if expense.StatusID != "" {
+			if _, ok := s.findStatus(workspaceID, expense.StatusID); !ok {
+				return nil, &store.ImportError{Index: i, Err: store.ErrUnknownStatus}
+			}
+			record.statusID = ptr(expense.StatusID)
+		}
+		if expense.ImportRef != "" {
+			if refs[expense.ImportRef] {
+				return nil, &store.ImportError{Index: i, Err: store.ErrConflict}
+			}
+			refs[expense.ImportRef] = true
+		}
+		records[i] = record
+	}
+
+	ids := make([]string, len(records))
+	for i, record := range records {
+		ids[i] = record.id
+	}
+	s.expenses = append(s.expenses, records...)
+
+	return ids, nil
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/month"
+	"go-sheet/store"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) findIncome(workspace, id string) (int, bool) {
+	for i := range s.incomes {
+		if s.incomes[i].workspace == workspace && s.incomes[i].IncomeID == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// applyIncome copies the input over an income, formatted as store/postgres
+// scans it; Amount and an empty Currency are filled in by renderIncome
+func applyIncome(income store.Income, input store.IncomeInput) store.Income {
+	income.Source = input.Source
+	income.OriginalAmount = input.Amount
+	income.Currency = input.Currency
+	income.ReferenceMonth = input.ReferenceMonth
+	income.ExpectedDate = input.ExpectedDate.Format("2006-01-02")
+	income.ReceivedDate = nil
+	if input.ReceivedDate != nil {
+		income.ReceivedDate = ptr(input.ReceivedDate.Format("2006-01-02"))
+	}
+	income.Recurring = input.Recurring
+	income.Description = optionalString(input.Description)
+	return income
+}
+
+// renderIncome converts the amount into the base currency with the rate of
+// the day it was received, or else expected
+func (s *Store) renderIncome(record incomeRecord) store.Income {
+	income := record.Income
+	day := income.ExpectedDate
+	if income.ReceivedDate != nil {
+		day = *income.ReceivedDate
+	}
+	on, _ := time.Parse(time.DateOnly, day)
+	income.Amount = nil
+	if amount, ok := s.toBase(record.workspace, income.OriginalAmount, income.Currency, on); ok {
+		income.Amount = &amount
+	}
+	if income.Currency == "" {
+		income.Currency = s.baseCurrency(record.workspace)
+	}
+	return income
+}
+
+func (s *Store) ListIncomes(ctx context.Context, workspaceID string, m month.YearMonth) ([]store.Income, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	incomes := []store.Income{}
+	for _, record := range s.incomes {
+		if record.workspace == workspaceID && (m.IsZero() || record.ReferenceMonth == m) {
+			incomes = append(incomes, s.renderIncome(record))
+		}
+	}
+	sort.SliceStable(incomes, func(i, j int) bool {
+		if incomes[i].ExpectedDate != incomes[j].ExpectedDate {
+			return incomes[i].ExpectedDate < incomes[j].ExpectedDate
+		}
+		return incomes[i].Source < incomes[j].Source
+	})
+	return incomes, nil
+}
+
+func (s *Store) GetIncome(ctx context.Context, workspaceID, id string) (store.Income, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.findIncome(workspaceID, id)
+	if !ok {
+		return store.Income{}, store.ErrNotFound
+	}
+	return s.renderIncome(s.incomes[i]), nil
+}
+
+func (s *Store) CreateIncome(ctx context.Context, workspaceID string, input store.IncomeInput) (store.Income, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := incomeRecord{workspace: workspaceID, Income: applyIncome(store.Income{IncomeID: uuid.NewString(), CreatedAt: s.now()}, input)}
+	s.incomes = append(s.incomes, record)
+
+	return s.renderIncome(record), nil
+}
+
+func (s *Store) UpdateIncome(ctx context.Context, workspaceID, id string, input store.IncomeInput) (store.Income, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findIncome(workspaceID, id)
+	if !ok {
+		return store.Income{}, store.ErrNotFound
+	}
+	s.incomes[i].Income = applyIncome(s.incomes[i].Income, input)
+
+	return s.renderIncome(s.incomes[i]), nil
+}
+
+func (s *Store) DeleteIncome(ctx context.Context, workspaceID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findIncome(workspaceID, id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	s.incomes = append(s.incomes[:i], s.incomes[i+1:]...)
+	return nil
+}
@@ -0,0 +1,215 @@
+// Package memory implements the store interfaces in process. It mirrors the
+// behaviour of store/postgres closely enough to back handler tests and local
+// experiments without a database.
+package memory
+
+import (
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store keeps every table in slices guarded by a single mutex. Each record
+// carries the workspace it belongs to; an empty workspace marks the shared defaults.
+type Store struct {
+	mu  sync.RWMutex
+	now func() time.Time
+	// currency is the base currency of workspaces that never chose one
+	currency money.Currency
+
+	categories []categoryRecord
+	expenses   []expenseRecord
+	paidTypes  []paidTypeRecord
+	statuses   []statusRecord
+
+	workspaces []workspaceRecord
+	members    []memberRecord
+	invites    []store.Invite
+
+	rules []ruleRecord
+
+	alerts []store.Alert
+
+	recurring []store.RecurringExpense
+
+	incomes []incomeRecord
+
+	rates []rateRecord
+}
+
+var _ store.Store = (*Store)(nil)
+
+type categoryRecord struct {
+	id            string
+	workspace     string
+	name          string
+	parentID      *string
+	archivedAt    *time.Time
+	plannedAmount money.Amount
+	plans         []store.CategoryPlan // oldest first
+	color         string
+	description   string
+	createdAt     time.Time
+	thresholds    []int
+}
+
+type expenseRecord struct {
+	id             string
+	workspace      string
+	categoryID     string
+	referenceMonth month.YearMonth
+	spentAmount    *money.Amount
+	plannedAmount  money.Amount
+	paymentDate    *time.Time
+	file           *string
+	paidID         *string
+	statusID       *string
+	description    *string
+	importRef      string
+	receiptKey     string
+	isPlanned      bool
+	recurringID    string
+	occurrenceDate time.Time
+	// currency of spentAmount; empty for the base currency
+	currency money.Currency
+}
+
+type paidTypeRecord struct {
+	workspace string
+	store.PaidType
+}
+
+type statusRecord struct {
+	workspace string
+	store.Status
+}
+
+type incomeRecord struct {
+	workspace string
+	store.Income
+}
+
+type ruleRecord struct {
+	workspace string
+	createdAt time.Time
+	store.Rule
+}
+
+type workspaceRecord struct {
+	id           string
+	name         string
+	baseCurrency money.Currency
+	createdAt    time.Time
+}
+
+type memberRecord struct {
+	workspace string
+	store.Member
+}
+
+// New returns an empty Store seeded with the default statuses, like the
+// initial migration does
+func New() *Store {
+	return &Store{
+		now:      time.Now,
+		currency: money.DefaultCurrency,
+		statuses: []statusRecord{
+			{Status: store.Status{ID: uuid.NewString(), StatusName: "pending"}},
+			{Status: store.Status{ID: uuid.NewString(), StatusName: "paid"}},
+		},
+	}
+}
+
+// SetClock replaces the time source, so tests can pin the current month
+func (s *Store) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+func (s *Store) findCategory(workspace, id string) (int, bool) {
+	for i := range s.categories {
+		if s.categories[i].workspace == workspace && s.categories[i].id == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// activeCategory is findCategory for rows about to point at the category,
+// which archived categories no longer take
+func (s *Store) activeCategory(workspace, id string) (int, error) {
+	i, ok := s.findCategory(workspace, id)
+	if !ok {
+		return -1, store.ErrUnknownCategory
+	}
+	if s.categories[i].archivedAt != nil {
+		return i, store.ErrCategoryArchived
+	}
+	return i, nil
+}
+
+func (s *Store) findExpense(workspace, id string) (int, bool) {
+	for i := range s.expenses {
+		if s.expenses[i].workspace == workspace && s.expenses[i].id == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func (s *Store) findPaidType(workspace, id string) (int, bool) {
+	for i := range s.paidTypes {
+		if s.paidTypes[i].workspace == workspace && s.paidTypes[i].ID == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// findStatus looks among the workspace's statuses and the shared defaults
+func (s *Store) findStatus(workspace, id string) (int, bool) {
+	for i, status := range s.statuses {
+		if status.visibleTo(workspace) && status.ID == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func (s *Store) findStatusByName(workspace, name string) (store.Status, bool) {
+	for _, status := range s.statuses {
+		if status.visibleTo(workspace) && status.StatusName == name {
+			return status.Status, true
+		}
+	}
+	return store.Status{}, false
+}
+
+func (r statusRecord) visibleTo(workspace string) bool {
+	return r.workspace == "" || r.workspace == workspace
+}
+
+// optionalString stores "" as NULL, as NULLIF does in store/postgres
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// deref returns "" for nil
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/store"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) ListPaidTypes(ctx context.Context, workspaceID string) ([]store.PaidType, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	paidTypes := []store.PaidType{}
+	for _, paidType := range s.paidTypes {
+		if paidType.workspace == workspaceID {
+			paidTypes = append(paidTypes, paidType.PaidType)
+		}
+	}
+
+	return paidTypes, nil
+}
+
+func (s *Store) CreatePaidType(ctx context.Context, workspaceID string, paidType store.PaidType) (store.PaidType, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paidType.ID = uuid.NewString()
+	paidType.CreatedAt = s.now().Format(time.RFC3339Nano)
+	s.paidTypes = append(s.paidTypes, paidTypeRecord{workspace: workspaceID, PaidType: paidType})
+
+	return paidType, nil
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"slices"
+)
+
+// planFor returns the plan effective in month m: the latest one from m or
+// before, else the first one, as planned_amount does
+func (record categoryRecord) planFor(m month.YearMonth) money.Amount {
+	if len(record.plans) == 0 {
+		return record.plannedAmount
+	}
+	amount := record.plans[0].PlannedAmount
+	for _, plan := range record.plans {
+		if plan.Month.After(m) {
+			break
+		}
+		amount = plan.PlannedAmount
+	}
+	return amount
+}
+
+// setPlan saves the plan of month m of the i-th category and copies the
+// plans back into its monthly rows
+func (s *Store) setPlan(i int, m month.YearMonth, amount money.Amount) {
+	record := &s.categories[i]
+	j, found := slices.BinarySearchFunc(record.plans, m, func(plan store.CategoryPlan, m month.YearMonth) int {
+		switch {
+		case plan.Month.Before(m):
+			return -1
+		case plan.Month.After(m):
+			return 1
+		}
+		return 0
+	})
+	if found {
+		record.plans[j].PlannedAmount = amount
+	} else {
+		record.plans = slices.Insert(record.plans, j, store.CategoryPlan{Month: m, PlannedAmount: amount})
+	}
+
+	for k := range s.expenses {
+		if s.expenses[k].categoryID == record.id {
+			s.expenses[k].plannedAmount = record.planFor(s.expenses[k].referenceMonth)
+		}
+	}
+}
+
+func (s *Store) ListPlans(ctx context.Context, workspaceID, categoryID string) ([]store.CategoryPlan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.findCategory(workspaceID, categoryID)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return append([]store.CategoryPlan{}, s.categories[i].plans...), nil
+}
+
+func (s *Store) SetPlan(ctx context.Context, workspaceID, categoryID string, m month.YearMonth, amount money.Amount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findCategory(workspaceID, categoryID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	s.setPlan(i, m, amount)
+	return nil
+}
+
+func (s *Store) PlannedAmounts(ctx context.Context, workspaceID string, m month.YearMonth) (map[string]money.Amount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	amounts := map[string]money.Amount{}
+	for _, record := range s.categories {
+		if record.workspace == workspaceID {
+			amounts[record.id] = record.planFor(m)
+		}
+	}
+	return amounts, nil
+}
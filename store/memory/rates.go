@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"go-sheet/money"
+	"go-sheet/store"
+	"slices"
+	"time"
+)
+
+type rateRecord struct {
+	workspace string
+	store.ExchangeRate
+}
+
+// SetCurrency replaces the base currency of workspaces that never set their
+// own, like the currency given to postgres.New
+func (s *Store) SetCurrency(currency money.Currency) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currency = currency
+}
+
+func (s *Store) baseCurrency(workspaceID string) money.Currency {
+	if i, ok := s.findWorkspace(workspaceID); ok && s.workspaces[i].baseCurrency != "" {
+		return s.workspaces[i].baseCurrency
+	}
+	return s.currency
+}
+
+// toBase mirrors to_base_currency: an amount without a currency is already
+// in the base one, and the others use the latest rate on or before the date,
+// dividing by the opposite pair's rate when only that one is known
+func (s *Store) toBase(workspaceID string, amount money.Amount, currency money.Currency, on time.Time) (money.Amount, bool) {
+	base := s.baseCurrency(workspaceID)
+	if currency == "" || currency == base {
+		return amount, true
+	}
+
+	day := on.Format(time.DateOnly)
+	var found *rateRecord
+	for i := range s.rates {
+		r := &s.rates[i]
+		if r.workspace != workspaceID || r.Date > day || !(r.From == currency && r.To == base || r.From == base && r.To == currency) {
+			continue
+		}
+		// Na mesma data, a cotação direta tem preferência sobre a inversa
+		if found == nil || r.Date > found.Date || r.Date == found.Date && r.From == currency {
+			found = r
+		}
+	}
+	if found == nil {
+		return 0, false
+	}
+	if found.From == currency {
+		return found.Rate.Convert(amount), true
+	}
+	return found.Rate.ConvertBack(amount), true
+}
+
+// spent is the spent amount of an expense in the base currency, nil when it
+// has none or no rate covers its payment date
+func (s *Store) spent(record expenseRecord) *money.Amount {
+	if record.spentAmount == nil {
+		return nil
+	}
+	on := record.referenceMonth.Start()
+	if record.paymentDate != nil {
+		on = *record.paymentDate
+	}
+	amount, ok := s.toBase(record.workspace, *record.spentAmount, record.currency, on)
+	if !ok {
+		return nil
+	}
+	return &amount
+}
+
+// unconverted tells whether spent drops the spent amount of an expense for
+// want of a rate, as unconvertedCount counts in store/postgres
+func (s *Store) unconverted(record expenseRecord) bool {
+	return record.spentAmount != nil && s.spent(record) == nil
+}
+
+func (s *Store) BaseCurrency(ctx context.Context, workspaceID string) (money.Currency, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.findWorkspace(workspaceID); !ok {
+		return "", store.ErrNotFound
+	}
+	return s.baseCurrency(workspaceID), nil
+}
+
+func (s *Store) SetBaseCurrency(ctx context.Context, workspaceID string, currency money.Currency) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.findWorkspace(workspaceID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	previous := s.baseCurrency(workspaceID)
+	if previous == currency {
+		return nil
+	}
+
+	for i := range s.expenses {
+		if s.expenses[i].workspace == workspaceID && s.expenses[i].currency == "" {
+			s.expenses[i].currency = previous
+		}
+	}
+	for i := range s.incomes {
+		if s.incomes[i].workspace == workspaceID && s.incomes[i].Currency == "" {
+			s.incomes[i].Currency = previous
+		}
+	}
+	for i := range s.recurring {
+		if s.recurring[i].WorkspaceID == workspaceID && s.recurring[i].Currency == "" {
+			s.recurring[i].Currency = previous
+		}
+	}
+	s.workspaces[w].baseCurrency = currency
+
+	return nil
+}
+
+func (s *Store) ImportRates(ctx context.Context, workspaceID string, rates []store.ExchangeRate) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rate := range rates {
+		i := slices.IndexFunc(s.rates, func(r rateRecord) bool {
+			return r.workspace == workspaceID && r.From == rate.From && r.To == rate.To && r.Date == rate.Date
+		})
+		if i >= 0 {
+			s.rates[i].Rate = rate.Rate
+			continue
+		}
+		s.rates = append(s.rates, rateRecord{workspace: workspaceID, ExchangeRate: rate})
+	}
+
+	return len(rates), nil
+}
+
+func (s *Store) ListRates(ctx context.Context, workspaceID string, from, to money.Currency) ([]store.ExchangeRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rates := []store.ExchangeRate{}
+	for _, r := range s.rates {
+		if r.workspace == workspaceID && (from == "" || r.From == from) && (to == "" || r.To == to) {
+			rates = append(rates, r.ExchangeRate)
+		}
+	}
+	slices.SortFunc(rates, func(a, b store.ExchangeRate) int {
+		if a.Date != b.Date {
+			return cmp.Compare(b.Date, a.Date)
+		}
+		if a.From != b.From {
+			return cmp.Compare(a.From, b.From)
+		}
+		return cmp.Compare(a.To, b.To)
+	})
+
+	return rates, nil
+}
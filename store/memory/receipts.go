@@ -0,0 +1,20 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/store"
+)
+
+func (s *Store) SetReceipt(ctx context.Context, workspaceID, expenseID, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findExpense(workspaceID, expenseID)
+	if !ok {
+		return "", store.ErrNotFound
+	}
+	previous := s.expenses[i].receiptKey
+	s.expenses[i].receiptKey = key
+
+	return previous, nil
+}
@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/month"
+	"go-sheet/store"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) findRecurring(workspace, id string) (int, bool) {
+	for i := range s.recurring {
+		if s.recurring[i].WorkspaceID == workspace && s.recurring[i].ID == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// renderRecurring fills in the category name and copies the exceptions, so
+// callers never share them with the store
+func (s *Store) renderRecurring(r store.RecurringExpense) store.RecurringExpense {
+	if i, ok := s.findCategory(r.WorkspaceID, r.CategoryID); ok {
+		r.CategoryName = s.categories[i].name
+	}
+	r.Exceptions = append([]store.OccurrenceException{}, r.Exceptions...)
+	return r
+}
+
+func (s *Store) checkRecurringReferences(workspaceID string, r store.RecurringExpense) error {
+	if _, err := s.activeCategory(workspaceID, r.CategoryID); err != nil {
+		return err
+	}
+	if r.PaidID != nil {
+		if _, ok := s.findPaidType(workspaceID, *r.PaidID); !ok {
+			return store.ErrUnknownPaidType
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListRecurring(ctx context.Context, workspaceID string) ([]store.RecurringExpense, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := []store.RecurringExpense{}
+	for _, r := range s.recurring {
+		if r.WorkspaceID == workspaceID {
+			list = append(list, s.renderRecurring(r))
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if !list[i].StartDate.Equal(list[j].StartDate) {
+			return list[i].StartDate.Before(list[j].StartDate)
+		}
+		return list[i].CategoryName < list[j].CategoryName
+	})
+	return list, nil
+}
+
+func (s *Store) GetRecurring(ctx context.Context, workspaceID, id string) (store.RecurringExpense, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.findRecurring(workspaceID, id)
+	if !ok {
+		return store.RecurringExpense{}, store.ErrNotFound
+	}
+	return s.renderRecurring(s.recurring[i]), nil
+}
+
+func (s *Store) CreateRecurring(ctx context.Context, workspaceID string, r store.RecurringExpense) (store.RecurringExpense, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRecurringReferences(workspaceID, r); err != nil {
+		return store.RecurringExpense{}, err
+	}
+
+	r.ID, r.WorkspaceID, r.CreatedAt = uuid.NewString(), workspaceID, s.now()
+	r.MaterializedThrough, r.Exceptions = nil, nil
+	s.recurring = append(s.recurring, r)
+
+	return s.renderRecurring(r), nil
+}
+
+func (s *Store) UpdateRecurring(ctx context.Context, workspaceID, id string, r store.RecurringExpense) (store.RecurringExpense, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findRecurring(workspaceID, id)
+	if !ok {
+		return store.RecurringExpense{}, store.ErrNotFound
+	}
+	if err := s.checkRecurringReferences(workspaceID, r); err != nil {
+		return store.RecurringExpense{}, err
+	}
+
+	current := s.recurring[i]
+	r.ID, r.WorkspaceID, r.CreatedAt = id, workspaceID, current.CreatedAt
+	r.MaterializedThrough, r.Exceptions = current.MaterializedThrough, current.Exceptions
+	s.recurring[i] = r
+
+	return s.renderRecurring(r), nil
+}
+
+func (s *Store) DeleteRecurring(ctx context.Context, workspaceID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findRecurring(workspaceID, id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	s.recurring = append(s.recurring[:i], s.recurring[i+1:]...)
+
+	// ON DELETE SET NULL em monthly_expenses.recurring_id
+	for j := range s.expenses {
+		if s.expenses[j].recurringID == id {
+			s.expenses[j].recurringID, s.expenses[j].occurrenceDate = "", time.Time{}
+		}
+	}
+	return nil
+}
+
+func (s *Store) SetOccurrenceException(ctx context.Context, workspaceID, recurringID string, e store.OccurrenceException) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findRecurring(workspaceID, recurringID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if e.PaidID != nil {
+		if _, ok := s.findPaidType(workspaceID, *e.PaidID); !ok {
+			return store.ErrUnknownPaidType
+		}
+	}
+
+	exceptions := slices.DeleteFunc(slices.Clone(s.recurring[i].Exceptions), func(other store.OccurrenceException) bool {
+		return other.Date.Equal(e.Date)
+	})
+	exceptions = append(exceptions, e)
+	sort.Slice(exceptions, func(a, b int) bool { return exceptions[a].Date.Before(exceptions[b].Date) })
+	s.recurring[i].Exceptions = exceptions
+
+	return nil
+}
+
+func (s *Store) DeleteOccurrenceException(ctx context.Context, workspaceID, recurringID string, date time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findRecurring(workspaceID, recurringID)
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	exceptions := s.recurring[i].Exceptions
+	j := slices.IndexFunc(exceptions, func(e store.OccurrenceException) bool { return e.Date.Equal(date) })
+	if j < 0 {
+		return store.ErrNotFound
+	}
+	s.recurring[i].Exceptions = slices.Delete(slices.Clone(exceptions), j, j+1)
+
+	return nil
+}
+
+func (s *Store) DueRecurring(ctx context.Context, through time.Time) ([]store.RecurringExpense, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := []store.RecurringExpense{}
+	for _, r := range s.recurring {
+		if r.StartDate.After(through) {
+			continue
+		}
+		if c, ok := s.findCategory(r.WorkspaceID, r.CategoryID); ok && s.categories[c].archivedAt != nil {
+			continue
+		}
+		if m := r.MaterializedThrough; m != nil && (!m.Before(through) || r.EndDate != nil && !m.Before(*r.EndDate)) {
+			continue
+		}
+		list = append(list, s.renderRecurring(r))
+	}
+	return list, nil
+}
+
+func (s *Store) MaterializeRecurring(ctx context.Context, r store.RecurringExpense, occurrences []store.Occurrence, through time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findRecurring(r.WorkspaceID, r.ID)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	categoryIndex, err := s.activeCategory(r.WorkspaceID, r.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	pending, _ := s.findStatusByName("", "pending")
+
+	materialized := s.recurring[i].MaterializedThrough
+	ids := []string{}
+	for _, occurrence := range occurrences {
+		if materialized != nil && !occurrence.Date.After(*materialized) || s.hasOccurrence(r.ID, occurrence.Date) {
+			continue
+		}
+		record := expenseRecord{
+			id:             uuid.NewString(),
+			workspace:      r.WorkspaceID,
+			categoryID:     r.CategoryID,
+			referenceMonth: month.Of(occurrence.Date),
+			spentAmount:    ptr(occurrence.Amount),
+			plannedAmount:  s.categories[categoryIndex].planFor(month.Of(occurrence.Date)),
+			paymentDate:    ptr(occurrence.Date),
+			paidID:         occurrence.PaidID,
+			statusID:       ptr(pending.ID),
+			description:    optionalString(occurrence.Description),
+			recurringID:    r.ID,
+			occurrenceDate: occurrence.Date,
+			currency:       occurrence.Currency,
+		}
+		s.expenses = append(s.expenses, record)
+		ids = append(ids, record.id)
+	}
+
+	if materialized == nil || through.After(*materialized) {
+		s.recurring[i].MaterializedThrough = ptr(through)
+	}
+	return ids, nil
+}
+
+// hasOccurrence mirrors the unique index on (recurring_id, occurrence_date)
+func (s *Store) hasOccurrence(recurringID string, date time.Time) bool {
+	for _, expense := range s.expenses {
+		if expense.recurringID == recurringID && expense.occurrenceDate.Equal(date) {
+			return true
+		}
+	}
+	return false
+}
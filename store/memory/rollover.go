@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/month"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) EnsurePlannedExpenses(ctx context.Context, m month.YearMonth) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// category ids are unique across workspaces, so they key the lookup alone
+	planned := map[string]bool{}
+	for _, record := range s.expenses {
+		if record.isPlanned && record.referenceMonth == m {
+			planned[record.categoryID] = true
+		}
+	}
+
+	var statusID *string
+	if pending, ok := s.findStatusByName("", "pending"); ok {
+		statusID = ptr(pending.ID)
+	}
+
+	created := 0
+	for _, category := range s.categories {
+		if planned[category.id] || category.archivedAt != nil || month.Of(category.createdAt).After(m) {
+			continue
+		}
+		s.expenses = append(s.expenses, expenseRecord{
+			id:             uuid.NewString(),
+			workspace:      category.workspace,
+			categoryID:     category.id,
+			referenceMonth: m,
+			plannedAmount:  category.planFor(m),
+			statusID:       statusID,
+			description:    ptr(category.description),
+			isPlanned:      true,
+		})
+		created++
+	}
+
+	return created, nil
+}
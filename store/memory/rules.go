@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/store"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) findRule(workspace, id string) (int, bool) {
+	for i := range s.rules {
+		if s.rules[i].workspace == workspace && s.rules[i].ID == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// checkRuleReferences makes sure every id of the rule belongs to the workspace
+func (s *Store) checkRuleReferences(workspaceID string, rule store.Rule) error {
+	if id := rule.Set.CategoryID; id != nil {
+		if _, err := s.activeCategory(workspaceID, *id); err != nil {
+			return err
+		}
+	}
+	for _, id := range []*string{rule.Match.PaidID, rule.Set.PaidID} {
+		if id != nil {
+			if _, ok := s.findPaidType(workspaceID, *id); !ok {
+				return store.ErrUnknownPaidType
+			}
+		}
+	}
+	if id := rule.Set.StatusID; id != nil {
+		if _, ok := s.findStatus(workspaceID, *id); !ok {
+			return store.ErrUnknownStatus
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListRules(ctx context.Context, workspaceID string) ([]store.Rule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []ruleRecord
+	for _, record := range s.rules {
+		if record.workspace == workspaceID {
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Priority != records[j].Priority {
+			return records[i].Priority < records[j].Priority
+		}
+		return records[i].createdAt.Before(records[j].createdAt)
+	})
+
+	rules := make([]store.Rule, len(records))
+	for i, record := range records {
+		rules[i] = record.Rule
+	}
+	return rules, nil
+}
+
+func (s *Store) GetRule(ctx context.Context, workspaceID, id string) (store.Rule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.findRule(workspaceID, id)
+	if !ok {
+		return store.Rule{}, store.ErrNotFound
+	}
+	return s.rules[i].Rule, nil
+}
+
+func (s *Store) CreateRule(ctx context.Context, workspaceID string, rule store.Rule) (store.Rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRuleReferences(workspaceID, rule); err != nil {
+		return store.Rule{}, err
+	}
+
+	now := s.now()
+	rule.ID = uuid.NewString()
+	rule.CreatedAt = now.Format(time.RFC3339Nano)
+	s.rules = append(s.rules, ruleRecord{workspace: workspaceID, createdAt: now, Rule: rule})
+
+	return rule, nil
+}
+
+func (s *Store) UpdateRule(ctx context.Context, workspaceID, id string, rule store.Rule) (store.Rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findRule(workspaceID, id)
+	if !ok {
+		return store.Rule{}, store.ErrNotFound
+	}
+	if err := s.checkRuleReferences(workspaceID, rule); err != nil {
+		return store.Rule{}, err
+	}
+
+	rule.ID, rule.CreatedAt = id, s.rules[i].CreatedAt
+	s.rules[i].Rule = rule
+
+	return rule, nil
+}
+
+func (s *Store) DeleteRule(ctx context.Context, workspaceID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findRule(workspaceID, id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	s.rules = append(s.rules[:i], s.rules[i+1:]...)
+
+	return nil
+}
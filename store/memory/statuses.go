@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/store"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) ListStatuses(ctx context.Context, workspaceID string) ([]store.Status, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var statuses []store.Status
+	for _, status := range s.statuses {
+		if status.visibleTo(workspaceID) {
+			statuses = append(statuses, status.Status)
+		}
+	}
+
+	return statuses, nil
+}
+
+func (s *Store) CreateStatus(ctx context.Context, workspaceID, name string) (store.Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.findStatusByName(workspaceID, name); exists {
+		return store.Status{StatusName: name}, store.ErrConflict
+	}
+
+	status := store.Status{ID: uuid.NewString(), StatusName: name}
+	s.statuses = append(s.statuses, statusRecord{workspace: workspaceID, Status: status})
+
+	return status, nil
+}
+
+func (s *Store) DeleteStatus(ctx context.Context, workspaceID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findStatus(workspaceID, id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if s.statuses[i].workspace == "" {
+		return store.ErrSystemStatus
+	}
+	s.statuses = append(s.statuses[:i], s.statuses[i+1:]...)
+
+	// ON DELETE SET NULL
+	for i := range s.expenses {
+		if s.expenses[i].statusID != nil && *s.expenses[i].statusID == id {
+			s.expenses[i].statusID = nil
+		}
+	}
+	for i := range s.rules {
+		if id == deref(s.rules[i].Set.StatusID) {
+			s.rules[i].Set.StatusID = nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"context"
+	"go-sheet/store"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) findWorkspace(id string) (int, bool) {
+	for i := range s.workspaces {
+		if s.workspaces[i].id == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func (s *Store) findMember(workspaceID, userID string) (int, bool) {
+	for i := range s.members {
+		if s.members[i].workspace == workspaceID && s.members[i].UserID == userID {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// checkOwners mirrors the Postgres store: the only owner cannot step down
+func (s *Store) checkOwners(workspaceID, userID string) error {
+	owners := 0
+	for _, member := range s.members {
+		if member.workspace == workspaceID && member.Role == store.RoleOwner {
+			owners++
+		}
+	}
+	i, _ := s.findMember(workspaceID, userID)
+	if s.members[i].Role == store.RoleOwner && owners == 1 {
+		return store.ErrLastOwner
+	}
+	return nil
+}
+
+func (s *Store) addMember(workspaceID, userID, email string, role store.Role) {
+	s.members = append(s.members, memberRecord{
+		workspace: workspaceID,
+		Member: store.Member{
+			UserID:    userID,
+			Email:     email,
+			Role:      role,
+			CreatedAt: s.now().Format(time.RFC3339Nano),
+		},
+	})
+}
+
+func (s *Store) EnsurePersonalWorkspace(ctx context.Context, userID, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.findWorkspace(userID); ok {
+		return nil
+	}
+	s.workspaces = append(s.workspaces, workspaceRecord{id: userID, name: "Personal", createdAt: s.now()})
+	s.addMember(userID, userID, email, store.RoleOwner)
+
+	return nil
+}
+
+func (s *Store) ListWorkspaces(ctx context.Context, userID string) ([]store.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workspaces := []store.Workspace{}
+	for _, workspace := range s.workspaces {
+		if i, ok := s.findMember(workspace.id, userID); ok {
+			workspaces = append(workspaces, store.Workspace{
+				ID:           workspace.id,
+				Name:         workspace.name,
+				Role:         s.members[i].Role,
+				BaseCurrency: s.baseCurrency(workspace.id),
+				CreatedAt:    workspace.createdAt.Format(time.RFC3339Nano),
+			})
+		}
+	}
+
+	return workspaces, nil
+}
+
+func (s *Store) CreateWorkspace(ctx context.Context, userID, email, name string) (store.Workspace, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := workspaceRecord{id: uuid.NewString(), name: name, createdAt: s.now()}
+	s.workspaces = append(s.workspaces, record)
+	s.addMember(record.id, userID, email, store.RoleOwner)
+
+	return store.Workspace{
+		ID:           record.id,
+		Name:         record.name,
+		Role:         store.RoleOwner,
+		BaseCurrency: s.currency,
+		CreatedAt:    record.createdAt.Format(time.RFC3339Nano),
+	}, nil
+}
+
+func (s *Store) MemberRole(ctx context.Context, workspaceID, userID string) (store.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.findMember(workspaceID, userID)
+	if !ok {
+		return "", store.ErrNotFound
+	}
+
+	return s.members[i].Role, nil
+}
+
+func (s *Store) ListMembers(ctx context.Context, workspaceID string) ([]store.Member, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	members := []store.Member{}
+	for _, member := range s.members {
+		if member.workspace == workspaceID {
+			members = append(members, member.Member)
+		}
+	}
+
+	return members, nil
+}
+
+func (s *Store) SetMemberRole(ctx context.Context, workspaceID, userID string, role store.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findMember(workspaceID, userID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if role != store.RoleOwner {
+		if err := s.checkOwners(workspaceID, userID); err != nil {
+			return err
+		}
+	}
+	s.members[i].Role = role
+
+	return nil
+}
+
+func (s *Store) RemoveMember(ctx context.Context, workspaceID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findMember(workspaceID, userID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if err := s.checkOwners(workspaceID, userID); err != nil {
+		return err
+	}
+	s.members = append(s.members[:i], s.members[i+1:]...)
+
+	return nil
+}
+
+func (s *Store) CreateInvite(ctx context.Context, workspaceID, invitedBy, email string, role store.Role) (store.Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, member := range s.members {
+		if member.workspace == workspaceID && strings.EqualFold(member.Email, email) {
+			return store.Invite{}, store.ErrConflict
+		}
+	}
+	for _, invite := range s.invites {
+		if invite.WorkspaceID == workspaceID && strings.EqualFold(invite.Email, email) {
+			return store.Invite{}, store.ErrConflict
+		}
+	}
+
+	invite := store.Invite{
+		ID:          uuid.NewString(),
+		WorkspaceID: workspaceID,
+		Email:       email,
+		Role:        role,
+		InvitedBy:   invitedBy,
+		CreatedAt:   s.now().Format(time.RFC3339Nano),
+	}
+	s.invites = append(s.invites, invite)
+
+	return invite, nil
+}
+
+func (s *Store) ListInvites(ctx context.Context, workspaceID string) ([]store.Invite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	invites := []store.Invite{}
+	for _, invite := range s.invites {
+		if invite.WorkspaceID == workspaceID {
+			invites = append(invites, invite)
+		}
+	}
+
+	return invites, nil
+}
+
+func (s *Store) ListInvitesFor(ctx context.Context, email string) ([]store.Invite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	invites := []store.Invite{}
+	for _, invite := range s.invites {
+		if strings.EqualFold(invite.Email, email) {
+			invites = append(invites, invite)
+		}
+	}
+
+	return invites, nil
+}
+
+func (s *Store) DeleteInvite(ctx context.Context, workspaceID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, invite := range s.invites {
+		if invite.WorkspaceID == workspaceID && invite.ID == id {
+			s.invites = append(s.invites[:i], s.invites[i+1:]...)
+			return nil
+		}
+	}
+
+	return store.ErrNotFound
+}
+
+func (s *Store) AcceptInvite(ctx context.Context, id, userID, email string) (store.Workspace, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, invite := range s.invites {
+		if invite.ID != id || email == "" || !strings.EqualFold(invite.Email, email) {
+			continue
+		}
+		s.invites = append(s.invites[:i], s.invites[i+1:]...)
+		if _, ok := s.findMember(invite.WorkspaceID, userID); !ok {
+			s.addMember(invite.WorkspaceID, userID, email, invite.Role)
+		}
+
+		w, _ := s.findWorkspace(invite.WorkspaceID)
+		m, _ := s.findMember(invite.WorkspaceID, userID)
+		return store.Workspace{
+			ID:           s.workspaces[w].id,
+			Name:         s.workspaces[w].name,
+			Role:         s.members[m].Role,
+			BaseCurrency: s.baseCurrency(invite.WorkspaceID),
+			CreatedAt:    s.workspaces[w].createdAt.Format(time.RFC3339Nano),
+		}, nil
+	}
+
+	return store.Workspace{}, store.ErrNotFound
+}
@@ -0,0 +1,203 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-sheet/store"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const alertColumns = `a.alert_id, a.workspace_id, a.category_id, c.category_name, a.reference_month, a.threshold,
+	a.spent_amount, a.planned_amount, a.created_at, a.acknowledged_at, a.acknowledged_by`
+
+func scanAlert(row rowScanner) (store.Alert, error) {
+	var alert store.Alert
+	var acknowledgedAt sql.NullTime
+	var acknowledgedBy sql.NullString
+	err := row.Scan(&alert.ID, &alert.WorkspaceID, &alert.CategoryID, &alert.CategoryName, &alert.ReferenceMonth, &alert.Threshold,
+		&alert.SpentAmount, &alert.PlannedAmount, &alert.CreatedAt, &acknowledgedAt, &acknowledgedBy)
+	if err != nil {
+		return alert, err
+	}
+
+	if acknowledgedAt.Valid {
+		alert.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	alert.AcknowledgedBy = nullString(acknowledgedBy)
+	return alert, nil
+}
+
+func (s *Store) AlertThresholds(ctx context.Context, workspaceID, categoryID string) ([]int, error) {
+	if err := s.checkCategory(ctx, workspaceID, categoryID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT percent FROM budget_thresholds WHERE category_id = $1 ORDER BY percent`, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	percents := []int{}
+	for rows.Next() {
+		var percent int
+		if err := rows.Scan(&percent); err != nil {
+			return nil, err
+		}
+		percents = append(percents, percent)
+	}
+
+	return percents, rows.Err()
+}
+
+func (s *Store) SetAlertThresholds(ctx context.Context, workspaceID, categoryID string, percents []int) error {
+	if err := s.checkCategory(ctx, workspaceID, categoryID); err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM budget_thresholds WHERE category_id = $1`, categoryID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO budget_thresholds (category_id, percent)
+			SELECT $1, unnest($2::int[])
+			ON CONFLICT DO NOTHING`, categoryID, pq.Array(percents))
+		return err
+	})
+}
+
+func (s *Store) checkCategory(ctx context.Context, workspaceID, categoryID string) error {
+	if !isUUID(categoryID) {
+		return store.ErrNotFound
+	}
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE category_id = $1 AND workspace_id = $2)`,
+		categoryID, workspaceID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) EvaluateAlerts(ctx context.Context, workspaceID string, expenseIDs []string) ([]store.Alert, error) {
+	ids := make([]string, 0, len(expenseIDs))
+	for _, id := range expenseIDs {
+		if isUUID(id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return []store.Alert{}, nil
+	}
+
+	// O planejado do mês vem da linha planejada; sem ela, do plano vigente no mês
+	rows, err := s.db.QueryContext(ctx, `
+		WITH touched AS (
+			SELECT DISTINCT category_id, reference_month
+			FROM monthly_expenses
+			WHERE workspace_id = $1 AND expense_id = ANY($2::uuid[]) AND category_id IS NOT NULL
+		), usage AS (
+			SELECT
+				t.category_id,
+				t.reference_month,
+				COALESCE(SUM(`+spentInBase("$3")+`), 0) AS spent,
+				COALESCE(SUM(me.amount_planned) FILTER (WHERE me.is_planned), MAX(planned_amount(c.category_id, t.reference_month))) AS planned
+			FROM touched t
+			JOIN categories c ON c.category_id = t.category_id
+			JOIN monthly_expenses me ON me.workspace_id = $1 AND me.category_id = t.category_id AND me.reference_month = t.reference_month
+			GROUP BY t.category_id, t.reference_month
+		), inserted AS (
+			INSERT INTO budget_alerts (workspace_id, category_id, reference_month, threshold, spent_amount, planned_amount)
+			SELECT $1, u.category_id, u.reference_month, bt.percent, u.spent, u.planned
+			FROM usage u
+			JOIN budget_thresholds bt ON bt.category_id = u.category_id
+			WHERE u.planned > 0 AND u.spent * 100 >= u.planned * bt.percent
+			ON CONFLICT (category_id, reference_month, threshold) DO NOTHING
+			RETURNING *
+		)
+		SELECT `+alertColumns+`
+		FROM inserted a
+		JOIN categories c ON c.category_id = a.category_id
+		ORDER BY c.category_name, a.reference_month, a.threshold`, workspaceID, pq.Array(ids), s.currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return collectAlerts(rows)
+}
+
+func (s *Store) ListAlerts(ctx context.Context, workspaceID string, query store.AlertQuery) ([]store.Alert, error) {
+	where := []string{"a.workspace_id = $1"}
+	args := []any{workspaceID}
+	if !query.Month.IsZero() {
+		args = append(args, query.Month)
+		where = append(where, fmt.Sprintf("a.reference_month = $%d", len(args)))
+	}
+	if query.CategoryID != "" {
+		if !isUUID(query.CategoryID) {
+			return []store.Alert{}, nil
+		}
+		args = append(args, query.CategoryID)
+		where = append(where, fmt.Sprintf("a.category_id = $%d", len(args)))
+	}
+	if query.Unacknowledged {
+		where = append(where, "a.acknowledged_at IS NULL")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+alertColumns+`
+		FROM budget_alerts a
+		JOIN categories c ON c.category_id = a.category_id
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY a.created_at DESC, a.threshold DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return collectAlerts(rows)
+}
+
+func (s *Store) AcknowledgeAlert(ctx context.Context, workspaceID, id, userID string) (store.Alert, error) {
+	if !isUUID(id) {
+		return store.Alert{}, store.ErrNotFound
+	}
+
+	alert, err := scanAlert(s.db.QueryRowContext(ctx, `
+		WITH updated AS (
+			UPDATE budget_alerts
+			SET acknowledged_at = COALESCE(acknowledged_at, now()),
+				acknowledged_by = COALESCE(acknowledged_by, $3)
+			WHERE alert_id = $1 AND workspace_id = $2
+			RETURNING *
+		)
+		SELECT `+alertColumns+`
+		FROM updated a
+		JOIN categories c ON c.category_id = a.category_id`, id, workspaceID, userID))
+	if err == sql.ErrNoRows {
+		return store.Alert{}, store.ErrNotFound
+	}
+
+	return alert, err
+}
+
+func collectAlerts(rows *sql.Rows) ([]store.Alert, error) {
+	alerts := []store.Alert{}
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
@@ -0,0 +1,250 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"time"
+)
+
+func (s *Store) Totals(ctx context.Context, workspaceID string, m month.YearMonth) (store.MonthTotals, error) {
+	sqlQuery := `
+		SELECT 
+			COALESCE(SUM(me.amount_planned) FILTER (WHERE me.is_planned), 0) AS total_planned, 
+			COALESCE(SUM(` + spentInBase("$4") + `), 0) AS total_spent,
+			` + unconvertedCount("$4") + ` AS unconverted,
+			(SELECT COALESCE(SUM(` + incomeInBase("$4") + `), 0) FROM incomes
+				WHERE workspace_id = $1 AND reference_month >= $2 AND reference_month < $3) AS total_income
+		FROM monthly_expenses me
+		WHERE me.workspace_id = $1 AND me.reference_month >= $2 AND me.reference_month < $3
+	`
+
+	var totals store.MonthTotals
+	err := s.db.QueryRowContext(ctx, sqlQuery, workspaceID, m, m.Next(), s.currency).Scan(&totals.Planned, &totals.Spent, &totals.Unconverted, &totals.Income)
+	if err != nil {
+		return store.MonthTotals{}, err
+	}
+	totals.Difference = totals.Planned.Sub(totals.Spent)
+	totals.Net = totals.Income.Sub(totals.Spent)
+
+	return totals, nil
+}
+
+func (s *Store) PendingPayments(ctx context.Context, workspaceID string, m month.YearMonth) ([]store.PendingPayment, error) {
+	// Despesas do mês com o status "pending" do sistema, pelo id semeado na
+	// migração; um status do workspace com o mesmo nome não conta
+	sqlQuery := `
+        SELECT 
+            me.expense_id, 
+            me.category_id, 
+            c.category_name,
+            me.reference_month, 
+            ` + spentInBase("$4") + `, 
+            me.amount_planned, 
+            me.payment_date, 
+            me.description,
+            s.status_name
+        FROM 
+            monthly_expenses me
+        JOIN
+            categories c ON me.category_id::text = c.category_id::text
+        JOIN
+            status s ON s.status_id = me.status_id
+                AND s.status_id = (SELECT status_id FROM status WHERE status_name = 'pending' AND workspace_id IS NULL)
+        WHERE 
+            me.workspace_id = $1 AND me.reference_month >= $2 AND me.reference_month < $3
+    `
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, workspaceID, m, m.Next(), s.currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pendingPayments := []store.PendingPayment{}
+	for rows.Next() {
+		var payment store.PendingPayment
+		var description sql.NullString
+		var referenceMonth time.Time
+		var spentAmount, amountPlanned money.NullAmount
+		var paymentDate sql.NullTime
+
+		err := rows.Scan(&payment.ExpenseID, &payment.CategoryID, &payment.CategoryName, &referenceMonth, &spentAmount, &amountPlanned, &paymentDate, &description, &payment.StatusName)
+		if err != nil {
+			return nil, err
+		}
+
+		payment.ReferenceMonth = referenceMonth.Format("2006-01-02")
+		payment.SpentAmount = spentAmount.Amount
+		payment.PlannedAmount = amountPlanned.Amount
+		payment.PaymentDate = paymentDate.Time.Format("2006-01-02")
+		payment.Description = description.String
+
+		pendingPayments = append(pendingPayments, payment)
+	}
+
+	return pendingPayments, rows.Err()
+}
+
+func (s *Store) CategoryTotals(ctx context.Context, workspaceID string, from, to month.YearMonth) ([]store.CategoryTotal, error) {
+	sqlQuery := `
+		SELECT 
+			c.category_id,
+			c.category_name,
+			COALESCE(SUM(me.amount_planned) FILTER (WHERE me.is_planned), 0) AS total_planned,
+			COALESCE(SUM(` + spentInBase("$4") + `), 0) AS total_spent,
+			` + unconvertedCount("$4") + ` AS unconverted
+		FROM 
+			monthly_expenses me
+		JOIN
+			categories c ON me.category_id = c.category_id
+		WHERE 
+			me.workspace_id = $1 AND me.reference_month >= $2 AND me.reference_month < $3
+		GROUP BY 
+			c.category_id, c.category_name
+		ORDER BY 
+			c.category_name, c.category_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, workspaceID, from, to.Next(), s.currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []store.CategoryTotal{}
+	for rows.Next() {
+		var total store.CategoryTotal
+		if err := rows.Scan(&total.CategoryID, &total.CategoryName, &total.Planned, &total.Spent, &total.Unconverted); err != nil {
+			return nil, err
+		}
+		total.Difference = total.Planned.Sub(total.Spent)
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
+
+func (s *Store) MonthlySeries(ctx context.Context, workspaceID string, from, to month.YearMonth) ([]store.MonthPoint, error) {
+	// A série começa 12 meses antes para que LAG alcance o mesmo mês do ano anterior
+	sqlQuery := `
+		WITH months AS (
+			SELECT generate_series($2::date, $3::date, interval '1 month')::date AS reference_month
+		), totals AS (
+			SELECT
+				m.reference_month,
+				COALESCE(SUM(me.amount_planned) FILTER (WHERE me.is_planned), 0) AS planned,
+				COALESCE(SUM(` + spentInBase("$5") + `), 0) AS spent,
+				` + unconvertedCount("$5") + ` AS unconverted
+			FROM months m
+			LEFT JOIN monthly_expenses me ON me.workspace_id = $1
+				AND me.reference_month >= m.reference_month AND me.reference_month < m.reference_month + interval '1 month'
+			GROUP BY m.reference_month
+		), compared AS (
+			SELECT
+				reference_month,
+				planned,
+				spent,
+				unconverted,
+				COALESCE(LAG(spent, 1) OVER w, 0) AS previous_month,
+				COALESCE(LAG(spent, 12) OVER w, 0) AS previous_year
+			FROM totals
+			WINDOW w AS (ORDER BY reference_month)
+		)
+		SELECT
+			reference_month, planned, spent, planned - spent, unconverted,
+			previous_month, spent - previous_month, ROUND((spent - previous_month) * 100 / NULLIF(previous_month, 0), 2),
+			previous_year, spent - previous_year, ROUND((spent - previous_year) * 100 / NULLIF(previous_year, 0), 2)
+		FROM compared
+		WHERE reference_month >= $4::date
+		ORDER BY reference_month
+	`
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, workspaceID, from.AddMonths(-12), to, from, s.currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []store.MonthPoint{}
+	for rows.Next() {
+		var point store.MonthPoint
+		var monthOverMonth, yearOverYear sql.NullFloat64
+		err := rows.Scan(&point.Month, &point.Planned, &point.Spent, &point.Difference, &point.Unconverted,
+			&point.MonthOverMonth.Previous, &point.MonthOverMonth.Change, &monthOverMonth,
+			&point.YearOverYear.Previous, &point.YearOverYear.Change, &yearOverYear)
+		if err != nil {
+			return nil, err
+		}
+		point.MonthOverMonth.Percent = nullFloat(monthOverMonth)
+		point.YearOverYear.Percent = nullFloat(yearOverYear)
+		points = append(points, point)
+	}
+
+	return points, rows.Err()
+}
+
+func (s *Store) CategoryBreakdown(ctx context.Context, workspaceID string, m month.YearMonth) ([]store.CategoryShare, error) {
+	sqlQuery := `
+		WITH converted AS (
+			SELECT me.category_id, me.reference_month, me.amount_planned, me.is_planned, ` + spentInBase("$7") + ` AS spent_amount,
+				me.spent_amount IS NOT NULL AND ` + spentInBase("$7") + ` IS NULL AS unconverted
+			FROM monthly_expenses me
+			WHERE me.workspace_id = $1
+				AND (me.reference_month >= $4 AND me.reference_month < $3 OR me.reference_month >= $5 AND me.reference_month < $6)
+		), usage AS (
+			SELECT
+				me.category_id,
+				COALESCE(SUM(me.amount_planned) FILTER (WHERE me.is_planned AND me.reference_month >= $2 AND me.reference_month < $3), 0) AS planned,
+				COALESCE(SUM(me.spent_amount) FILTER (WHERE me.reference_month >= $2 AND me.reference_month < $3), 0) AS spent,
+				COALESCE(SUM(me.spent_amount) FILTER (WHERE me.reference_month >= $4 AND me.reference_month < $2), 0) AS previous_month,
+				COALESCE(SUM(me.spent_amount) FILTER (WHERE me.reference_month >= $5 AND me.reference_month < $6), 0) AS previous_year,
+				COUNT(*) FILTER (WHERE me.unconverted AND me.reference_month >= $2 AND me.reference_month < $3) AS unconverted,
+				COUNT(*) FILTER (WHERE me.reference_month >= $2 AND me.reference_month < $3) AS current_rows
+			FROM converted me
+			GROUP BY me.category_id
+		)
+		SELECT
+			c.category_id, c.category_name, u.planned, u.spent, u.planned - u.spent, u.unconverted,
+			COALESCE(ROUND(u.spent * 100 / NULLIF(SUM(u.spent) OVER (), 0), 2), 0),
+			u.previous_month, u.spent - u.previous_month, ROUND((u.spent - u.previous_month) * 100 / NULLIF(u.previous_month, 0), 2),
+			u.previous_year, u.spent - u.previous_year, ROUND((u.spent - u.previous_year) * 100 / NULLIF(u.previous_year, 0), 2)
+		FROM usage u
+		JOIN categories c ON c.category_id = u.category_id
+		WHERE u.current_rows > 0
+		ORDER BY u.spent DESC, c.category_name, c.category_id
+	`
+
+	lastYear := m.AddMonths(-12)
+	rows, err := s.db.QueryContext(ctx, sqlQuery, workspaceID, m, m.Next(), m.AddMonths(-1), lastYear, lastYear.Next(), s.currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []store.CategoryShare{}
+	for rows.Next() {
+		var share store.CategoryShare
+		var monthOverMonth, yearOverYear sql.NullFloat64
+		err := rows.Scan(&share.CategoryID, &share.CategoryName, &share.Planned, &share.Spent, &share.Difference, &share.Unconverted, &share.Percent,
+			&share.MonthOverMonth.Previous, &share.MonthOverMonth.Change, &monthOverMonth,
+			&share.YearOverYear.Previous, &share.YearOverYear.Change, &yearOverYear)
+		if err != nil {
+			return nil, err
+		}
+		share.MonthOverMonth.Percent = nullFloat(monthOverMonth)
+		share.YearOverYear.Percent = nullFloat(yearOverYear)
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+func nullFloat(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
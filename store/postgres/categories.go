@@ -0,0 +1,316 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-sheet/month"
+	"go-sheet/store"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func (s *Store) ListCategories(ctx context.Context, workspaceID string) ([]store.Category, error) {
+	sqlQuery := `SELECT category_id, category_name, parent_id, planned_amount(category_id, $2), category_color, reference_month, archived_at FROM categories WHERE workspace_id = $1`
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, workspaceID, month.Current())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []store.Category{}
+	for rows.Next() {
+		var category store.Category
+		var parentID sql.NullString
+		var archivedAt sql.NullTime
+		err := rows.Scan(
+			&category.CategoryID,
+			&category.CategoryName,
+			&parentID,
+			&category.PlannedAmount,
+			&category.Color,
+			&category.ReferenceMonth,
+			&archivedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		category.ParentID = nullString(parentID)
+		if archivedAt.Valid {
+			category.ArchivedAt = &archivedAt.Time
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// CreateCategory inserts the category and its planned row in monthly_expenses
+// in a single transaction, so a category never exists without its monthly row
+func (s *Store) CreateCategory(ctx context.Context, workspaceID string, input store.CategoryInput) (string, error) {
+	categoryID := uuid.NewString()
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if input.ParentID != nil {
+			if _, err := txPlannedAmount(ctx, tx, workspaceID, *input.ParentID, month.Current()); err != nil {
+				return err
+			}
+		}
+
+		// Inserir categoria na tabela de categorias
+		sqlQuery := `INSERT INTO categories (category_id, workspace_id, category_name, parent_id, amount_planned, category_color) 
+			VALUES ($1, $2, $3, $4, $5, $6)`
+		_, err := tx.ExecContext(ctx, sqlQuery, categoryID, workspaceID, input.Name, input.ParentID, input.PlannedAmount, input.Color)
+		if err != nil {
+			return fmt.Errorf("insert category: %w", err)
+		}
+
+		referenceMonth := month.Current()
+		if err := txSetPlan(ctx, tx, categoryID, referenceMonth, input.PlannedAmount); err != nil {
+			return err
+		}
+
+		// Obter o status_id para "pending"
+		var statusID string
+		err = tx.QueryRowContext(ctx, "SELECT status_id FROM status WHERE status_name = 'pending' AND workspace_id IS NULL").Scan(&statusID)
+		if err != nil {
+			return fmt.Errorf("get pending status: %w", err)
+		}
+
+		monthlyExpenseQuery := `INSERT INTO monthly_expenses (workspace_id, category_id, reference_month, spent_amount, amount_planned, difference_amount, payment_date, file, description, status_id, is_planned) 
+			VALUES ($1, $2, $3, NULL, $4, NULL, NULL, NULL, $5, $6, true)`
+		_, err = tx.ExecContext(ctx, monthlyExpenseQuery, workspaceID, categoryID, referenceMonth, input.PlannedAmount, input.Description, statusID)
+		if err != nil {
+			return fmt.Errorf("insert into monthly_expenses: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return categoryID, nil
+}
+
+// UpdateCategory updates the category and its plan from the current month on
+// in a single transaction
+func (s *Store) UpdateCategory(ctx context.Context, workspaceID, id string, input store.CategoryInput) error {
+	if !isUUID(id) {
+		return store.ErrNotFound
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		// Lock the category so concurrent updates apply one after the other
+		var existingCategoryID string
+		err := tx.QueryRowContext(ctx, "SELECT category_id FROM categories WHERE category_id = $1 AND workspace_id = $2 FOR UPDATE", id, workspaceID).Scan(&existingCategoryID)
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		} else if err != nil {
+			return fmt.Errorf("check category existence: %w", err)
+		}
+
+		sqlUpdateCategory := `UPDATE categories 
+                              SET category_name = $1, amount_planned = $2, category_color = $3, description = $4 
+                              WHERE category_id = $5`
+		_, err = tx.ExecContext(ctx, sqlUpdateCategory, input.Name, input.PlannedAmount, input.Color, input.Description, id)
+		if err != nil {
+			return fmt.Errorf("update category: %w", err)
+		}
+
+		// Atualizar a tabela `monthly_expenses` para o mês atual; a descrição
+		// só vai para a linha planejada, não para os gastos lançados
+		currentMonth := month.Current()
+
+		sqlUpdateExpense := `UPDATE monthly_expenses 
+                             SET description = $1 
+                             WHERE category_id = $2 AND reference_month = $3 AND is_planned`
+		_, err = tx.ExecContext(ctx, sqlUpdateExpense, input.Description, id, currentMonth)
+		if err != nil {
+			return fmt.Errorf("update monthly expense: %w", err)
+		}
+
+		return txSetPlan(ctx, tx, id, currentMonth, input.PlannedAmount)
+	})
+}
+
+func (s *Store) ArchiveCategory(ctx context.Context, workspaceID, id string) error {
+	if !isUUID(id) {
+		return store.ErrNotFound
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockCategory(ctx, tx, workspaceID, id); err != nil {
+			return err
+		}
+
+		var activeChildren bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1 AND archived_at IS NULL)`, id).Scan(&activeChildren)
+		if err != nil {
+			return err
+		}
+		if activeChildren {
+			return store.ErrHasSubcategories
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE categories SET archived_at = COALESCE(archived_at, now()) WHERE category_id = $1`, id)
+		return err
+	})
+}
+
+func (s *Store) UnarchiveCategory(ctx context.Context, workspaceID, id string) error {
+	if !isUUID(id) {
+		return store.ErrNotFound
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var parentArchived bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT p.archived_at IS NOT NULL
+			FROM categories c
+			LEFT JOIN categories p ON p.category_id = c.parent_id
+			WHERE c.category_id = $1 AND c.workspace_id = $2
+			FOR UPDATE OF c`, id, workspaceID).Scan(&parentArchived)
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		} else if err != nil {
+			return err
+		}
+		if parentArchived {
+			return store.ErrCategoryArchived
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE categories SET archived_at = NULL WHERE category_id = $1`, id)
+		return err
+	})
+}
+
+// DeleteCategory removes the expenses first: monthly_expenses.category_id has
+// no ON DELETE action. Alerts, thresholds and recurring expenses cascade and
+// rules lose their category.
+func (s *Store) DeleteCategory(ctx context.Context, workspaceID, id string) ([]string, error) {
+	if !isUUID(id) {
+		return nil, store.ErrNotFound
+	}
+
+	receiptKeys := []string{}
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockCategory(ctx, tx, workspaceID, id); err != nil {
+			return err
+		}
+
+		// A subárvore inteira sai junto: a categoria e todas as subcategorias
+		var subtree []string
+		err := tx.QueryRowContext(ctx, `
+			WITH RECURSIVE subtree AS (
+				SELECT category_id FROM categories WHERE category_id = $1
+				UNION
+				SELECT c.category_id
+				FROM categories c
+				JOIN subtree st ON c.parent_id = st.category_id
+			)
+			SELECT array_agg(category_id) FROM subtree`, id).Scan(pq.Array(&subtree))
+		if err != nil {
+			return fmt.Errorf("list subcategories: %w", err)
+		}
+
+		rows, err := tx.QueryContext(ctx, `DELETE FROM monthly_expenses WHERE category_id = ANY($1::uuid[]) RETURNING COALESCE(receipt_key, '')`, pq.Array(subtree))
+		if err != nil {
+			return fmt.Errorf("delete expenses: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				return err
+			}
+			if key != "" {
+				receiptKeys = append(receiptKeys, key)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// parent_id é ON DELETE RESTRICT: solta os filhos antes de apagar
+		if _, err := tx.ExecContext(ctx, `UPDATE categories SET parent_id = NULL WHERE category_id = ANY($1::uuid[])`, pq.Array(subtree)); err != nil {
+			return fmt.Errorf("detach subcategories: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE category_id = ANY($1::uuid[])`, pq.Array(subtree)); err != nil {
+			return fmt.Errorf("delete category: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return receiptKeys, nil
+}
+
+// lockCategory locks a category of the workspace for the rest of the
+// transaction, returning ErrNotFound when there is none
+func lockCategory(ctx context.Context, tx *sql.Tx, workspaceID, id string) error {
+	var categoryID string
+	err := tx.QueryRowContext(ctx, `SELECT category_id FROM categories WHERE category_id = $1 AND workspace_id = $2 FOR UPDATE`, id, workspaceID).Scan(&categoryID)
+	if err == sql.ErrNoRows {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// MoveCategory locks the categories of the workspace while it checks the new
+// parent, so two concurrent moves cannot build a cycle between them
+func (s *Store) MoveCategory(ctx context.Context, workspaceID, id string, parentID *string) error {
+	if !isUUID(id) {
+		return store.ErrNotFound
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `SELECT 1 FROM categories WHERE workspace_id = $1 FOR UPDATE`, workspaceID)
+		if err != nil {
+			return fmt.Errorf("lock categories: %w", err)
+		}
+
+		var exists bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE category_id = $1 AND workspace_id = $2)`, id, workspaceID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return store.ErrNotFound
+		}
+
+		if parentID != nil {
+			if _, err := txPlannedAmount(ctx, tx, workspaceID, *parentID, month.Current()); err != nil {
+				return err
+			}
+
+			// Sobe a partir do novo pai: se a própria categoria aparecer, seria um ciclo
+			var cycle bool
+			err = tx.QueryRowContext(ctx, `
+				WITH RECURSIVE ancestors AS (
+					SELECT category_id, parent_id FROM categories WHERE category_id = $1
+					UNION
+					SELECT c.category_id, c.parent_id
+					FROM categories c
+					JOIN ancestors a ON c.category_id = a.parent_id
+				)
+				SELECT EXISTS (SELECT 1 FROM ancestors WHERE category_id = $2)`, *parentID, id).Scan(&cycle)
+			if err != nil {
+				return fmt.Errorf("check ancestors: %w", err)
+			}
+			if cycle {
+				return store.ErrCategoryCycle
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE categories SET parent_id = $1 WHERE category_id = $2`, parentID, id)
+		if err != nil {
+			return fmt.Errorf("update parent: %w", err)
+		}
+		return nil
+	})
+}
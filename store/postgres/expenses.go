@@ -0,0 +1,497 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// spentInBase converts me.spent_amount into the workspace's base currency
+// with the rate of the payment date (or of the reference month when unpaid).
+// defaultCurrency is the placeholder holding Store.currency.
+func spentInBase(defaultCurrency string) string {
+	return "to_base_currency(me.spent_amount, me.workspace_id, me.currency, COALESCE(me.payment_date, me.reference_month), " + defaultCurrency + "::text)"
+}
+
+// unconvertedCount counts the expenses whose spent amount spentInBase turns
+// into NULL, for want of an exchange rate
+func unconvertedCount(defaultCurrency string) string {
+	return "COUNT(*) FILTER (WHERE me.spent_amount IS NOT NULL AND " + spentInBase(defaultCurrency) + " IS NULL)"
+}
+
+// expenseColumns are the columns scanned by scanExpense, selected from
+// expenseFrom. $2 must hold the store's default currency.
+var expenseColumns = `
+			me.expense_id,
+			c.category_name,
+			me.reference_month,
+			` + spentInBase("$2") + `,
+			me.amount_planned,
+			me.payment_date,
+			me.file,
+			pt.paid_id,
+			pt.paid_type AS paid_type,
+			pt.paid_color AS paid_color,
+			st.status_id AS status_id,
+			st.status_name AS status_name,
+			me.description AS description,
+			COALESCE(me.receipt_key, ''),
+			me.spent_amount,
+			COALESCE(me.currency, (SELECT base_currency FROM workspaces WHERE workspace_id = me.workspace_id), $2::text)`
+
+const expenseFrom = `
+		FROM 
+			monthly_expenses me
+		JOIN 
+			categories c ON me.category_id = c.category_id
+		LEFT JOIN
+			paid_type pt ON me.paid_id::text = pt.paid_id::text
+		LEFT JOIN
+			status st ON me.status_id::text = st.status_id::text`
+
+// expenseSelectQuery selects the columns scanned by scanExpense
+var expenseSelectQuery = `
+		SELECT ` + expenseColumns + expenseFrom
+
+// sortKeys maps each sort field to the expression rows are ordered by and
+// the type a cursor key is cast back to. NULLs become values below any real
+// date or amount so keyset comparisons never meet them. Amounts are compared
+// in the base currency.
+var sortKeys = map[store.ExpenseSort]struct{ expr, cast string }{
+	store.SortReferenceMonth: {"me.reference_month", "date"},
+	store.SortPaymentDate:    {"COALESCE(me.payment_date, '-infinity'::date)", "date"},
+	store.SortSpentAmount:    {"COALESCE(" + spentInBase("$2") + ", -1)", "numeric"},
+	store.SortPlannedAmount:  {"me.amount_planned", "numeric"},
+	store.SortCategoryName:   {"c.category_name", "text"},
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// extraScanner appends extra destinations to every Scan, for queries that
+// select more than scanExpense reads
+type extraScanner struct {
+	row   rowScanner
+	extra []any
+}
+
+func (e extraScanner) Scan(dest ...any) error {
+	return e.row.Scan(append(dest, e.extra...)...)
+}
+
+// scanExpense reads one row produced by expenseSelectQuery
+func scanExpense(row rowScanner) (store.Expense, error) {
+	var expense store.Expense
+	var spentAmount, originalAmount money.NullAmount
+	var paymentDate, file, paidId, paidType, paidColor sql.NullString
+	var referenceMonth sql.NullTime
+	var statusId, statusName, description sql.NullString
+	err := row.Scan(
+		&expense.ExpenseID,
+		&expense.CategoryName,
+		&referenceMonth,
+		&spentAmount,
+		&expense.PlannedAmount,
+		&paymentDate,
+		&file,
+		&paidId,
+		&paidType,
+		&paidColor,
+		&statusId,
+		&statusName,
+		&description,
+		&expense.ReceiptKey,
+		&originalAmount,
+		&expense.Currency,
+	)
+	if err != nil {
+		return expense, err
+	}
+
+	if spentAmount.Valid {
+		difference := expense.PlannedAmount.Sub(spentAmount.Amount)
+		expense.SpentAmount = spentAmount.Ptr()
+		expense.Difference = &difference
+	}
+	expense.OriginalAmount = originalAmount.Ptr()
+	if paymentDate.Valid {
+		expense.PaymentDate = &paymentDate.String
+	}
+	if file.Valid {
+		expense.File = &file.String
+	}
+	if paidId.Valid {
+		expense.PaidId = &paidId.String
+	}
+	if paidType.Valid {
+		expense.PaidType = &paidType.String
+	}
+	if paidColor.Valid {
+		expense.PaidColor = &paidColor.String
+	}
+	if statusId.Valid {
+		expense.StatusId = &statusId.String
+	}
+	if statusName.Valid {
+		expense.StatusName = &statusName.String
+	}
+	if description.Valid {
+		expense.Description = &description.String
+	}
+
+	if referenceMonth.Valid {
+		formattedDate := referenceMonth.Time.Format("2006-01-02")
+		expense.ReferenceMonth = &formattedDate
+	}
+
+	return expense, nil
+}
+
+// ListExpenses returns one page of the workspace's expenses using keyset
+// pagination on the sort key and expense id
+func (s *Store) ListExpenses(ctx context.Context, workspaceID string, query store.ExpenseQuery) (store.ExpensePage, error) {
+	query.Normalize()
+	key := sortKeys[query.Sort]
+	direction, compare := "ASC", ">"
+	if query.Desc {
+		direction, compare = "DESC", "<"
+	}
+
+	where := []string{"me.workspace_id = $1"}
+	args := []any{workspaceID, s.currency}
+	add := func(condition string, values ...any) {
+		placeholders := make([]any, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		where = append(where, fmt.Sprintf(condition, placeholders...))
+	}
+
+	for _, id := range []string{query.CategoryID, query.PaidID, query.StatusID} {
+		if id != "" && !isUUID(id) {
+			return store.ExpensePage{Expenses: []store.Expense{}}, nil
+		}
+	}
+
+	if !query.From.IsZero() {
+		add("me.reference_month >= $%d", query.From)
+	}
+	if !query.To.IsZero() {
+		add("me.reference_month < $%d", query.To.Next())
+	}
+	if query.CategoryID != "" {
+		add("me.category_id = $%d", query.CategoryID)
+	}
+	if query.PaidID != "" {
+		add("me.paid_id = $%d", query.PaidID)
+	}
+	if query.StatusID != "" {
+		add("me.status_id = $%d", query.StatusID)
+	}
+	if query.MinAmount != nil {
+		add(spentInBase("$2")+" >= $%d", *query.MinAmount)
+	}
+	if query.MaxAmount != nil {
+		add(spentInBase("$2")+" <= $%d", *query.MaxAmount)
+	}
+	if query.Search != "" {
+		add("me.description ILIKE $%d", "%"+likeEscaper.Replace(query.Search)+"%")
+	}
+	if query.Cursor != nil {
+		if !query.Cursor.Matches(query) || !isUUID(query.Cursor.ID) {
+			return store.ExpensePage{}, store.ErrInvalidCursor
+		}
+		add(fmt.Sprintf("(%s, me.expense_id) %s ($%%d::%s, $%%d::uuid)", key.expr, compare, key.cast), query.Cursor.Key, query.Cursor.ID)
+	}
+
+	args = append(args, query.Limit+1)
+	sqlQuery := fmt.Sprintf(`
+		SELECT %s, (%s)::text %s
+		WHERE
+			%s
+		ORDER BY 
+			%s %s, me.expense_id %s
+		LIMIT $%d`,
+		expenseColumns, key.expr, expenseFrom, strings.Join(where, " AND "), key.expr, direction, direction, len(args))
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return store.ExpensePage{}, err
+	}
+	defer rows.Close()
+
+	page := store.ExpensePage{Expenses: []store.Expense{}}
+	var lastKey string
+	for rows.Next() {
+		var sortKey string
+		expense, err := scanExpense(extraScanner{row: rows, extra: []any{&sortKey}})
+		if err != nil {
+			return store.ExpensePage{}, err
+		}
+		if len(page.Expenses) == query.Limit {
+			last := page.Expenses[len(page.Expenses)-1]
+			page.NextCursor = store.Cursor{Sort: query.Sort, Desc: query.Desc, Key: lastKey, ID: last.ExpenseID}.Encode()
+			break
+		}
+		page.Expenses = append(page.Expenses, expense)
+		lastKey = sortKey
+	}
+
+	return page, rows.Err()
+}
+
+func (s *Store) GetExpense(ctx context.Context, workspaceID, id string) (store.Expense, error) {
+	if !isUUID(id) {
+		return store.Expense{}, store.ErrNotFound
+	}
+
+	expense, err := scanExpense(s.db.QueryRowContext(ctx, expenseSelectQuery+`
+		WHERE 
+			me.workspace_id = $1 AND me.expense_id = $3`, workspaceID, s.currency, id))
+	if err == sql.ErrNoRows {
+		return expense, store.ErrNotFound
+	}
+
+	return expense, err
+}
+
+// plannedAmount fetches the plan of a workspace category effective in month m;
+// expenses always copy it from their category. For archived categories the
+// amount comes along with ErrCategoryArchived.
+func (s *Store) plannedAmount(ctx context.Context, workspaceID, categoryID string, m month.YearMonth) (money.Amount, error) {
+	if !isUUID(categoryID) {
+		return 0, store.ErrUnknownCategory
+	}
+
+	var amountPlanned money.Amount
+	var archived bool
+	err := s.db.QueryRowContext(ctx, `SELECT planned_amount(category_id, $3), archived_at IS NOT NULL FROM categories WHERE category_id = $1 AND workspace_id = $2`, categoryID, workspaceID, m).Scan(&amountPlanned, &archived)
+	if err == sql.ErrNoRows {
+		return 0, store.ErrUnknownCategory
+	}
+	if err == nil && archived {
+		err = store.ErrCategoryArchived
+	}
+
+	return amountPlanned, err
+}
+
+// keepsArchived tells whether err only means that the expense stays in its
+// archived category, which editing an old expense may do
+func (s *Store) keepsArchived(ctx context.Context, err error, workspaceID, id, categoryID string) bool {
+	if !errors.Is(err, store.ErrCategoryArchived) {
+		return false
+	}
+
+	var same bool
+	err = s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM monthly_expenses WHERE expense_id = $1 AND workspace_id = $2 AND category_id = $3)`,
+		id, workspaceID, categoryID).Scan(&same)
+	return err == nil && same
+}
+
+// checkPaidType makes sure an expense only points at the workspace's paid types
+func (s *Store) checkPaidType(ctx context.Context, workspaceID, paidID string) error {
+	if !isUUID(paidID) {
+		return store.ErrUnknownPaidType
+	}
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM paid_type WHERE paid_id = $1 AND workspace_id = $2)`, paidID, workspaceID).Scan(&exists)
+	if err == nil && !exists {
+		return store.ErrUnknownPaidType
+	}
+
+	return err
+}
+
+// checkStatus accepts the workspace's statuses and the shared default ones
+func (s *Store) checkStatus(ctx context.Context, workspaceID, statusID string) error {
+	if !isUUID(statusID) {
+		return store.ErrUnknownStatus
+	}
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM status WHERE status_id = $1 AND (workspace_id = $2 OR workspace_id IS NULL))`, statusID, workspaceID).Scan(&exists)
+	if err == nil && !exists {
+		return store.ErrUnknownStatus
+	}
+
+	return err
+}
+
+func (s *Store) CreateExpense(ctx context.Context, workspaceID string, input store.ExpenseInput) (string, error) {
+	amountPlanned, err := s.plannedAmount(ctx, workspaceID, input.CategoryID, input.ReferenceMonth)
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkPaidType(ctx, workspaceID, input.PaidID); err != nil {
+		return "", err
+	}
+	var statusID *string
+	if input.StatusID != "" {
+		if err := s.checkStatus(ctx, workspaceID, input.StatusID); err != nil {
+			return "", err
+		}
+		statusID = &input.StatusID
+	}
+
+	id := uuid.NewString()
+	sqlQuery := `INSERT INTO monthly_expenses (expense_id, workspace_id, category_id, reference_month, spent_amount, amount_planned, payment_date, paid_id, file, description, currency, status_id) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), $12)`
+	_, err = s.db.ExecContext(ctx, sqlQuery, id, workspaceID, input.CategoryID, input.ReferenceMonth, input.SpentAmount, amountPlanned, input.PaymentDate, input.PaidID, input.File, input.Description, input.Currency, statusID)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Store) UpdateExpense(ctx context.Context, workspaceID, id string, input store.ExpenseInput) (store.Expense, error) {
+	if _, err := s.GetExpense(ctx, workspaceID, id); err != nil {
+		return store.Expense{}, err
+	}
+
+	amountPlanned, err := s.plannedAmount(ctx, workspaceID, input.CategoryID, input.ReferenceMonth)
+	if err != nil && !s.keepsArchived(ctx, err, workspaceID, id, input.CategoryID) {
+		return store.Expense{}, err
+	}
+	if err := s.checkPaidType(ctx, workspaceID, input.PaidID); err != nil {
+		return store.Expense{}, err
+	}
+
+	sqlQuery := `UPDATE monthly_expenses 
+              SET category_id = $1, reference_month = $2, spent_amount = $3, amount_planned = $4, payment_date = $5, paid_id = $6, file = $7, description = NULLIF($10, ''), currency = NULLIF($11, '') 
+              WHERE expense_id = $8 AND workspace_id = $9`
+	_, err = s.db.ExecContext(ctx, sqlQuery, input.CategoryID, input.ReferenceMonth, input.SpentAmount, amountPlanned, input.PaymentDate, input.PaidID, input.File, id, workspaceID, input.Description, input.Currency)
+	if err != nil {
+		return store.Expense{}, err
+	}
+
+	return s.GetExpense(ctx, workspaceID, id)
+}
+
+func (s *Store) PatchExpense(ctx context.Context, workspaceID, id string, patch store.ExpensePatch) (store.Expense, error) {
+	if !isUUID(id) {
+		return store.Expense{}, store.ErrNotFound
+	}
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		// Trava a despesa: a categoria nova é validada no mês dela, não no atual
+		var referenceMonth sql.NullTime
+		var categoryID string
+		err := tx.QueryRowContext(ctx, `SELECT reference_month, category_id FROM monthly_expenses WHERE expense_id = $1 AND workspace_id = $2 FOR UPDATE`,
+			id, workspaceID).Scan(&referenceMonth, &categoryID)
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		m := month.Current()
+		if patch.ReferenceMonth != nil {
+			m = *patch.ReferenceMonth
+		} else if referenceMonth.Valid {
+			m = month.Of(referenceMonth.Time)
+		}
+
+		var columns []string
+		var args []any
+		set := func(column string, value any) {
+			args = append(args, value)
+			columns = append(columns, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+
+		if patch.ReferenceMonth != nil {
+			set("reference_month", *patch.ReferenceMonth)
+		}
+		if patch.PaymentDate != nil {
+			set("payment_date", *patch.PaymentDate)
+		}
+		if patch.SpentAmount != nil {
+			set("spent_amount", *patch.SpentAmount)
+		}
+		if patch.PaidID != nil {
+			if err := txCheck(ctx, tx, `SELECT EXISTS (SELECT 1 FROM paid_type WHERE paid_id = $1 AND workspace_id = $2)`, workspaceID, *patch.PaidID, store.ErrUnknownPaidType); err != nil {
+				return err
+			}
+			set("paid_id", *patch.PaidID)
+		}
+		if patch.File != nil {
+			set("file", *patch.File)
+		}
+		if patch.StatusID != nil {
+			if err := txCheck(ctx, tx, `SELECT EXISTS (SELECT 1 FROM status WHERE status_id = $1 AND (workspace_id = $2 OR workspace_id IS NULL))`, workspaceID, *patch.StatusID, store.ErrUnknownStatus); err != nil {
+				return err
+			}
+			set("status_id", *patch.StatusID)
+		}
+		if patch.Description != nil {
+			set("description", *patch.Description)
+		}
+		if patch.Currency != nil {
+			set("currency", nullCurrency(*patch.Currency))
+		}
+		if patch.CategoryID != nil {
+			_, err := txPlannedAmount(ctx, tx, workspaceID, *patch.CategoryID, m)
+			// Uma despesa antiga pode continuar na sua categoria arquivada
+			if errors.Is(err, store.ErrCategoryArchived) && *patch.CategoryID == categoryID {
+				err = nil
+			}
+			if err != nil {
+				return err
+			}
+			set("category_id", *patch.CategoryID)
+		}
+
+		if len(columns) > 0 {
+			args = append(args, id, workspaceID)
+			sqlQuery := fmt.Sprintf(`UPDATE monthly_expenses SET %s WHERE expense_id = $%d AND workspace_id = $%d`, strings.Join(columns, ", "), len(args)-1, len(args))
+			if _, err := tx.ExecContext(ctx, sqlQuery, args...); err != nil {
+				return err
+			}
+		}
+		// O planejado acompanha a categoria e o mês já gravados acima
+		if patch.CategoryID != nil || patch.ReferenceMonth != nil {
+			_, err := tx.ExecContext(ctx, `UPDATE monthly_expenses SET amount_planned = planned_amount(category_id, reference_month) WHERE expense_id = $1`, id)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return store.Expense{}, err
+	}
+
+	return s.GetExpense(ctx, workspaceID, id)
+}
+
+func (s *Store) DeleteExpense(ctx context.Context, workspaceID, id string) error {
+	if !isUUID(id) {
+		return store.ErrNotFound
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM monthly_expenses WHERE expense_id = $1 AND workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
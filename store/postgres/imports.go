@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) ExpenseFingerprints(ctx context.Context, workspaceID string, from, to time.Time) ([]store.ExpenseFingerprint, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT expense_id, category_id, payment_date, spent_amount, COALESCE(description, ''), COALESCE(import_ref, '')
+		FROM monthly_expenses
+		WHERE workspace_id = $1 AND NOT is_planned AND spent_amount IS NOT NULL
+			AND payment_date BETWEEN $2::date AND $3::date`, workspaceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fingerprints := []store.ExpenseFingerprint{}
+	for rows.Next() {
+		var fp store.ExpenseFingerprint
+		if err := rows.Scan(&fp.ExpenseID, &fp.CategoryID, &fp.PaymentDate, &fp.SpentAmount, &fp.Description, &fp.ImportRef); err != nil {
+			return nil, err
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+
+	return fingerprints, rows.Err()
+}
+
+func (s *Store) ImportExpenses(ctx context.Context, workspaceID string, expenses []store.ImportedExpense) ([]string, error) {
+	ids := make([]string, len(expenses))
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var paidStatus string
+		err := tx.QueryRowContext(ctx, "SELECT status_id FROM status WHERE status_name = 'paid' AND workspace_id IS NULL").Scan(&paidStatus)
+		if err != nil {
+			return fmt.Errorf("get paid status: %w", err)
+		}
+
+		for i, expense := range expenses {
+			// As referências são verificadas dentro da transação, junto com o insert
+			planned, err := txPlannedAmount(ctx, tx, workspaceID, expense.CategoryID, month.Of(expense.PaymentDate))
+			if err != nil {
+				return &store.ImportError{Index: i, Err: err}
+			}
+			var paidID *string
+			if expense.PaidID != "" {
+				if err := txCheck(ctx, tx, `SELECT EXISTS (SELECT 1 FROM paid_type WHERE paid_id = $1 AND workspace_id = $2)`, workspaceID, expense.PaidID, store.ErrUnknownPaidType); err != nil {
+					return &store.ImportError{Index: i, Err: err}
+				}
+				paidID = &expense.PaidID
+			}
+			statusID := paidStatus
+			if expense.StatusID != "" {
+				if err := txCheck(ctx, tx, `SELECT EXISTS (SELECT 1 FROM status WHERE status_id = $1 AND (workspace_id = $2 OR workspace_id IS NULL))`, workspaceID, expense.StatusID, store.ErrUnknownStatus); err != nil {
+					return &store.ImportError{Index: i, Err: err}
+				}
+				statusID = expense.StatusID
+			}
+			var importRef, description *string
+			if expense.ImportRef != "" {
+				importRef = &expense.ImportRef
+			}
+			if expense.Description != "" {
+				description = &expense.Description
+			}
+
+			id := uuid.NewString()
+			err = tx.QueryRowContext(ctx, `
+				INSERT INTO monthly_expenses (expense_id, workspace_id, category_id, reference_month, spent_amount, amount_planned,
+					payment_date, paid_id, status_id, description, import_ref)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+				ON CONFLICT (workspace_id, import_ref) WHERE import_ref IS NOT NULL DO NOTHING
+				RETURNING expense_id`,
+				id, workspaceID, expense.CategoryID, month.Of(expense.PaymentDate), expense.SpentAmount, planned,
+				expense.PaymentDate, paidID, statusID, description, importRef).Scan(&ids[i])
+			if err == sql.ErrNoRows {
+				return &store.ImportError{Index: i, Err: store.ErrConflict}
+			} else if err != nil {
+				return fmt.Errorf("insert expense %d: %w", i, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// txPlannedAmount is plannedAmount inside a transaction; FOR SHARE keeps the
+// category from being deleted or archived before the import commits
+func txPlannedAmount(ctx context.Context, tx *sql.Tx, workspaceID, categoryID string, m month.YearMonth) (money.Amount, error) {
+	if !isUUID(categoryID) {
+		return 0, store.ErrUnknownCategory
+	}
+
+	var amountPlanned money.Amount
+	var archived bool
+	err := tx.QueryRowContext(ctx, `SELECT planned_amount(category_id, $3), archived_at IS NOT NULL FROM categories WHERE category_id = $1 AND workspace_id = $2 FOR SHARE`, categoryID, workspaceID, m).Scan(&amountPlanned, &archived)
+	if err == sql.ErrNoRows {
+		return 0, store.ErrUnknownCategory
+	}
+	if err == nil && archived {
+		err = store.ErrCategoryArchived
+	}
+
+	return amountPlanned, err
+}
+
+// txCheck runs an EXISTS query taking (id, workspaceID) and returns missing
+// when it is false
+func txCheck(ctx context.Context, tx *sql.Tx, query, workspaceID, id string, missing error) error {
+	if !isUUID(id) {
+		return missing
+	}
+
+	var exists bool
+	err := tx.QueryRowContext(ctx, query, id, workspaceID).Scan(&exists)
+	if err == nil && !exists {
+		return missing
+	}
+
+	return err
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"time"
+)
+
+// incomeInBase converts amount into the workspace's base currency with the
+// rate of the day it was received, or else expected; defaultCurrency is the
+// placeholder holding Store.currency
+func incomeInBase(defaultCurrency string) string {
+	return "to_base_currency(amount, workspace_id, currency, COALESCE(received_date, expected_date), " + defaultCurrency + "::text)"
+}
+
+// incomeColumns are the columns scanned by scanIncome
+func incomeColumns(defaultCurrency string) string {
+	return `income_id, source, ` + incomeInBase(defaultCurrency) + `, amount,
+		COALESCE(currency, (SELECT w.base_currency FROM workspaces w WHERE w.workspace_id = incomes.workspace_id), ` + defaultCurrency + `::text),
+		reference_month, expected_date, received_date, recurring, description, created_at`
+}
+
+func scanIncome(row rowScanner) (store.Income, error) {
+	var income store.Income
+	var amount money.NullAmount
+	var expectedDate time.Time
+	var receivedDate sql.NullTime
+	var description sql.NullString
+	err := row.Scan(&income.IncomeID, &income.Source, &amount, &income.OriginalAmount, &income.Currency, &income.ReferenceMonth, &expectedDate,
+		&receivedDate, &income.Recurring, &description, &income.CreatedAt)
+	if err != nil {
+		return income, err
+	}
+
+	income.Amount = amount.Ptr()
+	income.ExpectedDate = expectedDate.Format("2006-01-02")
+	if receivedDate.Valid {
+		date := receivedDate.Time.Format("2006-01-02")
+		income.ReceivedDate = &date
+	}
+	income.Description = nullString(description)
+	return income, nil
+}
+
+func (s *Store) ListIncomes(ctx context.Context, workspaceID string, m month.YearMonth) ([]store.Income, error) {
+	query := `SELECT ` + incomeColumns("$2") + ` FROM incomes WHERE workspace_id = $1`
+	args := []any{workspaceID, s.currency}
+	if !m.IsZero() {
+		query += ` AND reference_month >= $3 AND reference_month < $4`
+		args = append(args, m, m.Next())
+	}
+
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY expected_date, source, created_at`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	incomes := []store.Income{}
+	for rows.Next() {
+		income, err := scanIncome(rows)
+		if err != nil {
+			return nil, err
+		}
+		incomes = append(incomes, income)
+	}
+
+	return incomes, rows.Err()
+}
+
+func (s *Store) GetIncome(ctx context.Context, workspaceID, id string) (store.Income, error) {
+	if !isUUID(id) {
+		return store.Income{}, store.ErrNotFound
+	}
+
+	income, err := scanIncome(s.db.QueryRowContext(ctx, `SELECT `+incomeColumns("$3")+` FROM incomes WHERE income_id = $1 AND workspace_id = $2`, id, workspaceID, s.currency))
+	if err == sql.ErrNoRows {
+		return store.Income{}, store.ErrNotFound
+	}
+
+	return income, err
+}
+
+func (s *Store) CreateIncome(ctx context.Context, workspaceID string, input store.IncomeInput) (store.Income, error) {
+	return scanIncome(s.db.QueryRowContext(ctx, `
+		INSERT INTO incomes (workspace_id, source, amount, reference_month, expected_date, received_date, recurring, description, currency)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''))
+		RETURNING `+incomeColumns("$10"),
+		workspaceID, input.Source, input.Amount, input.ReferenceMonth, input.ExpectedDate, input.ReceivedDate,
+		input.Recurring, input.Description, input.Currency, s.currency))
+}
+
+func (s *Store) UpdateIncome(ctx context.Context, workspaceID, id string, input store.IncomeInput) (store.Income, error) {
+	if !isUUID(id) {
+		return store.Income{}, store.ErrNotFound
+	}
+
+	income, err := scanIncome(s.db.QueryRowContext(ctx, `
+		UPDATE incomes
+		SET source = $3, amount = $4, reference_month = $5, expected_date = $6, received_date = $7, recurring = $8,
+			description = NULLIF($9, ''), currency = NULLIF($10, '')
+		WHERE income_id = $1 AND workspace_id = $2
+		RETURNING `+incomeColumns("$11"),
+		id, workspaceID, input.Source, input.Amount, input.ReferenceMonth, input.ExpectedDate, input.ReceivedDate,
+		input.Recurring, input.Description, input.Currency, s.currency))
+	if err == sql.ErrNoRows {
+		return store.Income{}, store.ErrNotFound
+	}
+
+	return income, err
+}
+
+func (s *Store) DeleteIncome(ctx context.Context, workspaceID, id string) error {
+	if !isUUID(id) {
+		return store.ErrNotFound
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM incomes WHERE income_id = $1 AND workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
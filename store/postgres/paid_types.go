@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"go-sheet/store"
+)
+
+func (s *Store) ListPaidTypes(ctx context.Context, workspaceID string) ([]store.PaidType, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT paid_id, paid_type, paid_color, created_at FROM paid_type WHERE workspace_id = $1", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	paidTypes := []store.PaidType{}
+	for rows.Next() {
+		var paidType store.PaidType
+		err := rows.Scan(&paidType.ID, &paidType.Type, &paidType.PaidColor, &paidType.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		paidTypes = append(paidTypes, paidType)
+	}
+
+	return paidTypes, rows.Err()
+}
+
+func (s *Store) CreatePaidType(ctx context.Context, workspaceID string, paidType store.PaidType) (store.PaidType, error) {
+	query := "INSERT INTO paid_type (workspace_id, paid_type, paid_color) VALUES ($1, $2, $3) RETURNING paid_id"
+	err := s.db.QueryRowContext(ctx, query, workspaceID, paidType.Type, paidType.PaidColor).Scan(&paidType.ID)
+	return paidType, err
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+)
+
+func (s *Store) ListPlans(ctx context.Context, workspaceID, categoryID string) ([]store.CategoryPlan, error) {
+	if err := s.checkCategory(ctx, workspaceID, categoryID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT effective_month, amount_planned FROM category_plans WHERE category_id = $1 ORDER BY effective_month`, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []store.CategoryPlan{}
+	for rows.Next() {
+		var plan store.CategoryPlan
+		if err := rows.Scan(&plan.Month, &plan.PlannedAmount); err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+
+	return plans, rows.Err()
+}
+
+func (s *Store) SetPlan(ctx context.Context, workspaceID, categoryID string, m month.YearMonth, amount money.Amount) error {
+	if !isUUID(categoryID) {
+		return store.ErrNotFound
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockCategory(ctx, tx, workspaceID, categoryID); err != nil {
+			return err
+		}
+		return txSetPlan(ctx, tx, categoryID, m, amount)
+	})
+}
+
+func (s *Store) PlannedAmounts(ctx context.Context, workspaceID string, m month.YearMonth) (map[string]money.Amount, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT category_id, planned_amount(category_id, $2) FROM categories WHERE workspace_id = $1`, workspaceID, m)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	amounts := map[string]money.Amount{}
+	for rows.Next() {
+		var id string
+		var amount money.Amount
+		if err := rows.Scan(&id, &amount); err != nil {
+			return nil, err
+		}
+		amounts[id] = amount
+	}
+
+	return amounts, rows.Err()
+}
+
+// txSetPlan saves the plan of month m and copies the plans back into the
+// monthly rows of the category. Recomputing every row also covers the months
+// before m when m becomes the first plan.
+func txSetPlan(ctx context.Context, tx *sql.Tx, categoryID string, m month.YearMonth, amount money.Amount) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO category_plans (category_id, effective_month, amount_planned)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (category_id, effective_month) DO UPDATE SET amount_planned = EXCLUDED.amount_planned, updated_at = now()`,
+		categoryID, m, amount)
+	if err != nil {
+		return fmt.Errorf("save plan: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE monthly_expenses SET amount_planned = planned_amount(category_id, reference_month)
+		WHERE category_id = $1 AND amount_planned IS DISTINCT FROM planned_amount(category_id, reference_month)`, categoryID)
+	if err != nil {
+		return fmt.Errorf("update monthly rows: %w", err)
+	}
+	return nil
+}
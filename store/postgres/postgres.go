@@ -0,0 +1,51 @@
+// Package postgres implements the store interfaces on top of database/sql
+// and the schema in db/migrations.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/store"
+
+	"github.com/google/uuid"
+)
+
+// Store implements every store interface against a shared connection pool
+type Store struct {
+	db *sql.DB
+	// currency is the base currency of workspaces that never chose one
+	currency money.Currency
+}
+
+var _ store.Store = (*Store)(nil)
+
+// New returns a Store backed by the given pool; currency is the base
+// currency of workspaces that never set their own
+func New(db *sql.DB, currency money.Currency) *Store {
+	return &Store{db: db, currency: currency}
+}
+
+// isUUID guards lookups by id: Postgres rejects malformed uuids with an
+// error, but for callers they are simply ids that do not exist
+func isUUID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+// withTx runs fn inside a transaction, committing when it returns nil and
+// rolling back otherwise
+func (s *Store) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
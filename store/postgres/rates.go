@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/store"
+	"strings"
+)
+
+// nullCurrency stores the empty currency, meaning the base one, as NULL
+func nullCurrency(c money.Currency) *money.Currency {
+	if c == "" {
+		return nil
+	}
+	return &c
+}
+
+func (s *Store) BaseCurrency(ctx context.Context, workspaceID string) (money.Currency, error) {
+	if !isUUID(workspaceID) {
+		return "", store.ErrNotFound
+	}
+
+	var currency money.Currency
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(base_currency, $2) FROM workspaces WHERE workspace_id = $1`,
+		workspaceID, s.currency).Scan(&currency)
+	if err == sql.ErrNoRows {
+		return "", store.ErrNotFound
+	}
+
+	return currency, err
+}
+
+// SetBaseCurrency records the previous base currency on every amount that had
+// none before switching, so converted totals keep their meaning
+func (s *Store) SetBaseCurrency(ctx context.Context, workspaceID string, currency money.Currency) error {
+	if !isUUID(workspaceID) {
+		return store.ErrNotFound
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var previous money.Currency
+		err := tx.QueryRowContext(ctx, `SELECT COALESCE(base_currency, $2) FROM workspaces WHERE workspace_id = $1 FOR UPDATE`,
+			workspaceID, s.currency).Scan(&previous)
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		} else if err != nil {
+			return fmt.Errorf("get base currency: %w", err)
+		}
+		if previous == currency {
+			return nil
+		}
+
+		for _, table := range []string{"monthly_expenses", "incomes", "recurring_expenses"} {
+			_, err := tx.ExecContext(ctx, `UPDATE `+table+` SET currency = $2 WHERE workspace_id = $1 AND currency IS NULL`, workspaceID, previous)
+			if err != nil {
+				return fmt.Errorf("keep currency of %s: %w", table, err)
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE workspaces SET base_currency = $2 WHERE workspace_id = $1`, workspaceID, currency)
+		return err
+	})
+}
+
+func (s *Store) ImportRates(ctx context.Context, workspaceID string, rates []store.ExchangeRate) (int, error) {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO exchange_rates (workspace_id, from_currency, to_currency, rate_date, rate)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (workspace_id, from_currency, to_currency, rate_date) DO UPDATE SET rate = EXCLUDED.rate`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i, rate := range rates {
+			if _, err := stmt.ExecContext(ctx, workspaceID, rate.From, rate.To, rate.Date, rate.Rate); err != nil {
+				return fmt.Errorf("insert rate %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rates), nil
+}
+
+func (s *Store) ListRates(ctx context.Context, workspaceID string, from, to money.Currency) ([]store.ExchangeRate, error) {
+	where := []string{"workspace_id = $1"}
+	args := []any{workspaceID}
+	if from != "" {
+		args = append(args, from)
+		where = append(where, fmt.Sprintf("from_currency = $%d", len(args)))
+	}
+	if to != "" {
+		args = append(args, to)
+		where = append(where, fmt.Sprintf("to_currency = $%d", len(args)))
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT from_currency, to_currency, rate_date, rate
+		FROM exchange_rates
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY rate_date DESC, from_currency, to_currency`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []store.ExchangeRate{}
+	for rows.Next() {
+		var rate store.ExchangeRate
+		var date sql.NullTime
+		if err := rows.Scan(&rate.From, &rate.To, &date, &rate.Rate); err != nil {
+			return nil, err
+		}
+		rate.Date = date.Time.Format("2006-01-02")
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-sheet/store"
+)
+
+func (s *Store) SetReceipt(ctx context.Context, workspaceID, expenseID, key string) (string, error) {
+	if !isUUID(expenseID) {
+		return "", store.ErrNotFound
+	}
+
+	var previous string
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE monthly_expenses me SET receipt_key = NULLIF($3, '')
+		FROM (SELECT expense_id, receipt_key FROM monthly_expenses WHERE expense_id = $1 AND workspace_id = $2 FOR UPDATE) prev
+		WHERE me.expense_id = prev.expense_id
+		RETURNING COALESCE(prev.receipt_key, '')`, expenseID, workspaceID, key).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return "", store.ErrNotFound
+	}
+
+	return previous, err
+}
@@ -0,0 +1,313 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const recurringColumns = `r.recurring_id, r.workspace_id, r.category_id, c.category_name, r.paid_id, r.amount, COALESCE(r.currency, ''),
+	COALESCE(r.description, ''), r.frequency, r.every, r.day_of_month, r.start_date, r.end_date, r.occurrences,
+	r.materialized_through, r.created_at`
+
+const recurringFrom = `recurring_expenses r JOIN categories c ON c.category_id = r.category_id`
+
+func scanRecurring(row rowScanner) (store.RecurringExpense, error) {
+	var r store.RecurringExpense
+	var paidID sql.NullString
+	var dayOfMonth, occurrences sql.NullInt32
+	var endDate, materializedThrough sql.NullTime
+	err := row.Scan(&r.ID, &r.WorkspaceID, &r.CategoryID, &r.CategoryName, &paidID, &r.Amount, &r.Currency,
+		&r.Description, &r.Frequency, &r.Every, &dayOfMonth, &r.StartDate, &endDate, &occurrences,
+		&materializedThrough, &r.CreatedAt)
+	if err != nil {
+		return r, err
+	}
+
+	r.PaidID = nullString(paidID)
+	r.DayOfMonth = nullInt(dayOfMonth)
+	r.Occurrences = nullInt(occurrences)
+	r.EndDate = nullDate(endDate)
+	r.MaterializedThrough = nullDate(materializedThrough)
+	r.Exceptions = []store.OccurrenceException{}
+	return r, nil
+}
+
+// nullDate returns nil for NULL and the date as midnight UTC otherwise
+func nullDate(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	date := time.Date(t.Time.Year(), t.Time.Month(), t.Time.Day(), 0, 0, 0, 0, time.UTC)
+	return &date
+}
+
+// queryRecurring runs a query selecting recurringColumns and loads the
+// exceptions of every row
+func (s *Store) queryRecurring(ctx context.Context, query string, args ...any) ([]store.RecurringExpense, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []store.RecurringExpense{}
+	byID := map[string]int{}
+	for rows.Next() {
+		r, err := scanRecurring(rows)
+		if err != nil {
+			return nil, err
+		}
+		r.StartDate = *nullDate(sql.NullTime{Time: r.StartDate, Valid: true})
+		byID[r.ID] = len(list)
+		list = append(list, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return list, nil
+	}
+
+	ids := make([]string, 0, len(list))
+	for _, r := range list {
+		ids = append(ids, r.ID)
+	}
+	exceptions, err := s.db.QueryContext(ctx, `
+		SELECT recurring_id, occurrence_date, skip, amount, description, paid_id
+		FROM recurring_exceptions
+		WHERE recurring_id = ANY($1::uuid[])
+		ORDER BY occurrence_date`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer exceptions.Close()
+
+	for exceptions.Next() {
+		var recurringID string
+		var e store.OccurrenceException
+		var amount money.NullAmount
+		var description, paidID sql.NullString
+		if err := exceptions.Scan(&recurringID, &e.Date, &e.Skip, &amount, &description, &paidID); err != nil {
+			return nil, err
+		}
+		e.Date = *nullDate(sql.NullTime{Time: e.Date, Valid: true})
+		e.Amount = amount.Ptr()
+		e.Description = nullString(description)
+		e.PaidID = nullString(paidID)
+
+		i := byID[recurringID]
+		list[i].Exceptions = append(list[i].Exceptions, e)
+	}
+
+	return list, exceptions.Err()
+}
+
+func (s *Store) ListRecurring(ctx context.Context, workspaceID string) ([]store.RecurringExpense, error) {
+	return s.queryRecurring(ctx, `SELECT `+recurringColumns+` FROM `+recurringFrom+`
+		WHERE r.workspace_id = $1
+		ORDER BY r.start_date, c.category_name, r.created_at`, workspaceID)
+}
+
+func (s *Store) GetRecurring(ctx context.Context, workspaceID, id string) (store.RecurringExpense, error) {
+	if !isUUID(id) {
+		return store.RecurringExpense{}, store.ErrNotFound
+	}
+
+	list, err := s.queryRecurring(ctx, `SELECT `+recurringColumns+` FROM `+recurringFrom+`
+		WHERE r.recurring_id = $1 AND r.workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return store.RecurringExpense{}, err
+	}
+	if len(list) == 0 {
+		return store.RecurringExpense{}, store.ErrNotFound
+	}
+
+	return list[0], nil
+}
+
+// checkRecurringReferences verifies the category and paid type belong to the workspace
+func (s *Store) checkRecurringReferences(ctx context.Context, workspaceID string, r store.RecurringExpense) error {
+	if _, err := s.plannedAmount(ctx, workspaceID, r.CategoryID, month.Current()); err != nil {
+		return err
+	}
+	if r.PaidID != nil {
+		return s.checkPaidType(ctx, workspaceID, *r.PaidID)
+	}
+	return nil
+}
+
+func (s *Store) CreateRecurring(ctx context.Context, workspaceID string, r store.RecurringExpense) (store.RecurringExpense, error) {
+	if err := s.checkRecurringReferences(ctx, workspaceID, r); err != nil {
+		return store.RecurringExpense{}, err
+	}
+
+	var id string
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO recurring_expenses (workspace_id, category_id, paid_id, amount, description, frequency, every,
+			day_of_month, start_date, end_date, occurrences, currency)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, NULLIF($12, ''))
+		RETURNING recurring_id`,
+		workspaceID, r.CategoryID, r.PaidID, r.Amount, r.Description, r.Frequency, r.Every,
+		r.DayOfMonth, r.StartDate, r.EndDate, r.Occurrences, r.Currency).Scan(&id)
+	if err != nil {
+		return store.RecurringExpense{}, err
+	}
+
+	return s.GetRecurring(ctx, workspaceID, id)
+}
+
+func (s *Store) UpdateRecurring(ctx context.Context, workspaceID, id string, r store.RecurringExpense) (store.RecurringExpense, error) {
+	if !isUUID(id) {
+		return store.RecurringExpense{}, store.ErrNotFound
+	}
+	if err := s.checkRecurringReferences(ctx, workspaceID, r); err != nil {
+		return store.RecurringExpense{}, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE recurring_expenses
+		SET category_id = $3, paid_id = $4, amount = $5, description = NULLIF($6, ''), frequency = $7, every = $8,
+			day_of_month = $9, start_date = $10, end_date = $11, occurrences = $12, currency = NULLIF($13, '')
+		WHERE recurring_id = $1 AND workspace_id = $2`,
+		id, workspaceID, r.CategoryID, r.PaidID, r.Amount, r.Description, r.Frequency, r.Every,
+		r.DayOfMonth, r.StartDate, r.EndDate, r.Occurrences, r.Currency)
+	if err != nil {
+		return store.RecurringExpense{}, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return store.RecurringExpense{}, err
+	} else if n == 0 {
+		return store.RecurringExpense{}, store.ErrNotFound
+	}
+
+	return s.GetRecurring(ctx, workspaceID, id)
+}
+
+func (s *Store) DeleteRecurring(ctx context.Context, workspaceID, id string) error {
+	if !isUUID(id) {
+		return store.ErrNotFound
+	}
+
+	// As despesas já criadas ficam, apenas perdem o vínculo (ON DELETE SET NULL)
+	result, err := s.db.ExecContext(ctx, `DELETE FROM recurring_expenses WHERE recurring_id = $1 AND workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) SetOccurrenceException(ctx context.Context, workspaceID, recurringID string, e store.OccurrenceException) error {
+	if _, err := s.GetRecurring(ctx, workspaceID, recurringID); err != nil {
+		return err
+	}
+	if e.PaidID != nil {
+		if err := s.checkPaidType(ctx, workspaceID, *e.PaidID); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO recurring_exceptions (recurring_id, occurrence_date, skip, amount, description, paid_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (recurring_id, occurrence_date)
+		DO UPDATE SET skip = EXCLUDED.skip, amount = EXCLUDED.amount, description = EXCLUDED.description, paid_id = EXCLUDED.paid_id`,
+		recurringID, e.Date, e.Skip, e.Amount, e.Description, e.PaidID)
+	return err
+}
+
+func (s *Store) DeleteOccurrenceException(ctx context.Context, workspaceID, recurringID string, date time.Time) error {
+	if !isUUID(recurringID) {
+		return store.ErrNotFound
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM recurring_exceptions e
+		USING recurring_expenses r
+		WHERE e.recurring_id = r.recurring_id AND r.recurring_id = $1 AND r.workspace_id = $2 AND e.occurrence_date = $3`,
+		recurringID, workspaceID, date)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) DueRecurring(ctx context.Context, through time.Time) ([]store.RecurringExpense, error) {
+	return s.queryRecurring(ctx, `SELECT `+recurringColumns+` FROM `+recurringFrom+`
+		WHERE r.start_date <= $1::date
+			AND c.archived_at IS NULL
+			AND (r.materialized_through IS NULL OR r.materialized_through < $1::date)
+			AND (r.end_date IS NULL OR r.materialized_through IS NULL OR r.materialized_through < r.end_date)
+		ORDER BY r.workspace_id, r.recurring_id`, through)
+}
+
+func (s *Store) MaterializeRecurring(ctx context.Context, r store.RecurringExpense, occurrences []store.Occurrence, through time.Time) ([]string, error) {
+	ids := []string{}
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		// Trava a recorrência para que duas execuções simultâneas não avancem a mesma marca
+		var materialized sql.NullTime
+		err := tx.QueryRowContext(ctx, `SELECT materialized_through FROM recurring_expenses WHERE recurring_id = $1 FOR UPDATE`, r.ID).Scan(&materialized)
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		} else if err != nil {
+			return err
+		}
+
+		// Cada ocorrência copia o plano vigente no seu mês
+		if _, err := txPlannedAmount(ctx, tx, r.WorkspaceID, r.CategoryID, month.Current()); err != nil {
+			return err
+		}
+		var pending string
+		err = tx.QueryRowContext(ctx, "SELECT status_id FROM status WHERE status_name = 'pending' AND workspace_id IS NULL").Scan(&pending)
+		if err != nil {
+			return fmt.Errorf("get pending status: %w", err)
+		}
+
+		for _, occurrence := range occurrences {
+			if materialized.Valid && !occurrence.Date.After(materialized.Time) {
+				continue
+			}
+			var id string
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO monthly_expenses (workspace_id, category_id, reference_month, spent_amount, amount_planned,
+					payment_date, paid_id, status_id, description, recurring_id, occurrence_date, currency)
+				VALUES ($1, $2, $3, $4, planned_amount($2, $3), $5, $6, $7, NULLIF($8, ''), $9, $5, NULLIF($10, ''))
+				ON CONFLICT (recurring_id, occurrence_date) WHERE recurring_id IS NOT NULL DO NOTHING
+				RETURNING expense_id`,
+				r.WorkspaceID, r.CategoryID, month.Of(occurrence.Date), occurrence.Amount,
+				occurrence.Date, occurrence.PaidID, pending, occurrence.Description, r.ID, occurrence.Currency).Scan(&id)
+			if err == sql.ErrNoRows {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("materialize %s: %w", occurrence.Date.Format(time.DateOnly), err)
+			}
+			ids = append(ids, id)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE recurring_expenses SET materialized_through = GREATEST(materialized_through, $2::date)
+			WHERE recurring_id = $1`, r.ID, through)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
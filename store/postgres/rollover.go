@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"go-sheet/month"
+)
+
+func (s *Store) EnsurePlannedExpenses(ctx context.Context, m month.YearMonth) (int, error) {
+	sqlQuery := `
+		INSERT INTO monthly_expenses (workspace_id, category_id, reference_month, amount_planned, description, status_id, is_planned)
+		SELECT 
+			c.workspace_id,
+			c.category_id, 
+			$1::date, 
+			planned_amount(c.category_id, $1::date), 
+			c.description, 
+			(SELECT status_id FROM status WHERE status_name = 'pending' AND workspace_id IS NULL), 
+			true
+		FROM 
+			categories c
+		WHERE 
+			date_trunc('month', c.created_at) <= $1::date
+			AND c.archived_at IS NULL
+		ON CONFLICT (category_id, reference_month) WHERE is_planned DO NOTHING
+	`
+
+	result, err := s.db.ExecContext(ctx, sqlQuery, m)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := result.RowsAffected()
+	return int(created), err
+}
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-sheet/money"
+	"go-sheet/month"
+	"go-sheet/store"
+)
+
+const ruleColumns = `rule_id, name, priority, enabled, description_pattern, min_amount, max_amount,
+	match_paid_id, day_from, day_to, set_category_id, set_paid_id, set_status_id, created_at`
+
+func scanRule(row rowScanner) (store.Rule, error) {
+	var rule store.Rule
+	var pattern, matchPaid, setCategory, setPaid, setStatus sql.NullString
+	var minAmount, maxAmount money.NullAmount
+	var dayFrom, dayTo sql.NullInt32
+	err := row.Scan(&rule.ID, &rule.Name, &rule.Priority, &rule.Enabled, &pattern, &minAmount, &maxAmount,
+		&matchPaid, &dayFrom, &dayTo, &setCategory, &setPaid, &setStatus, &rule.CreatedAt)
+	if err != nil {
+		return rule, err
+	}
+
+	rule.Match = store.RuleMatch{
+		Description: nullString(pattern),
+		MinAmount:   minAmount.Ptr(),
+		MaxAmount:   maxAmount.Ptr(),
+		PaidID:      nullString(matchPaid),
+		DayFrom:     nullInt(dayFrom),
+		DayTo:       nullInt(dayTo),
+	}
+	rule.Set = store.RuleActions{
+		CategoryID: nullString(setCategory),
+		PaidID:     nullString(setPaid),
+		StatusID:   nullString(setStatus),
+	}
+	return rule, nil
+}
+
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func nullInt(n sql.NullInt32) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int32)
+	return &v
+}
+
+// checkRuleReferences makes sure every id of the rule belongs to the workspace
+func (s *Store) checkRuleReferences(ctx context.Context, workspaceID string, rule store.Rule) error {
+	if id := rule.Set.CategoryID; id != nil {
+		if _, err := s.plannedAmount(ctx, workspaceID, *id, month.Current()); err != nil {
+			return err
+		}
+	}
+	for _, id := range []*string{rule.Match.PaidID, rule.Set.PaidID} {
+		if id != nil {
+			if err := s.checkPaidType(ctx, workspaceID, *id); err != nil {
+				return err
+			}
+		}
+	}
+	if id := rule.Set.StatusID; id != nil {
+		return s.checkStatus(ctx, workspaceID, *id)
+	}
+	return nil
+}
+
+func (s *Store) ListRules(ctx context.Context, workspaceID string) ([]store.Rule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+ruleColumns+` FROM categorization_rules
+		WHERE workspace_id = $1 ORDER BY priority, created_at, rule_id`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []store.Rule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (s *Store) GetRule(ctx context.Context, workspaceID, id string) (store.Rule, error) {
+	if !isUUID(id) {
+		return store.Rule{}, store.ErrNotFound
+	}
+
+	rule, err := scanRule(s.db.QueryRowContext(ctx, `SELECT `+ruleColumns+` FROM categorization_rules
+		WHERE rule_id = $1 AND workspace_id = $2`, id, workspaceID))
+	if err == sql.ErrNoRows {
+		return store.Rule{}, store.ErrNotFound
+	}
+
+	return rule, err
+}
+
+func (s *Store) CreateRule(ctx context.Context, workspaceID string, rule store.Rule) (store.Rule, error) {
+	if err := s.checkRuleReferences(ctx, workspaceID, rule); err != nil {
+		return store.Rule{}, err
+	}
+
+	match, set := rule.Match, rule.Set
+	return scanRule(s.db.QueryRowContext(ctx, `
+		INSERT INTO categorization_rules (workspace_id, name, priority, enabled, description_pattern, min_amount, max_amount,
+			match_paid_id, day_from, day_to, set_category_id, set_paid_id, set_status_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING `+ruleColumns,
+		workspaceID, rule.Name, rule.Priority, rule.Enabled, match.Description, match.MinAmount, match.MaxAmount,
+		match.PaidID, match.DayFrom, match.DayTo, set.CategoryID, set.PaidID, set.StatusID))
+}
+
+func (s *Store) UpdateRule(ctx context.Context, workspaceID, id string, rule store.Rule) (store.Rule, error) {
+	if !isUUID(id) {
+		return store.Rule{}, store.ErrNotFound
+	}
+	if err := s.checkRuleReferences(ctx, workspaceID, rule); err != nil {
+		return store.Rule{}, err
+	}
+
+	match, set := rule.Match, rule.Set
+	updated, err := scanRule(s.db.QueryRowContext(ctx, `
+		UPDATE categorization_rules
+		SET name = $3, priority = $4, enabled = $5, description_pattern = $6, min_amount = $7, max_amount = $8,
+			match_paid_id = $9, day_from = $10, day_to = $11, set_category_id = $12, set_paid_id = $13, set_status_id = $14
+		WHERE rule_id = $1 AND workspace_id = $2
+		RETURNING `+ruleColumns,
+		id, workspaceID, rule.Name, rule.Priority, rule.Enabled, match.Description, match.MinAmount, match.MaxAmount,
+		match.PaidID, match.DayFrom, match.DayTo, set.CategoryID, set.PaidID, set.StatusID))
+	if err == sql.ErrNoRows {
+		return store.Rule{}, store.ErrNotFound
+	}
+
+	return updated, err
+}
+
+func (s *Store) DeleteRule(ctx context.Context, workspaceID, id string) error {
+	if !isUUID(id) {
+		return store.ErrNotFound
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM categorization_rules WHERE rule_id = $1 AND workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
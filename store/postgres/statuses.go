@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-sheet/store"
+)
+
+func (s *Store) ListStatuses(ctx context.Context, workspaceID string) ([]store.Status, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT status_id, status_name FROM status WHERE workspace_id = $1 OR workspace_id IS NULL`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []store.Status
+	for rows.Next() {
+		var status store.Status
+		if err := rows.Scan(&status.ID, &status.StatusName); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, rows.Err()
+}
+
+// CreateStatus returns store.ErrConflict when a status with the same name is
+// already visible in the workspace
+func (s *Store) CreateStatus(ctx context.Context, workspaceID, name string) (store.Status, error) {
+	status := store.Status{StatusName: name}
+
+	// Check if status with the same name already exists
+	var existingID string
+	checkQuery := `SELECT status_id FROM status WHERE status_name = $1 AND (workspace_id = $2 OR workspace_id IS NULL) LIMIT 1`
+	err := s.db.QueryRowContext(ctx, checkQuery, name, workspaceID).Scan(&existingID)
+	if err == nil {
+		return status, store.ErrConflict
+	} else if err != sql.ErrNoRows {
+		return status, err
+	}
+
+	// If no existing status found, proceed with insertion
+	sqlQuery := `INSERT INTO status (workspace_id, status_name) VALUES ($1, $2) RETURNING status_id`
+	err = s.db.QueryRowContext(ctx, sqlQuery, workspaceID, name).Scan(&status.ID)
+	return status, err
+}
+
+// DeleteStatus only removes the workspace's own statuses; the shared defaults give
+// store.ErrSystemStatus
+func (s *Store) DeleteStatus(ctx context.Context, workspaceID, id string) error {
+	if !isUUID(id) {
+		return store.ErrNotFound
+	}
+
+	var workspace sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT workspace_id FROM status WHERE status_id = $1 AND (workspace_id = $2 OR workspace_id IS NULL)`, id, workspaceID).Scan(&workspace)
+	if err == sql.ErrNoRows {
+		return store.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if !workspace.Valid {
+		return store.ErrSystemStatus
+	}
+
+	_, err = s.db.ExecContext(ctx, `DELETE FROM status WHERE status_id = $1 AND workspace_id = $2`, id, workspaceID)
+	return err
+}
@@ -0,0 +1,307 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-sheet/store"
+)
+
+// EnsurePersonalWorkspace creates the workspace and its owner membership in
+// one statement; nothing happens when the workspace already exists
+func (s *Store) EnsurePersonalWorkspace(ctx context.Context, userID, email string) error {
+	sqlQuery := `
+		WITH created AS (
+			INSERT INTO workspaces (workspace_id, name) VALUES ($1, 'Personal')
+			ON CONFLICT (workspace_id) DO NOTHING
+			RETURNING workspace_id
+		)
+		INSERT INTO workspace_members (workspace_id, user_id, email, role)
+		SELECT workspace_id, workspace_id, $2, 'owner' FROM created`
+
+	_, err := s.db.ExecContext(ctx, sqlQuery, userID, email)
+	return err
+}
+
+func (s *Store) ListWorkspaces(ctx context.Context, userID string) ([]store.Workspace, error) {
+	sqlQuery := `
+		SELECT w.workspace_id, w.name, m.role, COALESCE(w.base_currency, $2), w.created_at
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.workspace_id
+		WHERE m.user_id = $1
+		ORDER BY w.created_at`
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, userID, s.currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []store.Workspace{}
+	for rows.Next() {
+		var workspace store.Workspace
+		if err := rows.Scan(&workspace.ID, &workspace.Name, &workspace.Role, &workspace.BaseCurrency, &workspace.CreatedAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, rows.Err()
+}
+
+// CreateWorkspace inserts the workspace with the user as its owner
+func (s *Store) CreateWorkspace(ctx context.Context, userID, email, name string) (store.Workspace, error) {
+	workspace := store.Workspace{Name: name, Role: store.RoleOwner, BaseCurrency: s.currency}
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `INSERT INTO workspaces (name) VALUES ($1) RETURNING workspace_id, created_at`, name).
+			Scan(&workspace.ID, &workspace.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("insert workspace: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO workspace_members (workspace_id, user_id, email, role) VALUES ($1, $2, $3, $4)`,
+			workspace.ID, userID, email, store.RoleOwner)
+		if err != nil {
+			return fmt.Errorf("insert owner: %w", err)
+		}
+
+		return nil
+	})
+
+	return workspace, err
+}
+
+func (s *Store) MemberRole(ctx context.Context, workspaceID, userID string) (store.Role, error) {
+	if !isUUID(workspaceID) || !isUUID(userID) {
+		return "", store.ErrNotFound
+	}
+
+	var role store.Role
+	err := s.db.QueryRowContext(ctx, `SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`, workspaceID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", store.ErrNotFound
+	}
+
+	return role, err
+}
+
+func (s *Store) ListMembers(ctx context.Context, workspaceID string) ([]store.Member, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT user_id, email, role, created_at FROM workspace_members WHERE workspace_id = $1 ORDER BY created_at`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []store.Member{}
+	for rows.Next() {
+		var member store.Member
+		if err := rows.Scan(&member.UserID, &member.Email, &member.Role, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// lockMembers reads the roles of a workspace, locking the rows so concurrent
+// changes cannot remove the last owner between the check and the write
+func lockMembers(ctx context.Context, tx *sql.Tx, workspaceID string) (map[string]store.Role, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT user_id, role FROM workspace_members WHERE workspace_id = $1 FOR UPDATE`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := map[string]store.Role{}
+	for rows.Next() {
+		var userID string
+		var role store.Role
+		if err := rows.Scan(&userID, &role); err != nil {
+			return nil, err
+		}
+		roles[userID] = role
+	}
+
+	return roles, rows.Err()
+}
+
+// checkOwners returns store.ErrLastOwner when userID is the only owner and
+// would stop being one
+func checkOwners(roles map[string]store.Role, userID string) error {
+	if roles[userID] != store.RoleOwner {
+		return nil
+	}
+	owners := 0
+	for _, role := range roles {
+		if role == store.RoleOwner {
+			owners++
+		}
+	}
+	if owners == 1 {
+		return store.ErrLastOwner
+	}
+	return nil
+}
+
+func (s *Store) SetMemberRole(ctx context.Context, workspaceID, userID string, role store.Role) error {
+	if !isUUID(userID) {
+		return store.ErrNotFound
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		roles, err := lockMembers(ctx, tx, workspaceID)
+		if err != nil {
+			return fmt.Errorf("lock members: %w", err)
+		}
+		if _, ok := roles[userID]; !ok {
+			return store.ErrNotFound
+		}
+		if role != store.RoleOwner {
+			if err := checkOwners(roles, userID); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE workspace_members SET role = $1 WHERE workspace_id = $2 AND user_id = $3`, role, workspaceID, userID)
+		return err
+	})
+}
+
+func (s *Store) RemoveMember(ctx context.Context, workspaceID, userID string) error {
+	if !isUUID(userID) {
+		return store.ErrNotFound
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		roles, err := lockMembers(ctx, tx, workspaceID)
+		if err != nil {
+			return fmt.Errorf("lock members: %w", err)
+		}
+		if _, ok := roles[userID]; !ok {
+			return store.ErrNotFound
+		}
+		if err := checkOwners(roles, userID); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`, workspaceID, userID)
+		return err
+	})
+}
+
+const inviteColumns = `invite_id, workspace_id, email, role, invited_by, created_at`
+
+func scanInvite(row interface{ Scan(dest ...any) error }) (store.Invite, error) {
+	var invite store.Invite
+	err := row.Scan(&invite.ID, &invite.WorkspaceID, &invite.Email, &invite.Role, &invite.InvitedBy, &invite.CreatedAt)
+	return invite, err
+}
+
+// CreateInvite returns store.ErrConflict when the email already belongs to a
+// member or has a pending invite
+func (s *Store) CreateInvite(ctx context.Context, workspaceID, invitedBy, email string, role store.Role) (store.Invite, error) {
+	var member bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM workspace_members WHERE workspace_id = $1 AND lower(email) = lower($2))`, workspaceID, email).Scan(&member)
+	if err != nil {
+		return store.Invite{}, err
+	}
+	if member {
+		return store.Invite{}, store.ErrConflict
+	}
+
+	sqlQuery := `INSERT INTO workspace_invites (workspace_id, email, role, invited_by) VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+		RETURNING ` + inviteColumns
+	invite, err := scanInvite(s.db.QueryRowContext(ctx, sqlQuery, workspaceID, email, role, invitedBy))
+	if err == sql.ErrNoRows {
+		return store.Invite{}, store.ErrConflict
+	}
+
+	return invite, err
+}
+
+func (s *Store) listInvites(ctx context.Context, where string, arg string) ([]store.Invite, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+inviteColumns+` FROM workspace_invites WHERE `+where+` ORDER BY created_at`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []store.Invite{}
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+
+	return invites, rows.Err()
+}
+
+func (s *Store) ListInvites(ctx context.Context, workspaceID string) ([]store.Invite, error) {
+	return s.listInvites(ctx, `workspace_id = $1`, workspaceID)
+}
+
+func (s *Store) ListInvitesFor(ctx context.Context, email string) ([]store.Invite, error) {
+	return s.listInvites(ctx, `lower(email) = lower($1)`, email)
+}
+
+func (s *Store) DeleteInvite(ctx context.Context, workspaceID, id string) error {
+	if !isUUID(id) {
+		return store.ErrNotFound
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM workspace_invites WHERE invite_id = $1 AND workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// AcceptInvite consumes the invite and adds the membership in a single
+// transaction. Users who are already members keep their current role.
+func (s *Store) AcceptInvite(ctx context.Context, id, userID, email string) (store.Workspace, error) {
+	if !isUUID(id) || email == "" {
+		return store.Workspace{}, store.ErrNotFound
+	}
+
+	var workspace store.Workspace
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var role store.Role
+		err := tx.QueryRowContext(ctx, `DELETE FROM workspace_invites WHERE invite_id = $1 AND lower(email) = lower($2) RETURNING workspace_id, role`, id, email).
+			Scan(&workspace.ID, &role)
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		} else if err != nil {
+			return fmt.Errorf("delete invite: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO workspace_members (workspace_id, user_id, email, role) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (workspace_id, user_id) DO NOTHING`, workspace.ID, userID, email, role)
+		if err != nil {
+			return fmt.Errorf("insert member: %w", err)
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			SELECT w.name, m.role, COALESCE(w.base_currency, $3), w.created_at
+			FROM workspaces w
+			JOIN workspace_members m ON m.workspace_id = w.workspace_id
+			WHERE w.workspace_id = $1 AND m.user_id = $2`, workspace.ID, userID, s.currency).
+			Scan(&workspace.Name, &workspace.Role, &workspace.BaseCurrency, &workspace.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("get workspace: %w", err)
+		}
+
+		return nil
+	})
+
+	return workspace, err
+}
@@ -0,0 +1,708 @@
+// Package store defines the persistence contracts used by the HTTP handlers.
+//
+// Every row belongs to a workspace (a shared budget). Methods only ever see
+// the rows of the workspaceID they are given: ids from another workspace
+// behave exactly like ids that do not exist. Who may use a workspace is
+// decided by WorkspaceStore memberships before these methods are reached.
+//
+// Handlers depend only on the interfaces declared here; store/postgres
+// implements them on top of database/sql and store/memory keeps everything
+// in process so handlers can be exercised without a database.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-sheet/money"
+	"go-sheet/month"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a unique value is already taken
+	ErrConflict = errors.New("already exists")
+	// ErrUnknownCategory is returned when an expense references a missing category
+	ErrUnknownCategory = errors.New("category not found")
+	// ErrUnknownPaidType is returned when an expense references a missing paid type
+	ErrUnknownPaidType = errors.New("paid type not found")
+	// ErrUnknownStatus is returned when an expense references a missing status
+	ErrUnknownStatus = errors.New("status not found")
+	// ErrSystemStatus is returned when deleting one of the shared default statuses
+	ErrSystemStatus = errors.New("default statuses cannot be deleted")
+	// ErrLastOwner is returned when a change would leave a workspace without owners
+	ErrLastOwner = errors.New("workspace must keep at least one owner")
+	// ErrCategoryCycle is returned when moving a category under itself or
+	// one of its subcategories
+	ErrCategoryCycle = errors.New("a category cannot be moved under itself or its subcategories")
+	// ErrHasSubcategories is returned when archiving a category that still
+	// has subcategories in use
+	ErrHasSubcategories = errors.New("category still has subcategories")
+	// ErrCategoryArchived is returned when new expenses, rules, recurring
+	// expenses or subcategories would point at an archived category
+	ErrCategoryArchived = errors.New("category is archived")
+)
+
+// Store groups every store the API needs
+type Store interface {
+	ExpenseStore
+	CategoryStore
+	PlanStore
+	PaidTypeStore
+	StatusStore
+	AnalyticsStore
+	RolloverStore
+	WorkspaceStore
+	ImportStore
+	RuleStore
+	ReceiptStore
+	AlertStore
+	RecurringStore
+	IncomeStore
+	CurrencyStore
+}
+
+// Expense is a monthly expense joined with its category, paid type and status.
+// SpentAmount and Difference are in the workspace's base currency, converted
+// with the rate of the payment date; OriginalAmount is the amount paid, in
+// Currency. SpentAmount is nil when no rate covers the payment date.
+type Expense struct {
+	ExpenseID      string         `json:"expenseId"`
+	CategoryName   string         `json:"categoryName"`
+	ReferenceMonth *string        `json:"referenceMonth"` // colocar * significa que o campo é opcional
+	SpentAmount    *money.Amount  `json:"spentAmount"`
+	PlannedAmount  money.Amount   `json:"plannedAmount"`
+	Difference     *money.Amount  `json:"difference"`
+	PaymentDate    *string        `json:"paymentDate"`
+	File           *string        `json:"file"`
+	PaidId         *string        `json:"paidId"`
+	PaidType       *string        `json:"paidType"`
+	PaidColor      *string        `json:"paidColor"`
+	StatusId       *string        `json:"statusId"`
+	StatusName     *string        `json:"statusName"`
+	Description    *string        `json:"description"`
+	Currency       money.Currency `json:"currency"`
+	OriginalAmount *money.Amount  `json:"originalAmount"`
+	// ReceiptKey is the blob holding the uploaded receipt, if any; handlers
+	// expose it as a download URL in File
+	ReceiptKey string `json:"-"`
+}
+
+// ExpenseInput carries every editable field of an expense
+type ExpenseInput struct {
+	CategoryID     string
+	ReferenceMonth month.YearMonth
+	PaidID         string
+	SpentAmount    money.Amount
+	PaymentDate    time.Time
+	File           string
+	// StatusID is only written by CreateExpense; empty leaves the expense
+	// without a status
+	StatusID    string
+	Description string
+	// Currency of SpentAmount; empty for the base currency
+	Currency money.Currency
+}
+
+// ExpensePatch carries the fields of a partial update; nil fields are left untouched
+type ExpensePatch struct {
+	CategoryID     *string
+	ReferenceMonth *month.YearMonth
+	PaidID         *string
+	SpentAmount    *money.Amount
+	PaymentDate    *time.Time
+	File           *string
+	StatusID       *string
+	Description    *string
+	Currency       *money.Currency
+}
+
+// ExpenseStore persists monthly expenses
+type ExpenseStore interface {
+	ListExpenses(ctx context.Context, workspaceID string, query ExpenseQuery) (ExpensePage, error)
+	GetExpense(ctx context.Context, workspaceID, id string) (Expense, error)
+	CreateExpense(ctx context.Context, workspaceID string, input ExpenseInput) (string, error)
+	UpdateExpense(ctx context.Context, workspaceID, id string, input ExpenseInput) (Expense, error)
+	PatchExpense(ctx context.Context, workspaceID, id string, patch ExpensePatch) (Expense, error)
+	DeleteExpense(ctx context.Context, workspaceID, id string) error
+}
+
+// ImportedExpense is a statement entry accepted for import. The reference
+// month is the month of PaymentDate; empty PaidID and StatusID are left NULL
+// and the default paid status respectively.
+type ImportedExpense struct {
+	CategoryID  string
+	PaidID      string
+	StatusID    string
+	SpentAmount money.Amount
+	PaymentDate time.Time
+	Description string
+	// ImportRef identifies the entry in its source statement; a workspace
+	// never holds two expenses with the same non-empty ImportRef
+	ImportRef string
+}
+
+// ExpenseFingerprint is what an import compares its entries against, to
+// detect duplicates and to suggest categories
+type ExpenseFingerprint struct {
+	ExpenseID   string
+	CategoryID  string
+	PaymentDate time.Time
+	SpentAmount money.Amount
+	Description string
+	ImportRef   string
+}
+
+// ImportError names the expense of an import that failed. Index is its
+// position in the slice given to ImportExpenses.
+type ImportError struct {
+	Index int
+	Err   error
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("expense %d: %v", e.Index, e.Err)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// ImportStore persists expenses imported from bank statements
+type ImportStore interface {
+	// ExpenseFingerprints lists the expenses paid between from and to, both
+	// inclusive
+	ExpenseFingerprints(ctx context.Context, workspaceID string, from, to time.Time) ([]ExpenseFingerprint, error)
+	// ImportExpenses creates every expense in one transaction and returns
+	// their ids. When one of them fails nothing is created and the error is
+	// an *ImportError wrapping ErrUnknownCategory, ErrUnknownPaidType,
+	// ErrUnknownStatus or ErrConflict (ImportRef already imported).
+	ImportExpenses(ctx context.Context, workspaceID string, expenses []ImportedExpense) ([]string, error)
+}
+
+// ReceiptStore records which blob holds the receipt of an expense
+type ReceiptStore interface {
+	// SetReceipt replaces the receipt key of an expense, "" removing it, and
+	// returns the previous key
+	SetReceipt(ctx context.Context, workspaceID, expenseID, key string) (string, error)
+}
+
+// Alert records that a category's spending reached Threshold percent of its
+// planned amount in a month
+type Alert struct {
+	ID             string          `json:"alertId"`
+	WorkspaceID    string          `json:"workspaceId"`
+	CategoryID     string          `json:"categoryId"`
+	CategoryName   string          `json:"categoryName"`
+	ReferenceMonth month.YearMonth `json:"referenceMonth"`
+	Threshold      int             `json:"threshold"`
+	SpentAmount    money.Amount    `json:"spentAmount"`
+	PlannedAmount  money.Amount    `json:"plannedAmount"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	AcknowledgedAt *time.Time      `json:"acknowledgedAt"`
+	AcknowledgedBy *string         `json:"acknowledgedBy"`
+}
+
+// AlertQuery filters ListAlerts; zero fields match everything
+type AlertQuery struct {
+	Month          month.YearMonth
+	CategoryID     string
+	Unacknowledged bool
+}
+
+// AlertStore keeps the alert thresholds of categories and the alerts raised
+type AlertStore interface {
+	// AlertThresholds returns the percentages of a category, ascending
+	AlertThresholds(ctx context.Context, workspaceID, categoryID string) ([]int, error)
+	// SetAlertThresholds replaces the percentages of a category
+	SetAlertThresholds(ctx context.Context, workspaceID, categoryID string, percents []int) error
+	// EvaluateAlerts checks the categories and months of the given expenses
+	// and returns the alerts raised for thresholds crossed for the first
+	// time. A category without a planned amount raises nothing.
+	EvaluateAlerts(ctx context.Context, workspaceID string, expenseIDs []string) ([]Alert, error)
+	// ListAlerts returns the newest alerts first
+	ListAlerts(ctx context.Context, workspaceID string, query AlertQuery) ([]Alert, error)
+	// AcknowledgeAlert marks an alert as seen by userID; acknowledging it
+	// again keeps the first acknowledgement
+	AcknowledgeAlert(ctx context.Context, workspaceID, id, userID string) (Alert, error)
+}
+
+// Category is a budget line as listed by GetCategories. ParentID is nil for
+// top-level categories and ArchivedAt for the ones in use. PlannedAmount is
+// the plan effective in the current month.
+type Category struct {
+	CategoryID     string         `json:"categoryId"`
+	CategoryName   string         `json:"categoryName"`
+	ParentID       *string        `json:"parentId"`
+	PlannedAmount  money.Amount   `json:"plannedAmount"`
+	Color          string         `json:"color"`
+	ReferenceMonth sql.NullString `json:"referenceMonth"`
+	ArchivedAt     *time.Time     `json:"archivedAt"`
+}
+
+// CategoryInput carries the editable fields of a category. ParentID is only
+// read on create; MoveCategory changes it afterwards.
+type CategoryInput struct {
+	Name          string
+	ParentID      *string
+	PlannedAmount money.Amount
+	Color         string
+	Description   string
+}
+
+// CategoryStore persists categories together with their monthly planned row.
+//
+// Archived categories keep their expenses, so history and analytics still
+// resolve them, but get no new planned rows, expenses or recurring
+// occurrences; creating any of those returns ErrCategoryArchived.
+type CategoryStore interface {
+	// ListCategories returns every category, archived ones included
+	ListCategories(ctx context.Context, workspaceID string) ([]Category, error)
+	// CreateCategory returns ErrUnknownCategory when the parent is missing
+	CreateCategory(ctx context.Context, workspaceID string, input CategoryInput) (string, error)
+	// UpdateCategory saves the planned amount as the plan effective from the
+	// current month, keeping the plans of earlier months
+	UpdateCategory(ctx context.Context, workspaceID, id string, input CategoryInput) error
+	// ArchiveCategory returns ErrHasSubcategories while the category has
+	// subcategories that are not archived. Archiving twice keeps the first date.
+	ArchiveCategory(ctx context.Context, workspaceID, id string) error
+	// UnarchiveCategory returns ErrCategoryArchived while the parent is
+	// archived
+	UnarchiveCategory(ctx context.Context, workspaceID, id string) error
+	// DeleteCategory removes the category and all its subcategories for
+	// good, with their expenses, alerts, thresholds and recurring expenses,
+	// and returns the receipt keys of the deleted expenses.
+	DeleteCategory(ctx context.Context, workspaceID, id string) ([]string, error)
+	// MoveCategory re-parents a category, with all its subcategories; a nil
+	// parentID makes it top-level. It returns ErrUnknownCategory when the
+	// parent is missing and ErrCategoryCycle when it is the category itself
+	// or one of its subcategories.
+	MoveCategory(ctx context.Context, workspaceID, id string, parentID *string) error
+}
+
+// CategoryPlan is the planned amount of a category from Month until the
+// month of its next plan
+type CategoryPlan struct {
+	Month         month.YearMonth `json:"month"`
+	PlannedAmount money.Amount    `json:"plannedAmount"`
+}
+
+// PlanStore versions the planned amounts of categories by the month they
+// take effect. A month before the first plan uses the first plan. Monthly
+// rows copy the plan effective in their reference month.
+type PlanStore interface {
+	// ListPlans returns the plans of a category, oldest first
+	ListPlans(ctx context.Context, workspaceID, categoryID string) ([]CategoryPlan, error)
+	// SetPlan saves the plan effective from month m and updates the planned
+	// amount of the category's monthly rows from m up to the next plan
+	SetPlan(ctx context.Context, workspaceID, categoryID string, m month.YearMonth, amount money.Amount) error
+	// PlannedAmounts returns the plan effective in month m of every category
+	// of the workspace, by category id
+	PlannedAmounts(ctx context.Context, workspaceID string, m month.YearMonth) (map[string]money.Amount, error)
+}
+
+// PaidType is a payment method such as credit card or pix
+type PaidType struct {
+	ID        string `json:"uuid"`
+	Type      string `json:"type"`
+	PaidColor string `json:"color"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// PaidTypeStore persists payment methods
+type PaidTypeStore interface {
+	ListPaidTypes(ctx context.Context, workspaceID string) ([]PaidType, error)
+	CreatePaidType(ctx context.Context, workspaceID string, paidType PaidType) (PaidType, error)
+}
+
+// Status is an expense state such as pending or paid
+type Status struct {
+	ID         string `json:"uuid"`
+	StatusName string `json:"statusName"`
+}
+
+// StatusStore persists expense states. The default statuses (pending, paid)
+// belong to no workspace and are visible in all of them.
+type StatusStore interface {
+	ListStatuses(ctx context.Context, workspaceID string) ([]Status, error)
+	CreateStatus(ctx context.Context, workspaceID, name string) (Status, error)
+	DeleteStatus(ctx context.Context, workspaceID, id string) error
+}
+
+// MonthTotals sums the expenses and incomes of a period. Net is what is
+// left of the income once the spent amount is paid. Unconverted counts the
+// expenses left out of Spent because no exchange rate covers their currency
+// on their payment date.
+type MonthTotals struct {
+	Planned     money.Amount
+	Spent       money.Amount
+	Difference  money.Amount
+	Income      money.Amount
+	Net         money.Amount
+	Unconverted int
+}
+
+// PendingPayment is an expense still waiting to be paid
+type PendingPayment struct {
+	ExpenseID      string       `json:"expenseId"`
+	CategoryID     string       `json:"categoryId"`
+	CategoryName   string       `json:"categoryName"`
+	ReferenceMonth string       `json:"referenceMonth"`
+	SpentAmount    money.Amount `json:"spentAmount"`
+	PlannedAmount  money.Amount `json:"plannedAmount"`
+	PaymentDate    string       `json:"paymentDate"`
+	Description    string       `json:"description"`
+	StatusName     string       `json:"statusName"`
+}
+
+// CategoryTotal sums the expenses of one category over a period. Planned
+// counts the category's planned rows only, one per month; Unconverted is
+// as in MonthTotals.
+type CategoryTotal struct {
+	CategoryID   string       `json:"categoryId"`
+	CategoryName string       `json:"categoryName"`
+	Planned      money.Amount `json:"plannedAmount"`
+	Spent        money.Amount `json:"spentAmount"`
+	Difference   money.Amount `json:"difference"`
+	Unconverted  int          `json:"unconvertedExpenses"`
+}
+
+// Delta compares a spent amount with the one of an earlier month. Percent is
+// nil when nothing was spent back then.
+type Delta struct {
+	Previous money.Amount `json:"previous"`
+	Change   money.Amount `json:"change"`
+	Percent  *float64     `json:"percent"`
+}
+
+// MonthPoint is one month of a time series. Planned counts the planned rows
+// only, so its sums match Totals for the same month, Unconverted included.
+type MonthPoint struct {
+	Month          month.YearMonth `json:"month"`
+	Planned        money.Amount    `json:"totalPlanned"`
+	Spent          money.Amount    `json:"totalSpent"`
+	Difference     money.Amount    `json:"totalDifference"`
+	Unconverted    int             `json:"unconvertedExpenses"`
+	MonthOverMonth Delta           `json:"monthOverMonth"`
+	YearOverYear   Delta           `json:"yearOverYear"`
+}
+
+// CategoryShare is a category's part of a month's spending. Planned counts
+// the planned row only and Unconverted the month's expenses only, as in
+// CategoryTotal; Percent is of the month's total spent, rounded to two
+// decimals.
+type CategoryShare struct {
+	CategoryID     string       `json:"categoryId"`
+	CategoryName   string       `json:"categoryName"`
+	Planned        money.Amount `json:"plannedAmount"`
+	Spent          money.Amount `json:"spentAmount"`
+	Difference     money.Amount `json:"difference"`
+	Unconverted    int          `json:"unconvertedExpenses"`
+	Percent        float64      `json:"percentOfTotal"`
+	MonthOverMonth Delta        `json:"monthOverMonth"`
+	YearOverYear   Delta        `json:"yearOverYear"`
+}
+
+// AnalyticsStore aggregates the expenses of a month for the dashboard
+type AnalyticsStore interface {
+	Totals(ctx context.Context, workspaceID string, m month.YearMonth) (MonthTotals, error)
+	PendingPayments(ctx context.Context, workspaceID string, m month.YearMonth) ([]PendingPayment, error)
+	// CategoryTotals sums every category with expenses between the months
+	// from and to, both inclusive, ordered by category name
+	CategoryTotals(ctx context.Context, workspaceID string, from, to month.YearMonth) ([]CategoryTotal, error)
+	// MonthlySeries returns one point per month from from to to, both
+	// inclusive, months without expenses included
+	MonthlySeries(ctx context.Context, workspaceID string, from, to month.YearMonth) ([]MonthPoint, error)
+	// CategoryBreakdown returns the categories with expenses in month m,
+	// largest spending first
+	CategoryBreakdown(ctx context.Context, workspaceID string, m month.YearMonth) ([]CategoryShare, error)
+}
+
+// Frequency is the unit a recurring expense repeats in
+type Frequency string
+
+const (
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly"
+	Yearly  Frequency = "yearly"
+)
+
+// Valid reports whether f is a known frequency
+func (f Frequency) Valid() bool {
+	return f == Weekly || f == Monthly || f == Yearly
+}
+
+// RecurringExpense repeats an expense every Every weeks, months or years
+// from StartDate until EndDate or until Occurrences have happened, whichever
+// comes first. Dates are midnight UTC.
+type RecurringExpense struct {
+	ID           string
+	WorkspaceID  string
+	CategoryID   string
+	CategoryName string
+	PaidID       *string
+	Amount       money.Amount
+	// Currency of Amount; empty for the base currency
+	Currency    money.Currency
+	Description string
+	Frequency   Frequency
+	Every       int
+	// DayOfMonth pins monthly and yearly occurrences to a day, moved back to
+	// the last day of shorter months; nil uses the day of StartDate
+	DayOfMonth  *int
+	StartDate   time.Time
+	EndDate     *time.Time
+	Occurrences *int
+	// MaterializedThrough is the last date already copied to monthly_expenses
+	MaterializedThrough *time.Time
+	Exceptions          []OccurrenceException
+	CreatedAt           time.Time
+}
+
+// OccurrenceException skips one occurrence or replaces some of its values
+type OccurrenceException struct {
+	Date        time.Time
+	Skip        bool
+	Amount      *money.Amount
+	Description *string
+	PaidID      *string
+}
+
+// Occurrence is a due occurrence, exceptions applied, ready to become a
+// pending expense
+type Occurrence struct {
+	Date        time.Time
+	Amount      money.Amount
+	Currency    money.Currency
+	Description string
+	PaidID      *string
+}
+
+// RecurringStore persists recurring expenses and copies their occurrences
+// into monthly_expenses
+type RecurringStore interface {
+	ListRecurring(ctx context.Context, workspaceID string) ([]RecurringExpense, error)
+	GetRecurring(ctx context.Context, workspaceID, id string) (RecurringExpense, error)
+	// CreateRecurring and UpdateRecurring return ErrUnknownCategory or
+	// ErrUnknownPaidType for references outside the workspace. Updates keep
+	// MaterializedThrough and the exceptions.
+	CreateRecurring(ctx context.Context, workspaceID string, r RecurringExpense) (RecurringExpense, error)
+	UpdateRecurring(ctx context.Context, workspaceID, id string, r RecurringExpense) (RecurringExpense, error)
+	DeleteRecurring(ctx context.Context, workspaceID, id string) error
+	// SetOccurrenceException replaces the exception of e.Date
+	SetOccurrenceException(ctx context.Context, workspaceID, recurringID string, e OccurrenceException) error
+	DeleteOccurrenceException(ctx context.Context, workspaceID, recurringID string, date time.Time) error
+
+	// DueRecurring lists, across every workspace, the recurring expenses not
+	// materialised through the given date yet
+	DueRecurring(ctx context.Context, through time.Time) ([]RecurringExpense, error)
+	// MaterializeRecurring creates a pending expense per occurrence, skipping
+	// those already created, moves MaterializedThrough to through and returns
+	// the ids of the new expenses
+	MaterializeRecurring(ctx context.Context, r RecurringExpense, occurrences []Occurrence, through time.Time) ([]string, error)
+}
+
+// Income is money coming in for a month. ReceivedDate is nil until it
+// arrives; Recurring marks incomes expected again every month.
+//
+// Amount is in the workspace's base currency, converted like an expense with
+// the rate of the received (or else expected) date; OriginalAmount is in
+// Currency.
+type Income struct {
+	IncomeID       string          `json:"incomeId"`
+	Source         string          `json:"source"`
+	Amount         *money.Amount   `json:"amount"`
+	OriginalAmount money.Amount    `json:"originalAmount"`
+	Currency       money.Currency  `json:"currency"`
+	ReferenceMonth month.YearMonth `json:"referenceMonth"`
+	ExpectedDate   string          `json:"expectedDate"`
+	ReceivedDate   *string         `json:"receivedDate"`
+	Recurring      bool            `json:"recurring"`
+	Description    *string         `json:"description"`
+	CreatedAt      time.Time       `json:"createdAt"`
+}
+
+// IncomeInput carries every editable field of an income
+type IncomeInput struct {
+	Source         string
+	Amount         money.Amount
+	ReferenceMonth month.YearMonth
+	ExpectedDate   time.Time
+	ReceivedDate   *time.Time
+	Recurring      bool
+	Description    string
+	// Currency of Amount; empty for the base currency
+	Currency money.Currency
+}
+
+// IncomeStore persists incomes
+type IncomeStore interface {
+	// ListIncomes returns the incomes of month m, or of every month when m
+	// is zero, by expected date
+	ListIncomes(ctx context.Context, workspaceID string, m month.YearMonth) ([]Income, error)
+	GetIncome(ctx context.Context, workspaceID, id string) (Income, error)
+	CreateIncome(ctx context.Context, workspaceID string, input IncomeInput) (Income, error)
+	UpdateIncome(ctx context.Context, workspaceID, id string, input IncomeInput) (Income, error)
+	DeleteIncome(ctx context.Context, workspaceID, id string) error
+}
+
+// ExchangeRate says one unit of From is worth Rate units of To from Date
+// (YYYY-MM-DD) until the next rate of the pair
+type ExchangeRate struct {
+	From money.Currency `json:"from"`
+	To   money.Currency `json:"to"`
+	Date string         `json:"date"`
+	Rate money.Rate     `json:"rate"`
+}
+
+// CurrencyStore keeps the base currency of each workspace and the exchange
+// rates its amounts are converted with. A conversion uses the latest rate of
+// the pair on or before the date, or the opposite pair when only that one is
+// known.
+type CurrencyStore interface {
+	// BaseCurrency returns the workspace's base currency, or the store's
+	// default when it has none
+	BaseCurrency(ctx context.Context, workspaceID string) (money.Currency, error)
+	// SetBaseCurrency changes the base currency. Amounts recorded without a
+	// currency keep meaning the previous one.
+	SetBaseCurrency(ctx context.Context, workspaceID string, currency money.Currency) error
+	// ImportRates adds or replaces rates and returns how many were saved
+	ImportRates(ctx context.Context, workspaceID string, rates []ExchangeRate) (int, error)
+	// ListRates returns the rates of the workspace, newest first, optionally
+	// only those from and to the given currencies
+	ListRates(ctx context.Context, workspaceID string, from, to money.Currency) ([]ExchangeRate, error)
+}
+
+// RolloverStore creates the planned rows of a month. It is a maintenance
+// job and works across every workspace.
+type RolloverStore interface {
+	// EnsurePlannedExpenses creates a pending planned row for every category
+	// that existed by month m and has none yet, returning how many were
+	// created. Calling it twice for the same month is harmless.
+	EnsurePlannedExpenses(ctx context.Context, m month.YearMonth) (int, error)
+}
+
+// RuleMatch holds the conditions of a rule; nil conditions always match.
+// Description is a regular expression matched case-insensitively, the
+// amount bounds are inclusive and a DayFrom after DayTo wraps around the end
+// of the month (e.g. 25 to 5).
+type RuleMatch struct {
+	Description *string       `json:"description"`
+	MinAmount   *money.Amount `json:"minAmount"`
+	MaxAmount   *money.Amount `json:"maxAmount"`
+	PaidID      *string       `json:"paidId"`
+	DayFrom     *int          `json:"dayFrom"`
+	DayTo       *int          `json:"dayTo"`
+}
+
+// RuleActions are the fields a matching rule fills in
+type RuleActions struct {
+	CategoryID *string `json:"categoryId"`
+	PaidID     *string `json:"paidId"`
+	StatusID   *string `json:"statusId"`
+}
+
+// Rule categorises expenses that arrive without a category, paid type or
+// status. Rules run by ascending Priority, then by age.
+type Rule struct {
+	ID        string      `json:"uuid"`
+	Name      string      `json:"name"`
+	Priority  int         `json:"priority"`
+	Enabled   bool        `json:"enabled"`
+	Match     RuleMatch   `json:"match"`
+	Set       RuleActions `json:"set"`
+	CreatedAt string      `json:"createdAt"`
+}
+
+// RuleStore persists categorisation rules. Create and Update return
+// ErrUnknownCategory, ErrUnknownPaidType or ErrUnknownStatus when a rule
+// references a missing row.
+type RuleStore interface {
+	// ListRules returns the rules in evaluation order
+	ListRules(ctx context.Context, workspaceID string) ([]Rule, error)
+	GetRule(ctx context.Context, workspaceID, id string) (Rule, error)
+	CreateRule(ctx context.Context, workspaceID string, rule Rule) (Rule, error)
+	UpdateRule(ctx context.Context, workspaceID, id string, rule Rule) (Rule, error)
+	DeleteRule(ctx context.Context, workspaceID, id string) error
+}
+
+// Role is what a member may do inside a workspace
+type Role string
+
+const (
+	// RoleViewer may only read
+	RoleViewer Role = "viewer"
+	// RoleEditor may also create, change and delete budget data
+	RoleEditor Role = "editor"
+	// RoleOwner may also manage members and invites
+	RoleOwner Role = "owner"
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// Valid reports whether r is one of the known roles
+func (r Role) Valid() bool {
+	return roleRank[r] > 0
+}
+
+// Allows reports whether r grants at least the permissions of min
+func (r Role) Allows(min Role) bool {
+	return r.Valid() && roleRank[r] >= roleRank[min]
+}
+
+// Workspace is a budget shared by its members. Role is the caller's role
+// when the workspace is listed for a user.
+type Workspace struct {
+	ID           string         `json:"uuid"`
+	Name         string         `json:"name"`
+	Role         Role           `json:"role,omitempty"`
+	BaseCurrency money.Currency `json:"baseCurrency"`
+	CreatedAt    string         `json:"createdAt"`
+}
+
+// Member is a user with access to a workspace
+type Member struct {
+	UserID    string `json:"userId"`
+	Email     string `json:"email"`
+	Role      Role   `json:"role"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// Invite grants a role to whoever signs in with Email and accepts it
+type Invite struct {
+	ID          string `json:"uuid"`
+	WorkspaceID string `json:"workspaceId"`
+	Email       string `json:"email"`
+	Role        Role   `json:"role"`
+	InvitedBy   string `json:"invitedBy"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// WorkspaceStore persists workspaces, their members and pending invites.
+// Emails are compared case-insensitively.
+type WorkspaceStore interface {
+	// EnsurePersonalWorkspace creates the user's personal workspace, whose id
+	// is the user id, the first time it is needed
+	EnsurePersonalWorkspace(ctx context.Context, userID, email string) error
+	ListWorkspaces(ctx context.Context, userID string) ([]Workspace, error)
+	CreateWorkspace(ctx context.Context, userID, email, name string) (Workspace, error)
+	// MemberRole returns ErrNotFound when the user is not a member
+	MemberRole(ctx context.Context, workspaceID, userID string) (Role, error)
+
+	ListMembers(ctx context.Context, workspaceID string) ([]Member, error)
+	SetMemberRole(ctx context.Context, workspaceID, userID string, role Role) error
+	RemoveMember(ctx context.Context, workspaceID, userID string) error
+
+	// CreateInvite returns ErrConflict when the email is already invited
+	CreateInvite(ctx context.Context, workspaceID, invitedBy, email string, role Role) (Invite, error)
+	ListInvites(ctx context.Context, workspaceID string) ([]Invite, error)
+	DeleteInvite(ctx context.Context, workspaceID, id string) error
+	// ListInvitesFor returns the invites addressed to email in any workspace
+	ListInvitesFor(ctx context.Context, email string) ([]Invite, error)
+	// AcceptInvite turns an invite addressed to email into a membership and
+	// returns the workspace joined
+	AcceptInvite(ctx context.Context, id, userID, email string) (Workspace, error)
+}
@@ -0,0 +1,118 @@
+// Package workspace selects the workspace (shared budget) a request acts on
+// and enforces the caller's role in it.
+//
+// The workspace comes from the ":workspace" path parameter, the
+// X-Workspace-ID header or, when neither is given, the caller's personal
+// workspace. Callers who are not members get a 404, exactly like ids from
+// another workspace.
+package workspace
+
+import (
+	"context"
+	"errors"
+	"go-sheet/auth"
+	"go-sheet/money"
+	"go-sheet/store"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header selects the workspace when the route has no ":workspace" parameter
+const Header = "X-Workspace-ID"
+
+// Param is the path parameter that selects the workspace
+const Param = "workspace"
+
+const (
+	idKey       = "workspace.id"
+	roleKey     = "workspace.role"
+	currencyKey = "workspace.currency"
+)
+
+// Store is what Middleware reads memberships and base currencies from
+type Store interface {
+	store.WorkspaceStore
+	BaseCurrency(ctx context.Context, workspaceID string) (money.Currency, error)
+}
+
+// Middleware resolves the selected workspace, the caller's role in it and
+// its base currency. It must run after auth.Middleware.
+func Middleware(s Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		identity, _ := auth.IdentityFrom(ctx)
+
+		workspaceID := ctx.Param(Param)
+		if workspaceID == "" {
+			workspaceID = ctx.GetHeader(Header)
+		}
+		if workspaceID == "" {
+			workspaceID = identity.UserID
+		}
+
+		role, err := s.MemberRole(ctx.Request.Context(), workspaceID, identity.UserID)
+		if errors.Is(err, store.ErrNotFound) && workspaceID == identity.UserID {
+			// Primeiro acesso: cria o workspace pessoal
+			if err = s.EnsurePersonalWorkspace(ctx.Request.Context(), identity.UserID, identity.Email); err == nil {
+				role, err = s.MemberRole(ctx.Request.Context(), workspaceID, identity.UserID)
+			}
+		}
+		var currency money.Currency
+		if err == nil {
+			currency, err = s.BaseCurrency(ctx.Request.Context(), workspaceID)
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Workspace not found",
+			})
+			return
+		} else if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Error resolving workspace",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		ctx.Set(idKey, workspaceID)
+		ctx.Set(roleKey, role)
+		ctx.Set(currencyKey, currency)
+		ctx.Next()
+	}
+}
+
+// Require rejects callers whose role in the workspace is below min
+func Require(min store.Role) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !Role(ctx).Allows(min) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "This action requires the " + string(min) + " role",
+			})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// ID returns the workspace selected by Middleware
+func ID(ctx *gin.Context) string {
+	return ctx.GetString(idKey)
+}
+
+// Currency returns the base currency of the selected workspace, the one
+// amounts are reported in
+func Currency(ctx *gin.Context) money.Currency {
+	currency, _ := ctx.Get(currencyKey)
+	c, _ := currency.(money.Currency)
+	return c
+}
+
+// Role returns the caller's role in the selected workspace
+func Role(ctx *gin.Context) store.Role {
+	role, _ := ctx.Get(roleKey)
+	r, _ := role.(store.Role)
+	return r
+}